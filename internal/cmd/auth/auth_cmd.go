@@ -0,0 +1,30 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package auth
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/cmd/auth/status"
+)
+
+func Cmd() *cobra.Command {
+	result := &cobra.Command{
+		Use:   "auth",
+		Short: "Inspect authentication",
+		Args:  cobra.NoArgs,
+	}
+	result.AddCommand(status.Cmd())
+	return result
+}
@@ -0,0 +1,315 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/innabox/fulfillment-common/logging"
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/exit"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// Possible output formats:
+const (
+	outputFormatText = "text"
+	outputFormatJson = "json"
+	outputFormatYaml = "yaml"
+)
+
+// Possible values of the status, and the corresponding exit codes. Note that exit code 1 is used for any other error,
+// for example when the configuration file can't be loaded.
+const (
+	statusOk          = "ok"
+	statusNotLoggedIn = "not_logged_in"
+	statusExpired     = "expired"
+
+	exitCodeNotLoggedIn = exit.Error(2)
+	exitCodeExpired     = exit.Error(3)
+)
+
+// oauthInvalidGrant is the OAuth error code that the server returns when the refresh token or the credentials are no
+// longer valid.
+const oauthInvalidGrant = "invalid_grant"
+
+// Possible values of the TLS mode:
+const (
+	tlsPlaintext = "plaintext"
+	tlsInsecure  = "insecure"
+	tlsVerified  = "verified"
+)
+
+// Cmd creates the 'auth status' command.
+func Cmd() *cobra.Command {
+	return newCmd("status [OPTION]...")
+}
+
+// WhoamiCmd creates the 'whoami' command, which is the same than 'auth status' but intended to be used as a top level
+// command.
+func WhoamiCmd() *cobra.Command {
+	return newCmd("whoami [OPTION]...")
+}
+
+func newCmd(use string) *cobra.Command {
+	runner := &runnerContext{}
+	result := &cobra.Command{
+		Use:   use,
+		Short: "Show who you are logged in as, and to what server",
+		Long: "Show the identity, server and token details of the selected context. The exit code is 0 if " +
+			"there is a usable token, 2 if there is no server or token, 3 if the token has expired or the " +
+			"server refuses to renew it, and 1 for any other error.",
+		Args: cobra.NoArgs,
+		RunE: runner.run,
+	}
+	flags := result.Flags()
+	flags.StringVarP(
+		&runner.args.format,
+		"output",
+		"o",
+		outputFormatText,
+		fmt.Sprintf(
+			"Output format, one of '%s', '%s' or '%s'.",
+			outputFormatText, outputFormatJson, outputFormatYaml,
+		),
+	)
+	return result
+}
+
+type runnerContext struct {
+	logger  *slog.Logger
+	console *terminal.Console
+	args    struct {
+		format string
+	}
+}
+
+// statusData contains the information that is displayed by the command.
+type statusData struct {
+	Context           string     `json:"context"`
+	Status            string     `json:"status"`
+	Address           string     `json:"address,omitempty"`
+	Tls               string     `json:"tls,omitempty"`
+	ClientCertificate bool       `json:"client_certificate,omitempty"`
+	Issuer            string     `json:"issuer,omitempty"`
+	Flow              string     `json:"flow,omitempty"`
+	Subject           string     `json:"subject,omitempty"`
+	Email             string     `json:"email,omitempty"`
+	PreferredUsername string     `json:"preferred_username,omitempty"`
+	Groups            []string   `json:"groups,omitempty"`
+	Audience          []string   `json:"audience,omitempty"`
+	Expiry            *time.Time `json:"expiry,omitempty"`
+	RefreshToken      bool       `json:"refresh_token"`
+	Error             string     `json:"error,omitempty"`
+}
+
+func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	var err error
+
+	// Get the context:
+	ctx := cmd.Context()
+
+	// Get the logger and the console:
+	c.logger = logging.LoggerFromContext(ctx)
+	c.console = terminal.ConsoleFromContext(ctx)
+
+	// Check the flags:
+	switch c.args.format {
+	case outputFormatText, outputFormatJson, outputFormatYaml:
+	default:
+		return fmt.Errorf(
+			"unknown output format '%s', should be '%s', '%s' or '%s'",
+			c.args.format, outputFormatText, outputFormatJson, outputFormatYaml,
+		)
+	}
+
+	// Load the templates for the console messages:
+	err = c.console.AddTemplates(templatesFS, "templates")
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	// Get the configuration:
+	cfg, err := config.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Calculate and display the status:
+	data, err := c.status(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	switch c.args.format {
+	case outputFormatJson:
+		c.console.RenderJson(ctx, data)
+	case outputFormatYaml:
+		c.console.RenderYaml(ctx, data)
+	default:
+		if data.Status == statusNotLoggedIn {
+			c.console.Render(ctx, "auth_not_logged_in.txt", data)
+		} else {
+			c.console.Render(ctx, "auth_status.txt", data)
+		}
+	}
+
+	// Return the exit code that corresponds to the status:
+	switch data.Status {
+	case statusNotLoggedIn:
+		return exitCodeNotLoggedIn
+	case statusExpired:
+		return exitCodeExpired
+	default:
+		return nil
+	}
+}
+
+// status calculates the status of the given configuration, requesting a new token if needed.
+func (c *runnerContext) status(ctx context.Context, cfg *config.Config) (result *statusData, err error) {
+	data := &statusData{
+		Context: cfg.Name(),
+		Address: cfg.Address,
+		Issuer:  cfg.OauthIssuer,
+		Flow:    string(cfg.OAuthFlow),
+	}
+	if cfg.Address == "" {
+		data.Status = statusNotLoggedIn
+		result = data
+		return
+	}
+	switch {
+	case cfg.Plaintext:
+		data.Tls = tlsPlaintext
+	case cfg.Insecure:
+		data.Tls = tlsInsecure
+	default:
+		data.Tls = tlsVerified
+	}
+	data.ClientCertificate = cfg.ClientCert.Name != "" || cfg.ClientCert.Content != ""
+
+	// Get the token, this will renew it if needed:
+	source, err := cfg.TokenSource(ctx)
+	if err != nil {
+		return
+	}
+	if source == nil {
+		data.Status = statusNotLoggedIn
+		result = data
+		return
+	}
+	token, tokenErr := source.Token(ctx)
+	if tokenErr != nil {
+		c.logger.DebugContext(
+			ctx,
+			"Failed to get token",
+			slog.Any("error", tokenErr),
+		)
+
+		// Only report that the token has expired if the server rejected the grant or the token is known to
+		// have expired. Other errors, like failing to connect to the server, say nothing about the token.
+		expired := !cfg.TokenExpiry.IsZero() && cfg.TokenExpiry.Before(time.Now())
+		if !expired && !isInvalidGrant(tokenErr) {
+			err = fmt.Errorf("failed to get token: %w", tokenErr)
+			return
+		}
+		data.Status = statusExpired
+		data.Error = fmt.Sprintf("The token has expired and it can't be renewed: %v", tokenErr)
+		data.RefreshToken = cfg.RefreshToken != ""
+		if !cfg.TokenExpiry.IsZero() {
+			data.Expiry = &cfg.TokenExpiry
+		}
+		result = data
+		return
+	}
+	if token == nil || token.Access == "" {
+		data.Status = statusNotLoggedIn
+		result = data
+		return
+	}
+	data.RefreshToken = token.Refresh != ""
+	if !token.Expiry.IsZero() {
+		data.Expiry = &token.Expiry
+	}
+
+	// Extract the identity from the token. Note that the token may not be a JSON web token, as the protocol
+	// doesn't require that, so failing to parse it isn't an error.
+	parser := jwt.NewParser(jwt.WithJSONNumber())
+	claims := jwt.MapClaims{}
+	_, _, parseErr := parser.ParseUnverified(token.Access, claims)
+	if parseErr != nil {
+		c.logger.DebugContext(
+			ctx,
+			"Failed to parse token as a JSON web token",
+			slog.Any("error", parseErr),
+		)
+	} else {
+		c.extractClaims(data, claims)
+	}
+
+	// Static tokens don't have an explicit expiry, so we need to check the one from the claims:
+	if data.Expiry != nil && data.Expiry.Before(time.Now()) {
+		data.Status = statusExpired
+		data.Error = "The token has expired, run the 'login' command to get a new one."
+	} else {
+		data.Status = statusOk
+	}
+	result = data
+	return
+}
+
+// isInvalidGrant checks if the given error, or any of the errors that it wraps, is an OAuth 'invalid_grant' error. The
+// type of those errors isn't exported by the OAuth package, so this checks the text, which starts with the code.
+func isInvalidGrant(err error) bool {
+	for err != nil {
+		if strings.HasPrefix(err.Error(), oauthInvalidGrant+":") {
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
+
+// extractClaims copies to the status the values of the claims that describe the identity of the user.
+func (c *runnerContext) extractClaims(data *statusData, claims jwt.MapClaims) {
+	data.Subject, _ = claims.GetSubject()
+	data.Email, _ = claims["email"].(string)
+	data.PreferredUsername, _ = claims["preferred_username"].(string)
+	groups, _ := claims["groups"].([]any)
+	for _, group := range groups {
+		text, ok := group.(string)
+		if ok {
+			data.Groups = append(data.Groups, text)
+		}
+	}
+	audience, _ := claims.GetAudience()
+	data.Audience = audience
+	if data.Expiry == nil {
+		expiry, _ := claims.GetExpirationTime()
+		if expiry != nil {
+			data.Expiry = &expiry.Time
+		}
+	}
+}
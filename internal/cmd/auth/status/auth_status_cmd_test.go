@@ -0,0 +1,241 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/innabox/fulfillment-common/logging"
+	"github.com/innabox/fulfillment-common/oauth"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/exit"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+var _ = Describe("Status command", func() {
+	var (
+		ctx    context.Context
+		output *bytes.Buffer
+	)
+
+	// makeToken creates a signed JSON web token with the given claims.
+	makeToken := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		result, err := token.SignedString([]byte("my_key"))
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	// saveConfig saves the default context, after modifying it with the given function.
+	saveConfig := func(modify func(cfg *config.Config)) {
+		cfg, err := config.New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "api.example.com:443"
+		modify(cfg)
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	// run runs the command with the given arguments.
+	run := func(args ...string) error {
+		cmd := Cmd()
+		cmd.SetArgs(args)
+		return cmd.ExecuteContext(ctx)
+	}
+
+	// parseOutput parses the JSON output of the command.
+	parseOutput := func() map[string]any {
+		var result map[string]any
+		err := json.Unmarshal(output.Bytes(), &result)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	BeforeEach(func() {
+		logger := slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		output = &bytes.Buffer{}
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = terminal.ConsoleIntoContext(ctx, console)
+		ctx = config.LocationIntoContext(ctx, filepath.Join(GinkgoT().TempDir(), "config.json"))
+	})
+
+	It("Reports that there is no login", func() {
+		err := run()
+		Expect(err).To(Equal(exit.Error(2)))
+		Expect(output.String()).To(ContainSubstring("Not logged in to context 'default'"))
+	})
+
+	It("Reports that there is no login in JSON format", func() {
+		err := run("-o", "json")
+		Expect(err).To(Equal(exit.Error(2)))
+		Expect(parseOutput()).To(HaveKeyWithValue("status", "not_logged_in"))
+	})
+
+	It("Reports the identity from the token", func() {
+		expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+		saveConfig(func(cfg *config.Config) {
+			cfg.AccessToken = makeToken(jwt.MapClaims{
+				"sub":                "1234",
+				"email":              "mary@example.com",
+				"preferred_username": "mary",
+				"groups":             []string{"admins", "users"},
+				"aud":                "fulfillment",
+				"exp":                expiry.Unix(),
+			})
+		})
+		err := run("-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		data := parseOutput()
+		Expect(data).To(HaveKeyWithValue("context", "default"))
+		Expect(data).To(HaveKeyWithValue("status", "ok"))
+		Expect(data).To(HaveKeyWithValue("address", "api.example.com:443"))
+		Expect(data).To(HaveKeyWithValue("tls", "verified"))
+		Expect(data).To(HaveKeyWithValue("subject", "1234"))
+		Expect(data).To(HaveKeyWithValue("email", "mary@example.com"))
+		Expect(data).To(HaveKeyWithValue("preferred_username", "mary"))
+		Expect(data).To(HaveKeyWithValue("groups", ConsistOf("admins", "users")))
+		Expect(data).To(HaveKeyWithValue("audience", ConsistOf("fulfillment")))
+		Expect(data).To(HaveKeyWithValue("expiry", expiry.Format(time.RFC3339)))
+		Expect(data).To(HaveKeyWithValue("refresh_token", false))
+	})
+
+	It("Reports the identity in text format", func() {
+		saveConfig(func(cfg *config.Config) {
+			cfg.Plaintext = true
+			cfg.AccessToken = makeToken(jwt.MapClaims{
+				"sub":    "1234",
+				"groups": []string{"admins", "users"},
+			})
+		})
+		err := run()
+		Expect(err).ToNot(HaveOccurred())
+		text := output.String()
+		Expect(text).To(ContainSubstring("Status: ok\n"))
+		Expect(text).To(ContainSubstring("TLS: plaintext\n"))
+		Expect(text).To(ContainSubstring("Subject: 1234\n"))
+		Expect(text).To(ContainSubstring("Groups: admins, users\n"))
+		Expect(text).To(ContainSubstring("Refresh token: no\n"))
+		Expect(text).ToNot(ContainSubstring("Email:"))
+	})
+
+	It("Reports that the token has expired", func() {
+		saveConfig(func(cfg *config.Config) {
+			cfg.AccessToken = makeToken(jwt.MapClaims{
+				"sub": "1234",
+				"exp": time.Now().Add(-time.Hour).Unix(),
+			})
+		})
+		err := run("-o", "json")
+		Expect(err).To(Equal(exit.Error(3)))
+		Expect(parseOutput()).To(HaveKeyWithValue("status", "expired"))
+	})
+
+	It("Reports that the token has expired and can't be renewed", func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		DeferCleanup(server.Close)
+		saveConfig(func(cfg *config.Config) {
+			cfg.OAuthFlow = oauth.CredentialsFlow
+			cfg.OauthIssuer = server.URL
+			cfg.OAuthClientId = "my_client"
+			cfg.OAuthClientSecret = "my_secret"
+			cfg.AccessToken = makeToken(jwt.MapClaims{
+				"sub": "1234",
+			})
+			cfg.TokenExpiry = time.Now().Add(-time.Hour)
+		})
+		err := run("-o", "json")
+		Expect(err).To(Equal(exit.Error(3)))
+		data := parseOutput()
+		Expect(data).To(HaveKeyWithValue("status", "expired"))
+		Expect(data).To(HaveKeyWithValue("flow", "credentials"))
+		Expect(data).To(HaveKey("error"))
+	})
+
+	It("Reports that the token has expired if the server rejects the grant", func() {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/.well-known/oauth-authorization-server":
+				err := json.NewEncoder(w).Encode(map[string]any{
+					"issuer":         server.URL,
+					"token_endpoint": server.URL + "/token",
+				})
+				Expect(err).ToNot(HaveOccurred())
+			case "/token":
+				w.WriteHeader(http.StatusBadRequest)
+				err := json.NewEncoder(w).Encode(map[string]any{
+					"error":             "invalid_grant",
+					"error_description": "Client credentials are invalid",
+				})
+				Expect(err).ToNot(HaveOccurred())
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+		saveConfig(func(cfg *config.Config) {
+			cfg.OAuthFlow = oauth.CredentialsFlow
+			cfg.OauthIssuer = server.URL
+			cfg.OAuthClientId = "my_client"
+			cfg.OAuthClientSecret = "my_secret"
+			cfg.AccessToken = makeToken(jwt.MapClaims{
+				"sub": "1234",
+			})
+			cfg.TokenExpiry = time.Now().Add(10 * time.Second)
+		})
+		err := run("-o", "json")
+		Expect(err).To(Equal(exit.Error(3)))
+		data := parseOutput()
+		Expect(data).To(HaveKeyWithValue("status", "expired"))
+		Expect(data).To(HaveKeyWithValue("error", ContainSubstring("invalid_grant")))
+	})
+
+	It("Fails if the token can't be renewed for other reasons", func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		DeferCleanup(server.Close)
+		saveConfig(func(cfg *config.Config) {
+			cfg.OAuthFlow = oauth.CredentialsFlow
+			cfg.OauthIssuer = server.URL
+			cfg.OAuthClientId = "my_client"
+			cfg.OAuthClientSecret = "my_secret"
+			cfg.AccessToken = makeToken(jwt.MapClaims{
+				"sub": "1234",
+			})
+			cfg.TokenExpiry = time.Now().Add(10 * time.Second)
+		})
+		err := run("-o", "json")
+		Expect(err).To(MatchError(ContainSubstring("failed to get token")))
+		Expect(output.String()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,46 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/deletecontext"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/edit"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/getcontexts"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/renamecontext"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/set"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/unset"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/usecontext"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/validate"
+	"github.com/innabox/fulfillment-cli/internal/cmd/config/view"
+)
+
+func Cmd() *cobra.Command {
+	result := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration",
+		Args:  cobra.NoArgs,
+	}
+	result.AddCommand(deletecontext.Cmd())
+	result.AddCommand(edit.Cmd())
+	result.AddCommand(getcontexts.Cmd())
+	result.AddCommand(renamecontext.Cmd())
+	result.AddCommand(set.Cmd())
+	result.AddCommand(unset.Cmd())
+	result.AddCommand(usecontext.Cmd())
+	result.AddCommand(validate.Cmd())
+	result.AddCommand(view.Cmd())
+	return result
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package deletecontext
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+func Cmd() *cobra.Command {
+	runner := &runnerContext{}
+	result := &cobra.Command{
+		Use:   "delete-context NAME",
+		Short: "Delete a configuration context",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runner.run,
+	}
+	return result
+}
+
+type runnerContext struct {
+}
+
+func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	// Get the context:
+	ctx := cmd.Context()
+
+	// Get the console:
+	console := terminal.ConsoleFromContext(ctx)
+
+	// Delete the context:
+	name := args[0]
+	err := config.DeleteContext(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete context: %w", err)
+	}
+	console.Printf(ctx, "Deleted context '%s'.\n", name)
+	return nil
+}
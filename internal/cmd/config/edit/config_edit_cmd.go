@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/innabox/fulfillment-common/logging"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+func Cmd() *cobra.Command {
+	runner := &runnerContext{}
+	result := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the configuration",
+		Long:  "Edit the settings of the selected configuration context using the editor.",
+		Args:  cobra.NoArgs,
+		RunE:  runner.run,
+	}
+	return result
+}
+
+type runnerContext struct {
+}
+
+func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	// Get the context:
+	ctx := cmd.Context()
+
+	// Get the logger and the console:
+	logger := logging.LoggerFromContext(ctx)
+	console := terminal.ConsoleFromContext(ctx)
+
+	// Load the configuration of the selected context:
+	file, err := config.LoadFile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg := file.Selected(ctx)
+
+	// Render the configuration as YAML, using the same names that are used in the JSON file:
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var value any
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	err = encoder.Encode(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	original := buffer.Bytes()
+
+	// Run the editor:
+	editor, err := terminal.NewEditor().
+		SetLogger(logger).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to create editor: %w", err)
+	}
+	modified, err := editor.Edit(ctx, fmt.Sprintf("config-%s.yaml", cfg.Name()), original)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(original, modified) {
+		console.Printf(ctx, "Configuration of context '%s' not changed.\n", cfg.Name())
+		return nil
+	}
+
+	// Parse the result, rejecting unknown settings as they are most likely typos:
+	value = nil
+	err = yaml.Unmarshal(modified, &value)
+	if err != nil {
+		return fmt.Errorf("failed to parse modified configuration: %w", err)
+	}
+	data, err = json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to parse modified configuration: %w", err)
+	}
+	updated := cfg.Blank()
+	if value != nil {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(updated)
+		if err != nil {
+			return fmt.Errorf("failed to parse modified configuration: %w", err)
+		}
+	}
+
+	// Save the result:
+	err = config.Save(ctx, updated)
+	if err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	console.Printf(ctx, "Configuration of context '%s' changed.\n", cfg.Name())
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package getcontexts
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+func Cmd() *cobra.Command {
+	runner := &runnerContext{}
+	result := &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List configuration contexts",
+		Args:  cobra.NoArgs,
+		RunE:  runner.run,
+	}
+	return result
+}
+
+type runnerContext struct {
+}
+
+func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	// Get the context:
+	ctx := cmd.Context()
+
+	// Get the console:
+	console := terminal.ConsoleFromContext(ctx)
+
+	// Load the configuration file:
+	file, err := config.LoadFile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	names := file.Names()
+	if len(names) == 0 {
+		console.Printf(ctx, "There are no contexts, run the 'login' command to create one.\n")
+		return nil
+	}
+
+	// Render the table, marking the current context with an asterisk:
+	writer := tabwriter.NewWriter(console, 0, 0, 2, ' ', 0)
+	defer writer.Flush()
+	fmt.Fprintf(writer, "CURRENT\tNAME\tADDRESS\n")
+	for _, name := range names {
+		current := ""
+		if name == file.CurrentContext {
+			current = "*"
+		}
+		address := file.Contexts[name].Address
+		if address == "" {
+			address = "-"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", current, name, address)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package renamecontext
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+func Cmd() *cobra.Command {
+	runner := &runnerContext{}
+	result := &cobra.Command{
+		Use:   "rename-context OLD NEW",
+		Short: "Rename a configuration context",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runner.run,
+	}
+	return result
+}
+
+type runnerContext struct {
+}
+
+func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	// Get the context:
+	ctx := cmd.Context()
+
+	// Get the console:
+	console := terminal.ConsoleFromContext(ctx)
+
+	// Rename the context:
+	from, to := args[0], args[1]
+	err := config.RenameContext(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to rename context: %w", err)
+	}
+	console.Printf(ctx, "Renamed context '%s' to '%s'.\n", from, to)
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package set
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+func Cmd() *cobra.Command {
+	runner := &runnerContext{}
+	result := &cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Change a configuration setting",
+		Long: fmt.Sprintf(
+			"Change a setting of the selected configuration context. The valid keys are '%s'.",
+			strings.Join(config.Keys(), "', '"),
+		),
+		Args: cobra.ExactArgs(2),
+		RunE: runner.run,
+	}
+	return result
+}
+
+type runnerContext struct {
+}
+
+func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	// Get the context:
+	ctx := cmd.Context()
+
+	// Get the console:
+	console := terminal.ConsoleFromContext(ctx)
+
+	// Load the configuration of the selected context. Note that we don't use the config.Load function because that
+	// fails if the CA files are wrong, and that is one of the things that the user may want to fix.
+	file, err := config.LoadFile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg := file.Selected(ctx)
+
+	// Change the setting and save the result:
+	key, value := args[0], args[1]
+	err = cfg.Set(key, value)
+	if err != nil {
+		return err
+	}
+	err = config.Save(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	console.Printf(ctx, "Setting '%s' of context '%s' changed.\n", key, cfg.Name())
+	return nil
+}
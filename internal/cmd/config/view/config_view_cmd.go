@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+// Possible output formats:
+const (
+	outputFormatJson = "json"
+	outputFormatYaml = "yaml"
+)
+
+func Cmd() *cobra.Command {
+	runner := &runnerContext{}
+	result := &cobra.Command{
+		Use:   "view [OPTION]...",
+		Short: "Display the configuration",
+		Args:  cobra.NoArgs,
+		RunE:  runner.run,
+	}
+	flags := result.Flags()
+	flags.StringVarP(
+		&runner.args.format,
+		"output",
+		"o",
+		outputFormatYaml,
+		fmt.Sprintf(
+			"Output format, one of '%s' or '%s'.",
+			outputFormatJson, outputFormatYaml,
+		),
+	)
+	flags.BoolVar(
+		&runner.args.minify,
+		"minify",
+		false,
+		"Display only the selected context.",
+	)
+	flags.BoolVar(
+		&runner.args.raw,
+		"raw",
+		false,
+		"Display tokens and other secrets. By default they are redacted.",
+	)
+	return result
+}
+
+type runnerContext struct {
+	args struct {
+		format string
+		minify bool
+		raw    bool
+	}
+}
+
+func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	// Get the context:
+	ctx := cmd.Context()
+
+	// Get the console:
+	console := terminal.ConsoleFromContext(ctx)
+
+	// Check the flags:
+	if c.args.format != outputFormatJson && c.args.format != outputFormatYaml {
+		return fmt.Errorf(
+			"unknown output format '%s', should be '%s' or '%s'",
+			c.args.format, outputFormatJson, outputFormatYaml,
+		)
+	}
+
+	// Load the configuration file:
+	file, err := config.LoadFile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Select the contexts to display, redacting the secrets unless explicitly requested:
+	view := &config.File{
+		CurrentContext: file.CurrentContext,
+		Contexts:       map[string]*config.Config{},
+	}
+	if c.args.minify {
+		selected := file.Selected(ctx)
+		view.CurrentContext = selected.Name()
+		view.Contexts[selected.Name()] = selected
+	} else {
+		for name, cfg := range file.Contexts {
+			view.Contexts[name] = cfg
+		}
+	}
+	if !c.args.raw {
+		for name, cfg := range view.Contexts {
+			view.Contexts[name] = cfg.Redacted()
+		}
+	}
+
+	// Convert the configuration to a generic value so that it can be rendered as JSON or YAML using the same field
+	// names that are used in the file:
+	data, err := json.Marshal(view)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var value any
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+	switch c.args.format {
+	case outputFormatJson:
+		console.RenderJson(ctx, value)
+	default:
+		console.RenderYaml(ctx, value)
+	}
+	return nil
+}
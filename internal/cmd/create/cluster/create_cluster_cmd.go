@@ -118,9 +118,6 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if cfg.Address == "" {
-		return fmt.Errorf("there is no configuration, run the 'login' command")
-	}
 
 	// Check that we have a template:
 	if c.args.template == "" {
@@ -20,6 +20,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/innabox/fulfillment-common/logging"
 	"github.com/spf13/cobra"
@@ -36,6 +37,7 @@ import (
 	"github.com/innabox/fulfillment-cli/internal/config"
 	"github.com/innabox/fulfillment-cli/internal/reflection"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/timeout"
 )
 
 func Cmd() *cobra.Command {
@@ -45,6 +47,7 @@ func Cmd() *cobra.Command {
 		Short: "Create objects",
 		RunE:  runner.run,
 	}
+	timeout.SetDefault(result, 10*time.Minute)
 	result.AddCommand(cluster.Cmd())
 	result.AddCommand(computeinstance.Cmd())
 	result.AddCommand(hostpool.Cmd())
@@ -95,13 +98,9 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	defer c.conn.Close()
 
 	// Create the reflection helper:
-	helper, err := reflection.NewHelper().
-		SetLogger(c.logger).
-		SetConnection(c.conn).
-		AddPackages(cfg.Packages()).
-		Build()
+	helper, err := cfg.Helper(ctx, c.conn, cmd.Flags())
 	if err != nil {
-		return fmt.Errorf("failed to create reflection tool: %w", err)
+		return err
 	}
 
 	// Check the flags:
@@ -132,11 +131,15 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		}()
 	}
 
-	// Convert the input to a list of objects, and then create them:
-	objects, err := c.decodeObjects(reader)
+	// Convert the input to a list of objects:
+	objects, err := c.decodeObjects(reader, helper.Resolver())
 	if err != nil {
 		return err
 	}
+
+	// Check that all the objects can be created before creating any of them, so that an invalid object doesn't
+	// leave the rest half created:
+	objectHelpers := make([]*reflection.ObjectHelper, len(objects))
 	for i, object := range objects {
 		objectDesc := object.ProtoReflect().Descriptor()
 		objectType := string(objectDesc.FullName())
@@ -144,6 +147,18 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		if objectHelper == nil {
 			return fmt.Errorf("input object at index %d is of an unknown type '%s'", i, objectType)
 		}
+		if !objectHelper.CanCreate() {
+			return fmt.Errorf(
+				"input object at index %d is of type '%s', but the server doesn't support creating it",
+				i, objectType,
+			)
+		}
+		objectHelpers[i] = objectHelper
+	}
+
+	// Create the objects:
+	for i, object := range objects {
+		objectHelper := objectHelpers[i]
 		object, err = objectHelper.Create(ctx, object)
 		if err != nil {
 			return fmt.Errorf("failed to create object at index %d: %w", i, err)
@@ -170,8 +185,10 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 }
 
 // decode reads the given input, which may contain multiple YAML or JSON documents, each of them being a single object
-// or alist, and returns the corresponding list of protocol buffers messages
-func (c *runnerContext) decodeObjects(input io.Reader) (result []proto.Message, err error) {
+// or alist, and returns the corresponding list of protocol buffers messages. The types of the objects are found with
+// the given resolver.
+func (c *runnerContext) decodeObjects(input io.Reader, resolver reflection.Resolver) (result []proto.Message,
+	err error) {
 	// Parse the input file assuming it is a YAML file. As JSON is a subset of YAML, this will also work for JSON.
 	decoder := yaml.NewDecoder(input)
 	var items []any
@@ -201,6 +218,9 @@ func (c *runnerContext) decodeObjects(input io.Reader) (result []proto.Message,
 
 	// We assume that input objects are protocol buffers any objects, and we need to convert them to the
 	// appropriate type.
+	unmarshalOptions := protojson.UnmarshalOptions{
+		Resolver: resolver,
+	}
 	objects := make([]proto.Message, len(list))
 	for i, item := range list {
 		var data []byte
@@ -210,7 +230,7 @@ func (c *runnerContext) decodeObjects(input io.Reader) (result []proto.Message,
 			return
 		}
 		value := &anypb.Any{}
-		err = protojson.Unmarshal(data, value)
+		err = unmarshalOptions.Unmarshal(data, value)
 		if err != nil {
 			err = fmt.Errorf(
 				"failed to unmarshal item at index %d to a protocol buffers any: %w",
@@ -219,7 +239,9 @@ func (c *runnerContext) decodeObjects(input io.Reader) (result []proto.Message,
 			return
 		}
 		var object proto.Message
-		object, err = value.UnmarshalNew()
+		object, err = anypb.UnmarshalNew(value, proto.UnmarshalOptions{
+			Resolver: resolver,
+		})
 		if err != nil {
 			err = fmt.Errorf(
 				"failed to unmarshal object at index %d to a protocol buffers object: %w",
@@ -14,6 +14,13 @@ language governing permissions and limitations under the License.
 package create
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/innabox/fulfillment-common/logging"
 	. "github.com/onsi/ginkgo/v2/dsl/core"
 	. "github.com/onsi/ginkgo/v2/dsl/table"
 	. "github.com/onsi/gomega"
@@ -24,6 +31,9 @@ import (
 	"github.com/innabox/fulfillment-cli/internal/cmd/create/computeinstance"
 	"github.com/innabox/fulfillment-cli/internal/cmd/create/hostpool"
 	"github.com/innabox/fulfillment-cli/internal/cmd/create/hub"
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/testing"
 	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
 	privatev1 "github.com/innabox/fulfillment-common/api/private/v1"
 )
@@ -55,3 +65,96 @@ var _ = Describe("Create command", func() {
 		})
 	})
 })
+
+var _ = Describe("Create command objects", func() {
+	var (
+		ctx      context.Context
+		output   *bytes.Buffer
+		tmpDir   string
+		requests []*ffv1.ClustersCreateRequest
+	)
+
+	BeforeEach(func() {
+		logger := slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		output = &bytes.Buffer{}
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		tmpDir = GinkgoT().TempDir()
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = terminal.ConsoleIntoContext(ctx, console)
+		ctx = config.LocationIntoContext(ctx, filepath.Join(tmpDir, "config.json"))
+
+		// Start a server that records the create requests:
+		requests = nil
+		server := testing.NewServer()
+		DeferCleanup(server.Stop)
+		ffv1.RegisterClustersServer(server.Registrar(), &testing.ClustersServerFuncs{
+			CreateFunc: func(ctx context.Context,
+				request *ffv1.ClustersCreateRequest) (*ffv1.ClustersCreateResponse, error) {
+				requests = append(requests, request)
+				object := proto.Clone(request.GetObject()).(*ffv1.Cluster)
+				object.SetId("123")
+				return ffv1.ClustersCreateResponse_builder{
+					Object: object,
+				}.Build(), nil
+			},
+		})
+		server.Start()
+
+		// Save a configuration that points to the test server:
+		cfg, err := config.New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = server.Address()
+		cfg.Plaintext = true
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	// run writes the given input to a file and executes the command to create the objects that it contains.
+	run := func(input string) error {
+		file := filepath.Join(tmpDir, "input.yaml")
+		err := os.WriteFile(file, []byte(input), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		cmd := Cmd()
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		cmd.SetArgs([]string{"--filename", file})
+		return cmd.ExecuteContext(ctx)
+	}
+
+	It("Creates all the objects", func() {
+		err := run(`
+- "@type": type.googleapis.com/fulfillment.v1.Cluster
+  metadata:
+    name: my-cluster
+- "@type": type.googleapis.com/fulfillment.v1.Cluster
+  metadata:
+    name: your-cluster
+`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(2))
+		Expect(output.String()).To(ContainSubstring("Created cluster with name 'my-cluster'"))
+		Expect(output.String()).To(ContainSubstring("Created cluster with name 'your-cluster'"))
+	})
+
+	It("Doesn't create any object if one of them can't be created", func() {
+		err := run(`
+- "@type": type.googleapis.com/fulfillment.v1.Cluster
+  metadata:
+    name: my-cluster
+- "@type": type.googleapis.com/events.v1.Event
+  id: my-event
+`)
+		Expect(err).To(MatchError(
+			"input object at index 1 is of an unknown type 'events.v1.Event'",
+		))
+		Expect(requests).To(BeEmpty())
+		Expect(output.String()).To(BeEmpty())
+	})
+})
@@ -74,9 +74,6 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if cfg.Address == "" {
-		return fmt.Errorf("there is no configuration, run the 'login' command")
-	}
 
 	// Check the parameters:
 	if c.id == "" {
@@ -20,6 +20,7 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/innabox/fulfillment-common/logging"
 	"github.com/spf13/cobra"
@@ -32,6 +33,7 @@ import (
 	"github.com/innabox/fulfillment-cli/internal/exit"
 	"github.com/innabox/fulfillment-cli/internal/reflection"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/timeout"
 )
 
 //go:embed templates
@@ -44,6 +46,7 @@ func Cmd() *cobra.Command {
 		Short: "Delete objects",
 		RunE:  runner.run,
 	}
+	timeout.SetDefault(result, time.Minute)
 	return result
 }
 
@@ -87,13 +90,9 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	defer c.conn.Close()
 
 	// Create the reflection helper:
-	helper, err := reflection.NewHelper().
-		SetLogger(c.logger).
-		SetConnection(c.conn).
-		AddPackages(cfg.Packages()).
-		Build()
+	helper, err := cfg.Helper(ctx, c.conn, cmd.Flags())
 	if err != nil {
-		return fmt.Errorf("failed to create reflection tool: %w", err)
+		return err
 	}
 	c.console.SetHelper(helper)
 
@@ -120,6 +119,13 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		})
 		return nil
 	}
+	if !c.helper.CanList() || !c.helper.CanDelete() {
+		c.console.Render(ctx, "unsupported_object.txt", map[string]any{
+			"Helper": helper,
+			"Object": args[0],
+		})
+		return nil
+	}
 
 	// Find all objects matching the provided references using a single list operation:
 	refs := args[1:]
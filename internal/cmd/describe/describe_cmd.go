@@ -14,12 +14,15 @@ language governing permissions and limitations under the License.
 package describe
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/innabox/fulfillment-cli/internal/cmd/describe/cluster"
 	"github.com/innabox/fulfillment-cli/internal/cmd/describe/computeinstance"
 	"github.com/innabox/fulfillment-cli/internal/cmd/describe/host"
 	"github.com/innabox/fulfillment-cli/internal/cmd/describe/hostpool"
+	"github.com/innabox/fulfillment-cli/internal/timeout"
 )
 
 func Cmd() *cobra.Command {
@@ -27,6 +30,7 @@ func Cmd() *cobra.Command {
 		Use:   "describe",
 		Short: "Describe a resource",
 	}
+	timeout.SetDefault(result, time.Minute)
 	result.AddCommand(cluster.Cmd())
 	result.AddCommand(computeinstance.Cmd())
 	result.AddCommand(host.Cmd())
@@ -20,9 +20,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"os"
-	"os/exec"
-	"path/filepath"
 
 	"github.com/innabox/fulfillment-common/logging"
 	"github.com/spf13/cobra"
@@ -71,12 +68,13 @@ func Cmd() *cobra.Command {
 }
 
 type runnerContext struct {
-	logger         *slog.Logger
-	console        *terminal.Console
-	format         string
-	conn           *grpc.ClientConn
-	marshalOptions protojson.MarshalOptions
-	helper         *reflection.ObjectHelper
+	logger           *slog.Logger
+	console          *terminal.Console
+	format           string
+	conn             *grpc.ClientConn
+	marshalOptions   protojson.MarshalOptions
+	unmarshalOptions protojson.UnmarshalOptions
+	helper           *reflection.ObjectHelper
 }
 
 func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
@@ -112,15 +110,13 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	defer c.conn.Close()
 
 	// Create the reflection helper:
-	helper, err := reflection.NewHelper().
-		SetLogger(c.logger).
-		SetConnection(c.conn).
-		AddPackages(cfg.Packages()).
-		Build()
+	helper, err := cfg.Helper(ctx, c.conn, cmd.Flags())
 	if err != nil {
-		return fmt.Errorf("failed to create reflection tool: %w", err)
+		return err
 	}
 	c.console.SetHelper(helper)
+	c.marshalOptions.Resolver = helper.Resolver()
+	c.unmarshalOptions.Resolver = helper.Resolver()
 
 	// Check that the object type has been specified:
 	if len(args) == 0 {
@@ -139,6 +135,13 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		})
 		return nil
 	}
+	if !c.helper.CanList() || !c.helper.CanUpdate() {
+		c.console.Render(ctx, "unsupported_object.txt", map[string]any{
+			"Helper": helper,
+			"Object": args[0],
+		})
+		return nil
+	}
 
 	// Check the flags:
 	if c.format != outputFormatJson && c.format != outputFormatYaml {
@@ -177,54 +180,17 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Write the rendered object to a temporary file:
-	tmpDir, err := os.MkdirTemp("", "")
-	if err != nil {
-		return err
-	}
-	defer func() {
-		err := os.RemoveAll(tmpDir)
-		if err != nil {
-			c.logger.ErrorContext(
-				ctx,
-				"Failed to remove temporary directory",
-				slog.String("dir", tmpDir),
-				slog.Any("error", err),
-			)
-		}
-	}()
-	objectId := c.helper.GetId(object)
-	tmpFile := filepath.Join(tmpDir, fmt.Sprintf("%s-%s.%s", c.helper, objectId, c.format))
-	err = os.WriteFile(tmpFile, data, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to create temporary file '%s': %w", tmpFile, err)
-	}
-
 	// Run the editor:
-	editorName := c.findEditor(ctx)
-	editorPath, err := exec.LookPath(editorName)
-	if err != nil {
-		return fmt.Errorf("failed to find editor command '%s': %w", editorName, err)
-	}
-	editorCmd := &exec.Cmd{
-		Path: editorPath,
-		Args: []string{
-			editorName,
-			tmpFile,
-		},
-		Stdin:  os.Stdin,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-	}
-	err = editorCmd.Run()
+	editor, err := terminal.NewEditor().
+		SetLogger(c.logger).
+		Build()
 	if err != nil {
-		return fmt.Errorf("failed to edit: %w", err)
+		return fmt.Errorf("failed to create editor: %w", err)
 	}
-
-	// Load the potentiall modified file:
-	data, err = os.ReadFile(tmpFile)
+	objectId := c.helper.GetId(object)
+	data, err = editor.Edit(ctx, fmt.Sprintf("%s-%s.%s", c.helper, objectId, c.format), data)
 	if err != nil {
-		return fmt.Errorf("failed to read back temporary file '%s': %w", tmpFile, err)
+		return err
 	}
 
 	// Parse the result:
@@ -235,13 +201,23 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	default:
 		parse = c.parseYaml
 	}
-	object, err = parse(data)
+	edited, err := parse(data)
 	if err != nil {
 		return fmt.Errorf("failed to parse modified object: %w", err)
 	}
 
+	// Find the fields that have been changed, and don't send the update if there are none:
+	paths := c.helper.Diff(object, edited)
+	if len(paths) == 0 {
+		c.console.Render(ctx, "no_changes.txt", map[string]any{
+			"Object": c.helper.Singular(),
+			"Id":     objectId,
+		})
+		return nil
+	}
+
 	// Save the result:
-	updated, err := c.update(ctx, object)
+	updated, err := c.update(ctx, edited, paths)
 	if err != nil {
 		return err
 	}
@@ -251,30 +227,6 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// findEditor tries to find the name of the editor command. It will first try with the content of the `EDITOR` and
-// `VISUAL` environment variables, and if those are empty it defaults to `vi`.
-func (c *runnerContext) findEditor(ctx context.Context) string {
-	for _, editorEnvVar := range editorEnvVars {
-		value, ok := os.LookupEnv(editorEnvVar)
-		if ok && value != "" {
-			c.logger.DebugContext(
-				ctx,
-				"Found editor using environment variable",
-				slog.String("var", editorEnvVar),
-				slog.String("value", value),
-			)
-			return value
-		}
-	}
-	c.logger.InfoContext(
-		ctx,
-		"Didn't find a editor in the environment, will use the default",
-		slog.Any("vars", editorEnvVars),
-		slog.String("default", defaultEditor),
-	)
-	return defaultEditor
-}
-
 // findObject tries to find an object by identifier or name. It uses the list method with a filter that matches
 // either the identifier or the name. Returns an error if no match is found or if multiple matches are found.
 func (c *runnerContext) findObject(ctx context.Context, ref string) (result proto.Message, err error) {
@@ -313,8 +265,16 @@ func (c *runnerContext) findObject(ctx context.Context, ref string) (result prot
 	}
 }
 
-func (c *runnerContext) update(ctx context.Context, object proto.Message) (result proto.Message, err error) {
-	result, err = c.helper.Update(ctx, object)
+func (c *runnerContext) update(ctx context.Context, object proto.Message,
+	paths []string) (result proto.Message, err error) {
+	c.logger.DebugContext(
+		ctx,
+		"Updating object",
+		slog.String("type", c.helper.String()),
+		slog.String("id", c.helper.GetId(object)),
+		slog.Any("paths", paths),
+	)
+	result, err = c.helper.Update(ctx, object, paths...)
 	return
 }
 
@@ -354,7 +314,7 @@ func (c *runnerContext) renderYaml(object proto.Message) (result []byte, err err
 
 func (c *runnerContext) parseJson(data []byte) (result proto.Message, err error) {
 	object := c.helper.Instance()
-	err = protojson.Unmarshal(data, object)
+	err = c.unmarshalOptions.Unmarshal(data, object)
 	if err != nil {
 		return
 	}
@@ -375,12 +335,3 @@ func (c *runnerContext) parseYaml(data []byte) (result proto.Message, err error)
 	result, err = c.parseJson(data)
 	return
 }
-
-// editorEnvVars is the list of environment variables that will be used to obtain the name of the editor command.
-var editorEnvVars = []string{
-	"EDITOR",
-	"VISUAL",
-}
-
-// defualtEditor is the editor used when the environment variables don't indicate any other editor.
-const defaultEditor = "vi"
@@ -17,14 +17,19 @@ import (
 	"bytes"
 	"context"
 	"log/slog"
+	"os"
+	"path/filepath"
 
 	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
 	sharedv1 "github.com/innabox/fulfillment-common/api/shared/v1"
+	"github.com/innabox/fulfillment-common/logging"
 	. "github.com/onsi/ginkgo/v2/dsl/core"
 	. "github.com/onsi/gomega"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/innabox/fulfillment-cli/internal/config"
 	"github.com/innabox/fulfillment-cli/internal/reflection"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
 	"github.com/innabox/fulfillment-cli/internal/testing"
@@ -105,3 +110,115 @@ var _ = Describe("Edit command", func() {
 		})
 	})
 })
+
+var _ = Describe("Edit command updates", func() {
+	var (
+		ctx      context.Context
+		output   *bytes.Buffer
+		tmpDir   string
+		requests []*ffv1.ClustersUpdateRequest
+	)
+
+	BeforeEach(func() {
+		logger := slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		output = &bytes.Buffer{}
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		tmpDir = GinkgoT().TempDir()
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = terminal.ConsoleIntoContext(ctx, console)
+		ctx = config.LocationIntoContext(ctx, filepath.Join(tmpDir, "config.json"))
+
+		// Start a server that returns one cluster and records the update requests:
+		requests = nil
+		cluster := ffv1.Cluster_builder{
+			Id: "123",
+			Metadata: sharedv1.Metadata_builder{
+				Name: "my-cluster",
+			}.Build(),
+			Spec: ffv1.ClusterSpec_builder{
+				Template: "my-template",
+			}.Build(),
+		}.Build()
+		server := testing.NewServer()
+		DeferCleanup(server.Stop)
+		ffv1.RegisterClustersServer(server.Registrar(), &testing.ClustersServerFuncs{
+			ListFunc: func(ctx context.Context,
+				request *ffv1.ClustersListRequest) (*ffv1.ClustersListResponse, error) {
+				return ffv1.ClustersListResponse_builder{
+					Size:  proto.Int32(1),
+					Total: proto.Int32(1),
+					Items: []*ffv1.Cluster{cluster},
+				}.Build(), nil
+			},
+			UpdateFunc: func(ctx context.Context,
+				request *ffv1.ClustersUpdateRequest) (*ffv1.ClustersUpdateResponse, error) {
+				requests = append(requests, request)
+				return ffv1.ClustersUpdateResponse_builder{
+					Object: request.GetObject(),
+				}.Build(), nil
+			},
+		})
+		server.Start()
+
+		// Save a configuration that points to the test server:
+		cfg, err := config.New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = server.Address()
+		cfg.Plaintext = true
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	// setEditor creates a script that will be used as the editor, running the given shell command with the name of
+	// the file to edit in the `$1` variable.
+	setEditor := func(command string) {
+		script := filepath.Join(tmpDir, "editor.sh")
+		err := os.WriteFile(script, []byte("#!/bin/sh\n"+command+"\n"), 0700)
+		Expect(err).ToNot(HaveOccurred())
+		GinkgoT().Setenv("EDITOR", script)
+	}
+
+	// run executes the command with the given arguments.
+	run := func(args ...string) error {
+		cmd := Cmd()
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		cmd.SetArgs(args)
+		return cmd.ExecuteContext(ctx)
+	}
+
+	It("Doesn't update the object if there are no changes", func() {
+		setEditor("true")
+		err := run("cluster", "123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(BeEmpty())
+		Expect(output.String()).To(ContainSubstring("There are no changes to cluster '123'"))
+	})
+
+	It("Sends only the changed fields in the update mask", func() {
+		setEditor(`sed -i 's/my-cluster/your-cluster/' "$1"`)
+		err := run("cluster", "123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(1))
+		request := requests[0]
+		Expect(request.GetUpdateMask().GetPaths()).To(Equal([]string{"metadata.name"}))
+		Expect(request.GetObject().GetMetadata().GetName()).To(Equal("your-cluster"))
+		Expect(request.GetObject().GetSpec().GetTemplate()).To(Equal("my-template"))
+		Expect(output.String()).To(ContainSubstring("get cluster 123 --watch"))
+	})
+
+	It("Detects changes when using JSON", func() {
+		setEditor(`sed -i 's/my-template/your-template/' "$1"`)
+		err := run("cluster", "123", "--output", "json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].GetUpdateMask().GetPaths()).To(Equal([]string{"spec.template"}))
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/innabox/fulfillment-common/logging"
 	"github.com/spf13/cobra"
@@ -36,6 +37,7 @@ import (
 	"github.com/innabox/fulfillment-cli/internal/reflection"
 	"github.com/innabox/fulfillment-cli/internal/rendering"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/timeout"
 )
 
 //go:embed templates
@@ -48,6 +50,10 @@ const (
 	outputFormatYaml  = "yaml"
 )
 
+// defaultTableLimit is the maximum number of objects that are displayed with the table format when no explicit limit
+// is given.
+const defaultTableLimit = 100
+
 func Cmd() *cobra.Command {
 	runner := &runnerContext{
 		marshalOptions: protojson.MarshalOptions{
@@ -59,6 +65,7 @@ func Cmd() *cobra.Command {
 		Short: "Get objects",
 		RunE:  runner.run,
 	}
+	timeout.SetDefault(result, time.Minute)
 	result.AddCommand(kubeconfig.Cmd())
 	result.AddCommand(password.Cmd())
 	result.AddCommand(token.Cmd())
@@ -92,6 +99,41 @@ func Cmd() *cobra.Command {
 		false,
 		"Watch for changes to objects",
 	)
+	flags.Int32Var(
+		&runner.args.limit,
+		"limit",
+		0,
+		fmt.Sprintf(
+			"Maximum number of objects to return. The default is %d for the '%s' output format, and no "+
+				"limit for the other formats.",
+			defaultTableLimit, outputFormatTable,
+		),
+	)
+	flags.Int32Var(
+		&runner.args.offset,
+		"offset",
+		0,
+		"Index of the first object to return.",
+	)
+	flags.Int32Var(
+		&runner.args.chunkSize,
+		"chunk-size",
+		reflection.DefaultChunkSize,
+		"Number of objects requested from the server in each call.",
+	)
+	flags.BoolVar(
+		&runner.args.all,
+		"all",
+		false,
+		"Return all the objects, without limit.",
+	)
+	flags.StringArrayVar(
+		&runner.args.sortBy,
+		"sort-by",
+		nil,
+		"CEL expression used to sort the objects, for example 'this.metadata.name'. Add a '-' prefix or a "+
+			"'desc' suffix to sort in descending order. Can be used multiple times.",
+	)
 	return result
 }
 
@@ -101,6 +143,11 @@ type runnerContext struct {
 		filter         string
 		includeDeleted bool
 		watch          bool
+		limit          int32
+		offset         int32
+		chunkSize      int32
+		all            bool
+		sortBy         []string
 	}
 	ctx            context.Context
 	logger         *slog.Logger
@@ -109,6 +156,10 @@ type runnerContext struct {
 	marshalOptions protojson.MarshalOptions
 	globalHelper   *reflection.Helper
 	objectHelper   *reflection.ObjectHelper
+	iterator       *reflection.ListIterator
+	renderer       *rendering.TableRenderer
+	order          string
+	sorter         *rendering.ObjectSorter
 }
 
 func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
@@ -148,14 +199,11 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	defer c.conn.Close()
 
 	// Create the reflection helper:
-	c.globalHelper, err = reflection.NewHelper().
-		SetLogger(c.logger).
-		SetConnection(c.conn).
-		AddPackages(cfg.Packages()).
-		Build()
+	c.globalHelper, err = cfg.Helper(ctx, c.conn, cmd.Flags())
 	if err != nil {
-		return fmt.Errorf("failed to create reflection tool: %w", err)
+		return err
 	}
+	c.marshalOptions.Resolver = c.globalHelper.Resolver()
 
 	// Check that the object type has been specified:
 	if len(args) == 0 {
@@ -174,6 +222,13 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		})
 		return nil
 	}
+	if !c.objectHelper.CanList() {
+		c.console.Render(ctx, "unsupported_object.txt", map[string]any{
+			"Helper": c.globalHelper,
+			"Object": args[0],
+		})
+		return nil
+	}
 
 	// Check the flags:
 	if c.args.format != outputFormatTable && c.args.format != outputFormatJson && c.args.format != outputFormatYaml {
@@ -182,32 +237,184 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 			c.args.format, outputFormatTable, outputFormatJson, outputFormatYaml,
 		)
 	}
+	if c.args.all && cmd.Flags().Changed("limit") {
+		return fmt.Errorf("options '--all' and '--limit' can't be used together")
+	}
+	if c.args.limit < 0 {
+		return fmt.Errorf("value of '--limit' should be zero or positive, but it is %d", c.args.limit)
+	}
+	if c.args.offset < 0 {
+		return fmt.Errorf("value of '--offset' should be zero or positive, but it is %d", c.args.offset)
+	}
+	if c.args.chunkSize <= 0 {
+		return fmt.Errorf("value of '--chunk-size' should be positive, but it is %d", c.args.chunkSize)
+	}
+
+	// Prepare the sorting. If the server supports it the criteria are sent in the list request, otherwise the objects
+	// are sorted locally after receiving all the pages.
+	if len(c.args.sortBy) > 0 {
+		if c.args.watch {
+			return fmt.Errorf("options '--sort-by' and '--watch' can't be used together")
+		}
+		err = c.prepareSort(ctx)
+		if err != nil {
+			return err
+		}
+	}
 
 	// If watch mode is enabled, watch for events instead of listing
 	if c.args.watch {
 		return c.watch(ctx, args[1:])
 	}
 
+	// The table format is rendered page by page, as the objects are received from the server, unless they need to
+	// be sorted locally:
+	if c.args.format == outputFormatTable && c.sorter == nil {
+		return c.streamTable(ctx, args[1:])
+	}
+
 	// Get the objects using the list method, which will handle filtering by identifiers or names if provided.
 	objects, err := c.list(ctx, args[1:])
 	if err != nil {
 		return err
 	}
+	total := c.iterator.Total()
+
+	// When sorting locally all the objects have been requested, without offset or limit, so the range is selected
+	// here, after sorting:
+	if c.sorter != nil {
+		err = c.sorter.Sort(objects)
+		if err != nil {
+			return err
+		}
+		total = int32(len(objects))
+		objects = c.selectRange(objects)
+	}
 
 	// Render the items:
-	var render func(context.Context, []proto.Message) error
 	switch c.args.format {
 	case outputFormatJson:
-		render = c.renderJson
+		return c.renderJson(ctx, objects)
 	case outputFormatYaml:
-		render = c.renderYaml
+		return c.renderYaml(ctx, objects)
 	default:
-		render = c.renderTable
+		err = c.renderTable(ctx, objects)
+		if err != nil {
+			return err
+		}
+		c.renderFooter(ctx, int32(len(objects)), total)
+		return nil
 	}
-	return render(ctx, objects)
 }
 
+// prepareSort parses the sort keys given in the command line. If the server supports sorting they are saved to be
+// sent in the list request, otherwise it creates the sorter that will sort the objects locally.
+func (c *runnerContext) prepareSort(ctx context.Context) error {
+	keys := make([]rendering.SortKey, len(c.args.sortBy))
+	for i, text := range c.args.sortBy {
+		key, err := rendering.ParseSortKey(text)
+		if err != nil {
+			return fmt.Errorf("invalid value '%s' for option '--sort-by': %w", text, err)
+		}
+		keys[i] = key
+	}
+	if c.objectHelper.CanOrder() {
+		orders := make([]string, len(keys))
+		for i, key := range keys {
+			orders[i] = key.String()
+		}
+		c.order = strings.Join(orders, ", ")
+		c.logger.DebugContext(
+			ctx,
+			"Server supports sorting, will send the sort criteria",
+			slog.String("order", c.order),
+		)
+		return nil
+	}
+	builder := rendering.NewObjectSorter().
+		SetLogger(c.logger).
+		SetHelper(c.objectHelper)
+	for _, key := range keys {
+		builder.AddKey(key)
+	}
+	sorter, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	c.sorter = sorter
+	return nil
+}
+
+// list gets all the objects that match the given identifiers or names, and the filter.
 func (c *runnerContext) list(ctx context.Context, keys []string) (results []proto.Message, err error) {
+	c.iterator = c.makeIterator(keys)
+	for !c.iterator.Done() {
+		var page []proto.Message
+		page, err = c.iterator.Next(ctx)
+		if err != nil {
+			return
+		}
+		results = append(results, page...)
+	}
+	return
+}
+
+// streamTable renders as a table the objects that match the given identifiers or names, and the filter. Each page of
+// objects is rendered as soon as it is received from the server, so that they aren't all kept in memory.
+func (c *runnerContext) streamTable(ctx context.Context, keys []string) error {
+	renderer, err := c.tableRenderer()
+	if err != nil {
+		return err
+	}
+	stream := renderer.Stream()
+	c.iterator = c.makeIterator(keys)
+	for !c.iterator.Done() {
+		page, err := c.iterator.Next(ctx)
+		if err != nil {
+			return err
+		}
+		err = stream.Write(ctx, page)
+		if err != nil {
+			return err
+		}
+	}
+	err = stream.Flush()
+	if err != nil {
+		return err
+	}
+	if stream.Count() == 0 {
+		c.console.Render(ctx, "no_matching_objects.txt", nil)
+		return nil
+	}
+	c.renderFooter(ctx, c.iterator.Count(), c.iterator.Total())
+	return nil
+}
+
+// selectRange returns the objects that are in the range selected by the offset and limit given in the command line.
+func (c *runnerContext) selectRange(objects []proto.Message) []proto.Message {
+	offset := min(int(c.args.offset), len(objects))
+	objects = objects[offset:]
+	limit := int(c.limit())
+	if limit > 0 && limit < len(objects) {
+		objects = objects[:limit]
+	}
+	return objects
+}
+
+// limit calculates the maximum number of objects to return. The table format has a default limit because more objects
+// than that aren't useful in a terminal, but the other formats are usually processed by other tools, so they return
+// all the objects unless a limit is explicitly requested.
+func (c *runnerContext) limit() int32 {
+	if c.args.limit == 0 && !c.args.all && c.args.format == outputFormatTable {
+		return defaultTableLimit
+	}
+	return c.args.limit
+}
+
+// makeIterator creates the iterator that returns the objects that match the given identifiers or names, and the
+// filter, using the limit, offset and chunk size given in the command line. When the objects are sorted locally the
+// limit and offset are ignored, because all the objects are needed to sort them.
+func (c *runnerContext) makeIterator(keys []string) *reflection.ListIterator {
 	var options reflection.ListOptions
 
 	// If keys (identifiers or names) were provided, build a CEL filter to match them.
@@ -242,12 +449,21 @@ func (c *runnerContext) list(ctx context.Context, keys []string) (results []prot
 		}
 	}
 
-	listResult, err := c.objectHelper.List(ctx, options)
-	if err != nil {
-		return
+	// Calculate the range:
+	offset := c.args.offset
+	limit := c.limit()
+	if c.sorter != nil {
+		offset = 0
+		limit = 0
 	}
-	results = listResult.Items
-	return
+
+	return c.objectHelper.Iterator(reflection.IteratorOptions{
+		Filter:    options.Filter,
+		Order:     c.order,
+		Offset:    offset,
+		Limit:     limit,
+		ChunkSize: c.args.chunkSize,
+	})
 }
 
 func (c *runnerContext) renderTable(ctx context.Context, objects []proto.Message) error {
@@ -257,19 +473,47 @@ func (c *runnerContext) renderTable(ctx context.Context, objects []proto.Message
 		return nil
 	}
 
-	// Create the table renderer:
-	renderer, err := rendering.NewTableRenderer().
+	// Use the table renderer to render the objects:
+	renderer, err := c.tableRenderer()
+	if err != nil {
+		return err
+	}
+	return renderer.Render(ctx, objects)
+}
+
+// tableRenderer returns the table renderer, creating it the first time. The same renderer is reused so that the table
+// layouts are compiled only once, even when rendering multiple times in watch mode.
+func (c *runnerContext) tableRenderer() (result *rendering.TableRenderer, err error) {
+	if c.renderer != nil {
+		result = c.renderer
+		return
+	}
+	result, err = rendering.NewTableRenderer().
 		SetLogger(c.logger).
 		SetHelper(c.globalHelper).
 		SetWriter(c.console).
 		SetIncludeDeleted(c.args.includeDeleted).
 		Build()
 	if err != nil {
-		return fmt.Errorf("failed to create table renderer: %w", err)
+		err = fmt.Errorf("failed to create table renderer: %w", err)
+		return
 	}
+	c.renderer = result
+	return
+}
 
-	// Use the table renderer to render the objects:
-	return renderer.Render(ctx, objects)
+// renderFooter tells the user that not all the objects have been displayed, if that is the case.
+func (c *runnerContext) renderFooter(ctx context.Context, shown, total int32) {
+	if shown == 0 || c.args.offset+shown >= total {
+		return
+	}
+	c.console.Render(ctx, "truncated.txt", map[string]any{
+		"First":  c.args.offset + 1,
+		"Last":   c.args.offset + shown,
+		"Shown":  shown,
+		"Total":  total,
+		"Plural": c.objectHelper.Plural(),
+	})
 }
 
 func (c *runnerContext) renderJson(ctx context.Context, objects []proto.Message) error {
@@ -0,0 +1,344 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package get
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	privatev1 "github.com/innabox/fulfillment-common/api/private/v1"
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Get command", func() {
+	var (
+		ctx      context.Context
+		output   *bytes.Buffer
+		hosts    []*ffv1.Host
+		requests []*ffv1.HostsListRequest
+		hubs     []*privatev1.Hub
+	)
+
+	// run executes the command with the given arguments. The command is added to a parent because otherwise cobra
+	// would interpret the object type as the name of a subcommand.
+	run := func(args ...string) error {
+		root := &cobra.Command{
+			Use:           "fulfillment-cli",
+			SilenceErrors: true,
+			SilenceUsage:  true,
+		}
+		root.AddCommand(Cmd())
+		root.SetArgs(append([]string{"get"}, args...))
+		return root.ExecuteContext(ctx)
+	}
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = terminal.ConsoleIntoContext(ctx, console)
+		ctx = config.LocationIntoContext(ctx, filepath.Join(GinkgoT().TempDir(), "config.json"))
+
+		// Prepare the hosts:
+		hosts = make([]*ffv1.Host, 250)
+		for i := range hosts {
+			hosts[i] = ffv1.Host_builder{
+				Id: fmt.Sprintf("host-%03d", i),
+			}.Build()
+		}
+		requests = nil
+
+		// Start a server that returns the requested page of hosts:
+		server := testing.NewServer()
+		DeferCleanup(server.Stop)
+		ffv1.RegisterHostsServer(server.Registrar(), &testing.HostsServerFuncs{
+			ListFunc: func(ctx context.Context,
+				request *ffv1.HostsListRequest) (*ffv1.HostsListResponse, error) {
+				requests = append(requests, request)
+				offset := min(int(request.GetOffset()), len(hosts))
+				end := len(hosts)
+				if request.HasLimit() {
+					end = min(offset+int(request.GetLimit()), len(hosts))
+				}
+				page := hosts[offset:end]
+				return ffv1.HostsListResponse_builder{
+					Size:  proto.Int32(int32(len(page))),
+					Total: proto.Int32(int32(len(hosts))),
+					Items: page,
+				}.Build(), nil
+			},
+		})
+		// The list request for hubs doesn't have an order field, so they are sorted locally:
+		now := time.Now()
+		hubs = []*privatev1.Hub{
+			makeHub("hub-1", "beta", now.Add(-time.Hour)),
+			makeHub("hub-2", "alpha", now.Add(-time.Minute)),
+			makeHub("hub-3", "gamma", now.Add(-time.Second)),
+			makeHub("hub-4", "alpha", now.Add(-2*time.Hour)),
+		}
+		privatev1.RegisterHubsServer(server.Registrar(), &hubsServer{
+			list: func() []*privatev1.Hub {
+				return hubs
+			},
+		})
+		server.Start()
+
+		// Save a configuration that points to the test server:
+		cfg, err := config.New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = server.Address()
+		cfg.Plaintext = true
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	// decode parses the JSON output and returns the identifiers of the objects.
+	decode := func() []string {
+		var items []map[string]any
+		err := json.Unmarshal(output.Bytes(), &items)
+		Expect(err).ToNot(HaveOccurred())
+		ids := make([]string, len(items))
+		for i, item := range items {
+			ids[i] = item["id"].(string)
+		}
+		return ids
+	}
+
+	It("Shows only the first objects in the table, and explains how to see the rest", func() {
+		err := run("hosts")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring("host-000"))
+		Expect(output.String()).To(ContainSubstring("host-099"))
+		Expect(output.String()).ToNot(ContainSubstring("host-100"))
+		Expect(output.String()).To(ContainSubstring("Showing 100 of 250 hosts, from 1 to 100."))
+		Expect(output.String()).To(ContainSubstring("--all"))
+	})
+
+	It("Shows all the objects in the table when requested", func() {
+		err := run("hosts", "--all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring("host-000"))
+		Expect(output.String()).To(ContainSubstring("host-249"))
+		Expect(output.String()).ToNot(ContainSubstring("Showing"))
+		Expect(requests).To(HaveLen(3))
+	})
+
+	It("Shows the requested range of objects", func() {
+		err := run("hosts", "--offset", "10", "--limit", "5")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).ToNot(ContainSubstring("host-009"))
+		Expect(output.String()).To(ContainSubstring("host-010"))
+		Expect(output.String()).To(ContainSubstring("host-014"))
+		Expect(output.String()).ToNot(ContainSubstring("host-015"))
+		Expect(output.String()).To(ContainSubstring("Showing 5 of 250 hosts, from 11 to 15."))
+	})
+
+	It("Uses the requested chunk size", func() {
+		err := run("hosts", "--all", "--chunk-size", "50")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(5))
+		for _, request := range requests {
+			Expect(request.GetLimit()).To(BeNumerically("==", 50))
+		}
+	})
+
+	It("Returns all the objects in JSON format by default", func() {
+		err := run("hosts", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		ids := decode()
+		Expect(ids).To(HaveLen(250))
+		Expect(ids[0]).To(Equal("host-000"))
+		Expect(ids[249]).To(Equal("host-249"))
+	})
+
+	It("Honours the limit in JSON format", func() {
+		err := run("hosts", "-o", "json", "--limit", "3", "--offset", "247")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decode()).To(Equal([]string{"host-247", "host-248", "host-249"}))
+	})
+
+	It("Rejects '--all' together with '--limit'", func() {
+		err := run("hosts", "--all", "--limit", "10")
+		Expect(err).To(MatchError("options '--all' and '--limit' can't be used together"))
+	})
+
+	It("Rejects a negative offset", func() {
+		err := run("hosts", "--offset", "-1")
+		Expect(err).To(MatchError("value of '--offset' should be zero or positive, but it is -1"))
+	})
+
+	It("Rejects a chunk size that isn't positive", func() {
+		err := run("hosts", "--chunk-size", "0")
+		Expect(err).To(MatchError("value of '--chunk-size' should be positive, but it is 0"))
+	})
+
+	Describe("Sorting", func() {
+		BeforeEach(func() {
+			// Hubs are only available with the private API:
+			cfg, err := config.Load(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			cfg.Private = true
+			err = config.Save(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Sends the sort criteria to the server if it supports them", func() {
+			err := run("fulfillment.v1.Host", "--sort-by", "-this.metadata.name", "--sort-by", "this.id asc")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests).ToNot(BeEmpty())
+			Expect(requests[0].GetOrder()).To(Equal("this.metadata.name desc, this.id"))
+		})
+
+		It("Sorts the table locally if the server doesn't support it", func() {
+			err := run("hubs", "--sort-by", "this.metadata.name")
+			Expect(err).ToNot(HaveOccurred())
+			lines := strings.Split(output.String(), "\n")
+			Expect(lines[1]).To(HavePrefix("hub-2 "))
+			Expect(lines[2]).To(HavePrefix("hub-4 "))
+			Expect(lines[3]).To(HavePrefix("hub-1 "))
+			Expect(lines[4]).To(HavePrefix("hub-3 "))
+		})
+
+		It("Sorts by multiple keys in JSON format", func() {
+			err := run(
+				"hubs", "-o", "json",
+				"--sort-by", "this.metadata.name",
+				"--sort-by", "this.metadata.creation_timestamp desc",
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decode()).To(Equal([]string{"hub-2", "hub-4", "hub-1", "hub-3"}))
+		})
+
+		It("Sorts by timestamp in YAML format", func() {
+			err := run("hubs", "-o", "yaml", "--sort-by", "-this.metadata.creation_timestamp")
+			Expect(err).ToNot(HaveOccurred())
+			text := output.String()
+			Expect(strings.Index(text, "hub-3")).To(BeNumerically("<", strings.Index(text, "hub-2")))
+			Expect(strings.Index(text, "hub-2")).To(BeNumerically("<", strings.Index(text, "hub-1")))
+			Expect(strings.Index(text, "hub-1")).To(BeNumerically("<", strings.Index(text, "hub-4")))
+		})
+
+		Context("With more objects than the limit", func() {
+			BeforeEach(func() {
+				// The server returns the hubs in the reverse order of their names, so the first page isn't
+				// the first objects in sort order:
+				now := time.Now()
+				hubs = make([]*privatev1.Hub, 150)
+				for i := range hubs {
+					hubs[i] = makeHub(
+						fmt.Sprintf("hub-%03d", i),
+						fmt.Sprintf("name-%03d", len(hubs)-1-i),
+						now,
+					)
+				}
+			})
+
+			It("Sorts all the objects before selecting the first ones for the table", func() {
+				err := run("hubs", "--sort-by", "this.metadata.name")
+				Expect(err).ToNot(HaveOccurred())
+				text := output.String()
+				lines := strings.Split(text, "\n")
+				Expect(lines[1]).To(HavePrefix("hub-149 "))
+				Expect(lines[100]).To(HavePrefix("hub-050 "))
+				Expect(text).ToNot(ContainSubstring("hub-049"))
+				Expect(text).To(ContainSubstring("Showing 100 of 150 hubs, from 1 to 100."))
+			})
+
+			It("Applies the offset and limit after sorting", func() {
+				err := run(
+					"hubs", "-o", "json",
+					"--sort-by", "this.metadata.name",
+					"--offset", "10",
+					"--limit", "3",
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decode()).To(Equal([]string{"hub-139", "hub-138", "hub-137"}))
+			})
+
+			It("Shows the range selected after sorting in the footer", func() {
+				err := run("hubs", "--sort-by", "this.metadata.name", "--offset", "140")
+				Expect(err).ToNot(HaveOccurred())
+				text := output.String()
+				Expect(text).To(ContainSubstring("hub-009"))
+				Expect(text).To(ContainSubstring("hub-000"))
+				Expect(text).ToNot(ContainSubstring("Showing"))
+			})
+		})
+
+		It("Rejects an expression that doesn't compile", func() {
+			err := run("hubs", "--sort-by", "this.junk")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix(`failed to compile sort expression "this.junk"`))
+		})
+
+		It("Rejects '--sort-by' together with '--watch'", func() {
+			err := run("hubs", "--sort-by", "this.id", "--watch")
+			Expect(err).To(MatchError("options '--sort-by' and '--watch' can't be used together"))
+		})
+	})
+})
+
+// makeHub creates a hub with the given identifier, name and creation time.
+func makeHub(id, name string, created time.Time) *privatev1.Hub {
+	return privatev1.Hub_builder{
+		Id: id,
+		Metadata: privatev1.Metadata_builder{
+			Name:              name,
+			CreationTimestamp: timestamppb.New(created),
+		}.Build(),
+	}.Build()
+}
+
+// hubsServer is an implementation of the hubs server that returns the requested page of the hubs returned by a
+// function, ignoring the filter.
+type hubsServer struct {
+	privatev1.UnimplementedHubsServer
+	list func() []*privatev1.Hub
+}
+
+func (s *hubsServer) List(ctx context.Context,
+	request *privatev1.HubsListRequest) (*privatev1.HubsListResponse, error) {
+	items := s.list()
+	offset := min(int(request.GetOffset()), len(items))
+	end := len(items)
+	if request.HasLimit() {
+		end = min(offset+int(request.GetLimit()), len(items))
+	}
+	page := items[offset:end]
+	return privatev1.HubsListResponse_builder{
+		Size:  proto.Int32(int32(len(page))),
+		Total: proto.Int32(int32(len(items))),
+		Items: page,
+	}.Build(), nil
+}
@@ -131,16 +131,9 @@ var _ = Describe("Watch e2e", func() {
 			globalHelper: globalHelper,
 			objectHelper: helper,
 			console:      console,
-			args: struct {
-				format         string
-				filter         string
-				includeDeleted bool
-				watch          bool
-			}{
-				format: outputFormatTable,
-				watch:  true,
-			},
 		}
+		runner.args.format = outputFormatTable
+		runner.args.watch = true
 
 		// Start watching in a goroutine
 		done := make(chan error, 1)
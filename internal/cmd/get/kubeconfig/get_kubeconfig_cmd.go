@@ -14,9 +14,13 @@ language governing permissions and limitations under the License.
 package kubeconfig
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"slices"
 	"sort"
 
@@ -28,6 +32,7 @@ import (
 	"google.golang.org/protobuf/proto"
 	"gopkg.in/yaml.v3"
 
+	"github.com/innabox/fulfillment-cli/internal/cmd/get/token"
 	"github.com/innabox/fulfillment-cli/internal/config"
 	"github.com/innabox/fulfillment-cli/internal/exit"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
@@ -51,6 +56,13 @@ func Cmd() *cobra.Command {
 		"Name or identifier of the cluster.",
 	)
 	flags.MarkDeprecated("cluster", "use positional argument instead.\n")
+	flags.BoolVar(
+		&runner.args.execAuth,
+		"exec-auth",
+		false,
+		"Replace the credentials of the users of the kubeconfig with a call to this tool as a 'kubectl' "+
+			"credential plugin, so that the OAuth session is used to authenticate to the cluster.",
+	)
 	return result
 }
 
@@ -60,7 +72,8 @@ type runnerContext struct {
 	console *terminal.Console
 	conn    *grpc.ClientConn
 	args    struct {
-		key string
+		key      string
+		execAuth bool
 	}
 }
 
@@ -160,6 +173,19 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	kcText := getKubeconfigResponse.GetKubeconfig()
 	var kcYaml any
 	err = yaml.Unmarshal([]byte(kcText), &kcYaml)
+	if c.args.execAuth {
+		if err != nil {
+			return fmt.Errorf("failed to parse kubeconfig: %w", err)
+		}
+		exec, err := c.makeExec(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		err = replaceUserCredentials(kcYaml, exec)
+		if err != nil {
+			return err
+		}
+	}
 	if err != nil {
 		c.logger.ErrorContext(
 			ctx,
@@ -173,3 +199,74 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// makeExec creates the 'exec' section of a kubeconfig user that calls this tool to get the token of the given
+// configuration context. When the context exists in the configuration file its name and the location of the file are
+// explicitly passed, so that the kubeconfig keeps working if the current context is changed. Otherwise, for example
+// when the configuration comes only from environment variables, they aren't passed, as the tool would then fail
+// because the context doesn't exist.
+func (c *runnerContext) makeExec(ctx context.Context, cfg *config.Config) (result map[string]any, err error) {
+	command, err := os.Executable()
+	if err != nil {
+		err = fmt.Errorf("failed to get the path of the executable: %w", err)
+		return
+	}
+	args := []any{
+		"get",
+		"token",
+		"--exec-credential",
+	}
+	file, err := config.LoadFile(ctx)
+	if err != nil {
+		return
+	}
+	if file.Contexts[cfg.Name()] != nil {
+		var location string
+		location, err = config.Location(ctx)
+		if err != nil {
+			return
+		}
+		location, err = filepath.Abs(location)
+		if err != nil {
+			return
+		}
+		args = append(
+			args,
+			"--context",
+			cfg.Name(),
+			"--config",
+			location,
+		)
+	}
+	result = map[string]any{
+		"apiVersion":         token.ExecCredentialApiVersion,
+		"command":            command,
+		"args":               args,
+		"interactiveMode":    "Never",
+		"provideClusterInfo": false,
+	}
+	return
+}
+
+// replaceUserCredentials replaces the credentials of all the users of the given kubeconfig with the given 'exec'
+// section.
+func replaceUserCredentials(kubeconfig any, exec map[string]any) error {
+	object, ok := kubeconfig.(map[string]any)
+	if !ok {
+		return fmt.Errorf("kubeconfig should be an object, but it is of type %T", kubeconfig)
+	}
+	users, _ := object["users"].([]any)
+	if len(users) == 0 {
+		return errors.New("kubeconfig doesn't contain any user")
+	}
+	for i, user := range users {
+		entry, ok := user.(map[string]any)
+		if !ok {
+			return fmt.Errorf("user %d of kubeconfig should be an object, but it is of type %T", i, user)
+		}
+		entry["user"] = map[string]any{
+			"exec": exec,
+		}
+	}
+	return nil
+}
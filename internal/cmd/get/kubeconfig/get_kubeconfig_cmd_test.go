@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+)
+
+var _ = Describe("Replace user credentials", func() {
+	exec := map[string]any{
+		"apiVersion": "client.authentication.k8s.io/v1",
+		"command":    "/usr/bin/fulfillment-cli",
+	}
+
+	parse := func(text string) any {
+		var result any
+		err := yaml.Unmarshal([]byte(text), &result)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	It("Replaces the static credentials with the exec section", func() {
+		kubeconfig := parse(`
+clusters:
+- name: my-cluster
+  cluster:
+    server: https://api.my-cluster.example.com:6443
+users:
+- name: admin
+  user:
+    client-certificate-data: Y2VydA==
+    client-key-data: a2V5
+- name: other
+  user:
+    token: my-token
+`)
+		err := replaceUserCredentials(kubeconfig, exec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kubeconfig).To(Equal(parse(`
+clusters:
+- name: my-cluster
+  cluster:
+    server: https://api.my-cluster.example.com:6443
+users:
+- name: admin
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: /usr/bin/fulfillment-cli
+- name: other
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: /usr/bin/fulfillment-cli
+`)))
+	})
+
+	It("Fails if there are no users", func() {
+		err := replaceUserCredentials(parse("clusters: []"), exec)
+		Expect(err).To(MatchError("kubeconfig doesn't contain any user"))
+	})
+
+	It("Fails if the kubeconfig isn't an object", func() {
+		err := replaceUserCredentials(parse("- a"), exec)
+		Expect(err).To(MatchError(ContainSubstring("kubeconfig should be an object")))
+	})
+})
+
+var _ = Describe("Make exec section", func() {
+	var (
+		ctx      context.Context
+		location string
+		runner   *runnerContext
+	)
+
+	BeforeEach(func() {
+		logger := slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		location = filepath.Join(GinkgoT().TempDir(), "config.json")
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = config.LocationIntoContext(ctx, location)
+		runner = &runnerContext{}
+	})
+
+	It("Passes the context and the configuration file when the context exists", func() {
+		cfg, err := config.New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "localhost:8000"
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err = config.Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		exec, err := runner.makeExec(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exec["args"]).To(Equal([]any{
+			"get",
+			"token",
+			"--exec-credential",
+			"--context",
+			cfg.Name(),
+			"--config",
+			location,
+		}))
+	})
+
+	It("Doesn't pass the context or the configuration file when there is no configuration file", func() {
+		cfg, err := config.Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		exec, err := runner.makeExec(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exec["args"]).To(Equal([]any{
+			"get",
+			"token",
+			"--exec-credential",
+		}))
+	})
+})
@@ -15,10 +15,12 @@ package token
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/innabox/fulfillment-common/auth"
 	"github.com/innabox/fulfillment-common/logging"
 	json "github.com/neilotoole/jsoncolor"
 	"github.com/spf13/cobra"
@@ -72,20 +74,32 @@ func Cmd() *cobra.Command {
 		false,
 		"Displays the time claims using the UTC time zone.",
 	)
+	flags.BoolVar(
+		&runner.execCredential,
+		"exec-credential",
+		false,
+		"Print the access token as a Kubernetes 'ExecCredential' object, so that this command can be used "+
+			"as a 'kubectl' credential plugin.",
+	)
 
 	return result
 }
 
 type runnerContext struct {
-	logger  *slog.Logger
-	console *terminal.Console
-	refresh bool
-	header  bool
-	payload bool
-	rfc3339 bool
-	utc     bool
+	logger         *slog.Logger
+	console        *terminal.Console
+	refresh        bool
+	header         bool
+	payload        bool
+	rfc3339        bool
+	utc            bool
+	execCredential bool
 }
 
+// ExecCredentialApiVersion is the version of the Kubernetes client authentication API that is used for the
+// 'ExecCredential' objects generated by the '--exec-credential' flag.
+const ExecCredentialApiVersion = "client.authentication.k8s.io/v1"
+
 func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	var err error
 
@@ -96,6 +110,13 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	c.logger = logging.LoggerFromContext(ctx)
 	c.console = terminal.ConsoleFromContext(ctx)
 
+	// Check the flags:
+	if c.execCredential && (c.refresh || c.header || c.payload) {
+		return fmt.Errorf(
+			"flag '--exec-credential' can't be used together with '--refresh', '--header' or '--payload'",
+		)
+	}
+
 	// Get the configuration:
 	cfg, err := config.Load(ctx)
 	if err != nil {
@@ -145,6 +166,8 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 
 	// Print the token:
 	switch {
+	case c.execCredential:
+		c.console.RenderJson(ctx, c.makeExecCredential(ctx, token))
 	case c.header:
 		c.console.RenderJson(ctx, parsed.Header)
 	case c.payload:
@@ -157,6 +180,46 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// makeExecCredential creates the Kubernetes 'ExecCredential' object that contains the access token. The expiration
+// timestamp is taken from the 'exp' claim of the token, or from the expiry returned by the token source if the token
+// isn't a JSON web token. Note that 'kubectl' caches the token till that time, and without it it will cache it for the
+// life of the process.
+func (c *runnerContext) makeExecCredential(ctx context.Context, token *auth.Token) map[string]any {
+	status := map[string]any{
+		"token": token.Access,
+	}
+	expiry := token.Expiry
+	parser := jwt.NewParser(jwt.WithJSONNumber())
+	claims := jwt.MapClaims{}
+	_, _, err := parser.ParseUnverified(token.Access, claims)
+	if err != nil {
+		c.logger.DebugContext(
+			ctx,
+			"Failed to parse token as a JSON web token, will use the expiry from the token source",
+			slog.Any("error", err),
+		)
+	} else {
+		exp, err := claims.GetExpirationTime()
+		if err != nil {
+			c.logger.ErrorContext(
+				ctx,
+				"Failed to get expiration time from token",
+				slog.Any("error", err),
+			)
+		} else if exp != nil {
+			expiry = exp.Time
+		}
+	}
+	if !expiry.IsZero() {
+		status["expirationTimestamp"] = expiry.UTC().Format(time.RFC3339)
+	}
+	return map[string]any{
+		"apiVersion": ExecCredentialApiVersion,
+		"kind":       "ExecCredential",
+		"status":     status,
+	}
+}
+
 func (c *runnerContext) replaceTimeClaims(ctx context.Context, claims jwt.MapClaims) jwt.MapClaims {
 	result := jwt.MapClaims{}
 	for name, value := range claims {
@@ -0,0 +1,111 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package token
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+var _ = Describe("Get token command", func() {
+	var (
+		ctx    context.Context
+		output *bytes.Buffer
+	)
+
+	// saveToken saves the given access token to the default context.
+	saveToken := func(token string) {
+		cfg, err := config.New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "api.example.com:443"
+		cfg.AccessToken = token
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	// run runs the command with the given arguments.
+	run := func(args ...string) error {
+		cmd := Cmd()
+		cmd.SetArgs(args)
+		return cmd.ExecuteContext(ctx)
+	}
+
+	BeforeEach(func() {
+		logger := slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		output = &bytes.Buffer{}
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = terminal.ConsoleIntoContext(ctx, console)
+		ctx = config.LocationIntoContext(ctx, filepath.Join(GinkgoT().TempDir(), "config.json"))
+	})
+
+	Describe("Exec credential", func() {
+		It("Takes the expiration timestamp from the token", func() {
+			expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"sub": "1234",
+				"exp": expiry.Unix(),
+			}).SignedString([]byte("my_key"))
+			Expect(err).ToNot(HaveOccurred())
+			saveToken(token)
+			err = run("--exec-credential")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(MatchJSON(`{
+				"apiVersion": "client.authentication.k8s.io/v1",
+				"kind": "ExecCredential",
+				"status": {
+					"token": "` + token + `",
+					"expirationTimestamp": "` + expiry.UTC().Format(time.RFC3339) + `"
+				}
+			}`))
+		})
+
+		It("Omits the expiration timestamp if the token isn't a JSON web token", func() {
+			saveToken("my_token")
+			err := run("--exec-credential")
+			Expect(err).ToNot(HaveOccurred())
+			var credential map[string]any
+			err = json.Unmarshal(output.Bytes(), &credential)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(credential).To(HaveKeyWithValue("status", Equal(map[string]any{
+				"token": "my_token",
+			})))
+		})
+
+		It("Can't be combined with the payload flag", func() {
+			saveToken("my_token")
+			err := run("--exec-credential", "--payload")
+			Expect(err).To(MatchError(ContainSubstring("can't be used together")))
+		})
+	})
+})
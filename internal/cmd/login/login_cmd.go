@@ -15,12 +15,17 @@ package login
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"embed"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -32,11 +37,15 @@ import (
 	"github.com/spf13/pflag"
 	"google.golang.org/grpc"
 	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+	grpcmetadata "google.golang.org/grpc/metadata"
 
 	"github.com/innabox/fulfillment-cli/internal/config"
 	"github.com/innabox/fulfillment-cli/internal/exit"
 	internalnetwork "github.com/innabox/fulfillment-cli/internal/network"
+	"github.com/innabox/fulfillment-cli/internal/oidc"
+	"github.com/innabox/fulfillment-cli/internal/serviceaccount"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/version"
 	metadatav1 "github.com/innabox/fulfillment-common/api/metadata/v1"
 )
 
@@ -70,6 +79,22 @@ func Cmd() *cobra.Command {
 		[]string{},
 		"File or directory containing trusted CA certificates.",
 	)
+	flags.StringVar(
+		&runner.args.clientCert,
+		"client-cert",
+		"",
+		"File containing the PEM encoded client certificate presented to the OAuth and API servers.",
+	)
+	flags.StringVar(
+		&runner.args.clientKey,
+		"client-key",
+		"",
+		fmt.Sprintf(
+			"File containing the PEM encoded key of the client certificate. If the key is encrypted the "+
+				"passphrase is taken from the '%s' environment variable.",
+			internalnetwork.ClientKeyPassphraseEnvVar,
+		),
+	)
 	flags.StringVar(
 		&runner.args.address,
 		"address",
@@ -102,15 +127,24 @@ func Cmd() *cobra.Command {
 		&runner.args.oauthIssuer,
 		"oauth-issuer",
 		"",
-		"OAuth issuer URL. This is optional. By default the first issuer advertised by the server is used.",
+		"OAuth issuer URL. This is optional. It must be one of the issuers advertised by the server, unless "+
+			"the '--force' flag is used. By default if the server advertises multiple issuers you will be "+
+			"asked to choose one, or the first one will be used if the terminal isn't interactive.",
+	)
+	flags.BoolVar(
+		&runner.args.force,
+		"force",
+		false,
+		"Use the OAuth issuer given with '--oauth-issuer' even if the server doesn't advertise it.",
 	)
 	flags.StringVar(
 		&runner.args.oauthFlow,
 		"oauth-flow",
 		string(oauth.DeviceFlow),
 		fmt.Sprintf(
-			"OAuth flow to use. Must be '%s', '%s' or '%s'.",
-			oauth.CodeFlow, oauth.DeviceFlow, oauth.CredentialsFlow,
+			"OAuth flow to use. Must be '%s', '%s' or '%s'. By default the '%s' flow is used if the issuer "+
+				"supports it, otherwise the '%s' flow.",
+			oauth.CodeFlow, oauth.DeviceFlow, oauth.CredentialsFlow, oauth.DeviceFlow, oauth.CodeFlow,
 		),
 	)
 	flags.StringVar(
@@ -144,6 +178,27 @@ func Cmd() *cobra.Command {
 			defaultRedirectUri,
 		),
 	)
+	flags.BoolVar(
+		&runner.args.serviceAccount,
+		"service-account",
+		false,
+		"Authenticate with the token of the Kubernetes service account of the pod where the tool runs. "+
+			"This is the default when running inside a pod and there is no configuration yet.",
+	)
+	flags.StringVar(
+		&runner.args.serviceAccountTokenFile,
+		"service-account-token-file",
+		serviceaccount.DefaultTokenFile,
+		"File containing the token of the Kubernetes service account. It is read again for each "+
+			"request, so that rotated tokens are used.",
+	)
+	flags.BoolVar(
+		&runner.args.tokenExchange,
+		"token-exchange",
+		false,
+		"Exchange the service account token for a token of the OAuth issuer, using the token exchange "+
+			"grant described in RFC 8693.",
+	)
 	flags.MarkHidden("address")
 	flags.MarkHidden("private")
 	flags.MarkHidden("token")
@@ -152,27 +207,39 @@ func Cmd() *cobra.Command {
 }
 
 type runnerContext struct {
-	logger     *slog.Logger
-	console    *terminal.Console
-	flags      *pflag.FlagSet
-	address    string
-	plaintext  bool
-	caPool     *x509.CertPool
-	tokenStore auth.TokenStore
-	args       struct {
-		plaintext         bool
-		insecure          bool
-		caFiles           []string
-		address           string
-		private           bool
-		token             string
-		tokenScript       string
-		oauthIssuer       string
-		oauthFlow         string
-		oauthClientId     string
-		oauthClientSecret string
-		oauthScopes       []string
-		oauthRedirectUri  string
+	logger         *slog.Logger
+	console        *terminal.Console
+	flags          *pflag.FlagSet
+	address        string
+	plaintext      bool
+	caPool         *x509.CertPool
+	clientCert     config.PemFile
+	clientKey      config.PemFile
+	certificates   []tls.Certificate
+	issuers        map[string]*oidc.Metadata
+	tokenStore     auth.TokenStore
+	serviceAccount bool
+	serverVersion  string
+	args           struct {
+		plaintext               bool
+		insecure                bool
+		caFiles                 []string
+		clientCert              string
+		clientKey               string
+		address                 string
+		private                 bool
+		token                   string
+		tokenScript             string
+		oauthIssuer             string
+		force                   bool
+		oauthFlow               string
+		oauthClientId           string
+		oauthClientSecret       string
+		oauthScopes             []string
+		oauthRedirectUri        string
+		serviceAccount          bool
+		serviceAccountTokenFile string
+		tokenExchange           bool
 	}
 }
 
@@ -209,6 +276,12 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to parse address: %w", err)
 	}
 
+	// Addresses like 'unix:///...' or 'dns:///...' don't say anything about TLS, so for them we use the value of
+	// the plaintext flag.
+	if internalnetwork.IsTarget(c.address) {
+		c.plaintext = c.args.plaintext
+	}
+
 	// Check if the plaintext flag has been explcitly set, and if it conflicts with the result of parsing the
 	// address. If it does conflict, then explain the issue to the user.
 	if c.flags.Changed("plaintext") && c.plaintext != c.args.plaintext {
@@ -219,6 +292,12 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		return exit.Error(1)
 	}
 
+	// Decide if the token of the Kubernetes service account should be used:
+	err = c.selectServiceAccount(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Create the CA pool:
 	c.caPool, err = network.NewCertPool().
 		SetLogger(c.logger).
@@ -230,13 +309,33 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create CA pool: %w", err)
 	}
 
+	// Load the client certificate. As with the CA files, we need to save the content of the files that are
+	// relative.
+	if c.args.clientCert != "" {
+		c.clientCert, err = config.NewClientCertFile(c.args.clientCert)
+		if err != nil {
+			return err
+		}
+	}
+	if c.args.clientKey != "" {
+		c.clientKey, err = config.NewClientKeyFile(c.args.clientKey)
+		if err != nil {
+			return err
+		}
+	}
+	c.certificates, err = config.LoadClientCertificates(c.clientCert, c.clientKey)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
 	// Create an anonymous gRPC client that we will use to fetch the metadata:
-	grpcConn, err := network.NewGrpcClient().
+	grpcConn, err := internalnetwork.NewGrpcClient().
 		SetLogger(c.logger).
-		SetFlags(c.flags, network.GrpcClientName).
+		SetFlags(c.flags).
 		SetPlaintext(c.plaintext).
 		SetInsecure(c.args.insecure).
 		SetCaPool(c.caPool).
+		AddClientCertificates(c.certificates...).
 		SetAddress(c.address).
 		Build()
 	if err != nil {
@@ -269,12 +368,31 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	// Select the token issuer. The result may be no issuer, which means that no authentication will be used, it
 	// will all be anonoymous.
 	tokenIssuer, err := c.selectTokenIssuer(ctx, metadata.GetAuthn())
+	if _, ok := err.(exit.Error); ok {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to select token issuer: %w", err)
 	}
 
-	// Create an empty configuration and a token store that will load/save tokens from/to that configuration:
-	cfg := &config.Config{}
+	// Select the OAuth flow according to what the issuer supports. Note that when using a service account the
+	// token isn't obtained with an OAuth flow, the issuer is only used to exchange it.
+	if c.args.tokenExchange && tokenIssuer == "" {
+		return errors.New(
+			"token exchange requires an OAuth issuer, but the server doesn't advertise any, use the " +
+				"'--oauth-issuer' and '--force' flags to select one",
+		)
+	}
+	if tokenIssuer != "" && c.args.token == "" && c.args.tokenScript == "" && !c.serviceAccount {
+		c.selectFlow(ctx, tokenIssuer)
+	}
+
+	// Create an empty configuration for the selected context and a token store that will load/save tokens from/to
+	// that configuration:
+	cfg, err := config.New(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create configuration: %w", err)
+	}
 	c.tokenStore = cfg.TokenStore()
 
 	// Create the token source only if a token issuer has been selected.
@@ -297,26 +415,20 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	cfg.Insecure = c.args.insecure
 	cfg.Address = c.address
 	cfg.Private = c.args.private
+	cfg.ServerVersion = c.serverVersion
 
 	// For CA files that are absolute we need to store only the path, but for those that are relative we need to
 	// save the content because otherwise we will not be able to use them when the command is executed from a
 	// different directory.
 	for _, caFile := range c.args.caFiles {
-		if filepath.IsAbs(caFile) {
-			cfg.CaFiles = append(cfg.CaFiles, config.CaFile{
-				Name: caFile,
-			})
-		} else {
-			caContent, err := os.ReadFile(caFile)
-			if err != nil {
-				return fmt.Errorf("failed to read CA file '%s': %w", caFile, err)
-			}
-			cfg.CaFiles = append(cfg.CaFiles, config.CaFile{
-				Name:    caFile,
-				Content: string(caContent),
-			})
+		caEntry, err := config.NewCaFile(caFile)
+		if err != nil {
+			return err
 		}
+		cfg.CaFiles = append(cfg.CaFiles, caEntry)
 	}
+	cfg.ClientCert = c.clientCert
+	cfg.ClientKey = c.clientKey
 
 	// Save the authenticatoin configuration. Note that the OAuth settings are only saved when they are actually
 	// used, and they won't be actually used if the user selected to use a static token or a token script.
@@ -324,6 +436,18 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		cfg.AccessToken = c.args.token
 	} else if c.args.tokenScript != "" {
 		cfg.TokenScript = c.args.tokenScript
+	} else if c.serviceAccount {
+		cfg.ServiceAccountTokenFile, err = filepath.Abs(c.args.serviceAccountTokenFile)
+		if err != nil {
+			return err
+		}
+		if c.args.tokenExchange {
+			cfg.TokenExchange = true
+			cfg.OauthIssuer = tokenIssuer
+			cfg.OAuthClientId = c.args.oauthClientId
+			cfg.OAuthClientSecret = c.args.oauthClientSecret
+			cfg.OAuthScopes = c.args.oauthScopes
+		}
 	} else if tokenIssuer != "" {
 		cfg.OauthIssuer = tokenIssuer
 		cfg.OAuthFlow = oauth.Flow(c.args.oauthFlow)
@@ -338,12 +462,13 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to close anonymous gRPC connection: %w", err)
 	}
-	grpcConn, err = network.NewGrpcClient().
+	grpcConn, err = internalnetwork.NewGrpcClient().
 		SetLogger(c.logger).
-		SetFlags(c.flags, network.GrpcClientName).
+		SetFlags(c.flags).
 		SetPlaintext(c.plaintext).
 		SetInsecure(c.args.insecure).
 		SetCaPool(c.caPool).
+		AddClientCertificates(c.certificates...).
 		SetAddress(c.address).
 		SetTokenSource(tokenSource).
 		Build()
@@ -361,15 +486,50 @@ func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("server is not serving, status is '%s'", healthResponse.Status)
 	}
 
-	// Everything is working, so we can save the configuration:
-	err = config.Save(cfg)
+	// Everything is working, so we can save the configuration and make its context the current one:
+	err = config.Save(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
+	err = config.UseContext(ctx, cfg.Name())
+	if err != nil {
+		return fmt.Errorf("failed to set current context: %w", err)
+	}
 
 	return nil
 }
 
+// selectServiceAccount decides if the token of the Kubernetes service account should be used. That happens when
+// explicitly requested with the '--service-account' flag, and also when running inside a pod and no other
+// authentication mechanism has been requested and the configuration context doesn't exist yet.
+func (c *runnerContext) selectServiceAccount(ctx context.Context) error {
+	explicitAuth := c.args.token != "" || c.args.tokenScript != ""
+	if c.flags.Changed("service-account") {
+		if c.args.serviceAccount && explicitAuth {
+			return errors.New("flag '--service-account' can't be used together with '--token' or '--token-script'")
+		}
+		c.serviceAccount = c.args.serviceAccount
+	} else if !explicitAuth && !c.flags.Changed("oauth-issuer") && !c.flags.Changed("oauth-flow") {
+		file, err := config.LoadFile(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		if file.Contexts[file.Selected(ctx).Name()] == nil {
+			c.serviceAccount = serviceaccount.Detect(c.args.serviceAccountTokenFile)
+		}
+		if c.serviceAccount {
+			c.console.Printf(
+				ctx,
+				"Running inside a Kubernetes pod, will use the token of its service account.\n",
+			)
+		}
+	}
+	if c.args.tokenExchange && !c.serviceAccount {
+		return errors.New("flag '--token-exchange' can only be used together with '--service-account'")
+	}
+	return nil
+}
+
 // parseAddress parses the address and returns the address and whether accoding to that address the connection should
 // use plaintext, without TLS.
 func (c *runnerContext) parseAddress(text string) (address string, plaintext bool, err error) {
@@ -387,32 +547,171 @@ func (c *runnerContext) parseAddress(text string) (address string, plaintext boo
 func (c *runnerContext) fetchMetadata(ctx context.Context,
 	grpcConn *grpc.ClientConn) (result *metadatav1.MetadataGetResponse, err error) {
 	metadataClient := metadatav1.NewMetadataClient(grpcConn)
-	result, err = metadataClient.Get(ctx, metadatav1.MetadataGetRequest_builder{}.Build())
+	var header, trailer grpcmetadata.MD
+	result, err = metadataClient.Get(
+		ctx,
+		metadatav1.MetadataGetRequest_builder{}.Build(),
+		grpc.Header(&header),
+		grpc.Trailer(&trailer),
+	)
+	if err != nil {
+		return
+	}
+	c.serverVersion = version.ServerFromMetadata(header, trailer)
 	return
 }
 
+// selectTokenIssuer selects the token issuer that will be used. If the user explicitly selected one with the
+// '--oauth-issuer' flag it checks that the server advertises it, unless the '--force' flag is also used. If the user
+// didn't select one and the server advertises more than one, it asks the user to choose if the console is interactive.
 func (c *runnerContext) selectTokenIssuer(ctx context.Context, metadata *metadatav1.Authn) (result string, err error) {
 	advertisedIssuers := metadata.GetTrustedTokenIssuers()
-	if len(advertisedIssuers) > 0 {
-		result = advertisedIssuers[0]
-		if len(advertisedIssuers) > 1 {
+
+	// If the user selected an issuer explicitly then check that it is one of the advertised ones:
+	if c.args.oauthIssuer != "" {
+		advertised := slices.ContainsFunc(advertisedIssuers, func(advertisedIssuer string) bool {
+			return sameIssuer(advertisedIssuer, c.args.oauthIssuer)
+		})
+		if !advertised {
+			if !c.args.force {
+				c.console.Render(ctx, "issuer_not_advertised.txt", map[string]any{
+					"Issuer":     c.args.oauthIssuer,
+					"Advertised": advertisedIssuers,
+				})
+				err = exit.Error(1)
+				return
+			}
 			c.logger.WarnContext(
 				ctx,
-				"Server advertises multiple issuers, selecting the first one",
+				"Using issuer that isn't advertised by the server",
+				slog.String("selected", c.args.oauthIssuer),
 				slog.Any("advertised", advertisedIssuers),
-				slog.Any("selected", result),
 			)
 		}
-	} else {
+		result = c.args.oauthIssuer
+		return
+	}
+
+	// Otherwise select one of the advertised issuers:
+	switch len(advertisedIssuers) {
+	case 0:
 		c.logger.WarnContext(
 			ctx,
 			"Server advertises no issuers",
 			slog.Any("selected", result),
 		)
+	case 1:
+		result = advertisedIssuers[0]
+	default:
+		if !c.console.Interactive() {
+			result = advertisedIssuers[0]
+			c.logger.WarnContext(
+				ctx,
+				"Server advertises multiple issuers and the console isn't interactive, selecting the "+
+					"first one",
+				slog.Any("advertised", advertisedIssuers),
+				slog.Any("selected", result),
+			)
+			return
+		}
+		options := make([]string, len(advertisedIssuers))
+		for i, advertisedIssuer := range advertisedIssuers {
+			options[i] = advertisedIssuer
+			issuerMetadata := c.discoverIssuer(ctx, advertisedIssuer)
+			if issuerMetadata == nil {
+				options[i] += " (metadata not available)"
+				continue
+			}
+			flows := issuerMetadata.Flows()
+			if len(flows) > 0 {
+				names := make([]string, len(flows))
+				for j, flow := range flows {
+					names[j] = string(flow)
+				}
+				options[i] += fmt.Sprintf(" (%s)", strings.Join(names, ", "))
+			}
+		}
+		var index int
+		index, err = c.console.Choose(ctx, "The server advertises multiple OAuth issuers:", options)
+		if err != nil {
+			return
+		}
+		result = advertisedIssuers[index]
 	}
 	return
 }
 
+// selectFlow selects the OAuth flow. If the user didn't explicitly select one then it will be the first interactive
+// flow supported by the issuer, according to its metadata.
+func (c *runnerContext) selectFlow(ctx context.Context, issuer string) {
+	issuerMetadata := c.discoverIssuer(ctx, issuer)
+	if issuerMetadata == nil {
+		return
+	}
+	flow := oauth.Flow(c.args.oauthFlow)
+	if c.flags.Changed("oauth-flow") {
+		if !issuerMetadata.SupportsFlow(flow) {
+			c.logger.WarnContext(
+				ctx,
+				"Issuer doesn't seem to support the selected flow",
+				slog.String("issuer", issuer),
+				slog.String("flow", string(flow)),
+			)
+		}
+		return
+	}
+	flows := issuerMetadata.Flows()
+	if len(flows) > 0 && flows[0] != flow {
+		c.logger.DebugContext(
+			ctx,
+			"Selected flow supported by the issuer",
+			slog.String("issuer", issuer),
+			slog.String("flow", string(flows[0])),
+		)
+		c.args.oauthFlow = string(flows[0])
+	}
+}
+
+// discoverIssuer fetches the metadata of the given issuer. The result is cached, and it is nil if the metadata can't
+// be fetched.
+func (c *runnerContext) discoverIssuer(ctx context.Context, issuer string) *oidc.Metadata {
+	if c.issuers == nil {
+		c.issuers = map[string]*oidc.Metadata{}
+	}
+	result, ok := c.issuers[issuer]
+	if ok {
+		return result
+	}
+	client, err := oidc.NewDiscoveryClient().
+		SetLogger(c.logger).
+		SetHttpClient(&http.Client{
+			Timeout:   discoveryTimeout,
+			Transport: internalnetwork.NewHttpTransport(c.caPool, c.args.insecure, c.certificates),
+		}).
+		Build()
+	if err == nil {
+		result, err = client.Discover(ctx, issuer)
+	}
+	if err != nil {
+		c.logger.WarnContext(
+			ctx,
+			"Failed to discover issuer metadata",
+			slog.String("issuer", issuer),
+			slog.Any("error", err),
+		)
+	}
+	c.issuers[issuer] = result
+	return result
+}
+
+// sameIssuer checks if the given issuer URLs are the same, ignoring trailing slashes.
+func sameIssuer(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+// discoveryTimeout is the maximum time that we wait for the metadata of an issuer.
+const discoveryTimeout = 30 * time.Second
+
 // createTokenSource creates a token source from the configuration. The token source will be nil if no token, token
 // script or token issuer has been specified.
 func (c *runnerContext) createTokenSource(ctx context.Context, tokenIssuer string) (result auth.TokenSource, err error) {
@@ -443,8 +742,31 @@ func (c *runnerContext) createTokenSource(ctx context.Context, tokenIssuer strin
 		return
 	}
 
+	// Use the service account token if selected, exchanging it if requested:
+	if c.serviceAccount {
+		builder := serviceaccount.NewTokenSource().
+			SetLogger(c.logger).
+			SetFile(c.args.serviceAccountTokenFile).
+			SetHttpClient(&http.Client{
+				Timeout:   discoveryTimeout,
+				Transport: internalnetwork.NewHttpTransport(c.caPool, c.args.insecure, c.certificates),
+			})
+		if c.args.tokenExchange {
+			builder.SetIssuer(tokenIssuer).
+				SetClientId(c.args.oauthClientId).
+				SetClientSecret(c.args.oauthClientSecret).
+				SetScopes(c.args.oauthScopes...)
+		}
+		result, err = builder.Build()
+		if err != nil {
+			err = fmt.Errorf("failed to create service account token source: %w", err)
+		}
+		return
+	}
+
 	// If a token issuer has been selected, then use OAuth to create a token source:
 	if tokenIssuer != "" {
+		httpClient := &http.Client{}
 		result, err = oauth.NewTokenSource().
 			SetLogger(c.logger).
 			SetStore(c.tokenStore).
@@ -460,10 +782,16 @@ func (c *runnerContext) createTokenSource(ctx context.Context, tokenIssuer strin
 			SetClientSecret(c.args.oauthClientSecret).
 			SetScopes(c.args.oauthScopes...).
 			SetRedirectUri(c.args.oauthRedirectUri).
+			SetHttpClient(httpClient).
 			Build()
 		if err != nil {
 			err = fmt.Errorf("failed to create OAuth token source: %w", err)
+			return
 		}
+
+		// The builder replaces the transport of the HTTP client with one that doesn't present client
+		// certificates, so we need to put ours back after building the token source.
+		httpClient.Transport = internalnetwork.NewHttpTransport(c.caPool, c.args.insecure, c.certificates)
 		return
 	}
 
@@ -0,0 +1,292 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package login
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	metadatav1 "github.com/innabox/fulfillment-common/api/metadata/v1"
+	"github.com/innabox/fulfillment-common/logging"
+	"github.com/innabox/fulfillment-common/oauth"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/exit"
+	"github.com/innabox/fulfillment-cli/internal/serviceaccount"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+var _ = Describe("Login command", func() {
+	var (
+		ctx    context.Context
+		logger *slog.Logger
+		output *bytes.Buffer
+		runner *runnerContext
+	)
+
+	// makeIssuer starts an HTTP server that returns the given discovery document, and returns its URL.
+	makeIssuer := func(metadata map[string]any) string {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/.well-known/openid-configuration" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body := map[string]any{
+				"issuer": server.URL,
+			}
+			for name, value := range metadata {
+				body[name] = strings.ReplaceAll(value.(string), "ISSUER", server.URL)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(body)
+		}))
+		DeferCleanup(server.Close)
+		return server.URL
+	}
+
+	// makeRunner creates the runner with a console that reads the given input, and parses the given flags.
+	makeRunner := func(input string, interactive bool, args ...string) {
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetReader(strings.NewReader(input)).
+			SetWriter(output).
+			SetInteractive(interactive).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = console.AddTemplates(templatesFS, "templates")
+		Expect(err).ToNot(HaveOccurred())
+		cmd := Cmd()
+		err = cmd.ParseFlags(args)
+		Expect(err).ToNot(HaveOccurred())
+		runner = &runnerContext{
+			logger:  logger,
+			console: console,
+			flags:   cmd.Flags(),
+		}
+		runner.args.oauthIssuer, err = cmd.Flags().GetString("oauth-issuer")
+		Expect(err).ToNot(HaveOccurred())
+		runner.args.force, err = cmd.Flags().GetBool("force")
+		Expect(err).ToNot(HaveOccurred())
+		runner.args.oauthFlow, err = cmd.Flags().GetString("oauth-flow")
+		Expect(err).ToNot(HaveOccurred())
+		runner.args.token, err = cmd.Flags().GetString("token")
+		Expect(err).ToNot(HaveOccurred())
+		runner.args.serviceAccount, err = cmd.Flags().GetBool("service-account")
+		Expect(err).ToNot(HaveOccurred())
+		runner.args.serviceAccountTokenFile, err = cmd.Flags().GetString("service-account-token-file")
+		Expect(err).ToNot(HaveOccurred())
+		runner.args.tokenExchange, err = cmd.Flags().GetBool("token-exchange")
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	// makeAuthn creates the authentication metadata advertising the given issuers.
+	makeAuthn := func(issuers ...string) *metadatav1.Authn {
+		return metadatav1.Authn_builder{
+			TrustedTokenIssuers: issuers,
+		}.Build()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		logger = slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		output = &bytes.Buffer{}
+	})
+
+	Describe("Issuer selection", func() {
+		It("Selects the only advertised issuer without asking", func() {
+			makeRunner("", true)
+			issuer, err := runner.selectTokenIssuer(ctx, makeAuthn("https://sso.example.com"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(issuer).To(Equal("https://sso.example.com"))
+			Expect(output.String()).To(BeEmpty())
+		})
+
+		It("Selects the first issuer if the console isn't interactive", func() {
+			makeRunner("", false)
+			issuer, err := runner.selectTokenIssuer(
+				ctx,
+				makeAuthn("https://sso.example.com", "https://sa.example.com"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(issuer).To(Equal("https://sso.example.com"))
+		})
+
+		It("Asks the user to choose if there are multiple issuers", func() {
+			sso := makeIssuer(map[string]any{
+				"authorization_endpoint":        "ISSUER/auth",
+				"device_authorization_endpoint": "ISSUER/device",
+			})
+			sa := makeIssuer(map[string]any{
+				"token_endpoint": "ISSUER/token",
+			})
+			makeRunner("2\n", true)
+			issuer, err := runner.selectTokenIssuer(ctx, makeAuthn(sso, sa))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(issuer).To(Equal(sa))
+			Expect(output.String()).To(ContainSubstring("1. " + sso + " (device, code)"))
+			Expect(output.String()).To(ContainSubstring("2. " + sa + "\n"))
+		})
+
+		It("Accepts explicit issuer that is advertised", func() {
+			makeRunner("", true, "--oauth-issuer", "https://sa.example.com/")
+			issuer, err := runner.selectTokenIssuer(
+				ctx,
+				makeAuthn("https://sso.example.com", "https://sa.example.com"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(issuer).To(Equal("https://sa.example.com/"))
+			Expect(output.String()).To(BeEmpty())
+		})
+
+		It("Rejects explicit issuer that isn't advertised", func() {
+			makeRunner("", true, "--oauth-issuer", "https://other.example.com")
+			_, err := runner.selectTokenIssuer(ctx, makeAuthn("https://sso.example.com"))
+			Expect(err).To(Equal(exit.Error(1)))
+			Expect(output.String()).To(ContainSubstring(
+				"The OAuth issuer 'https://other.example.com' isn't advertised by the server.",
+			))
+			Expect(output.String()).To(ContainSubstring("https://sso.example.com"))
+			Expect(output.String()).To(ContainSubstring("--force"))
+		})
+
+		It("Accepts explicit issuer that isn't advertised if forced", func() {
+			makeRunner("", true, "--oauth-issuer", "https://other.example.com", "--force")
+			issuer, err := runner.selectTokenIssuer(ctx, makeAuthn("https://sso.example.com"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(issuer).To(Equal("https://other.example.com"))
+		})
+	})
+
+	Describe("Flow selection", func() {
+		It("Uses the device flow if the issuer supports it", func() {
+			issuer := makeIssuer(map[string]any{
+				"authorization_endpoint":        "ISSUER/auth",
+				"device_authorization_endpoint": "ISSUER/device",
+			})
+			makeRunner("", false)
+			runner.selectFlow(ctx, issuer)
+			Expect(runner.args.oauthFlow).To(Equal(string(oauth.DeviceFlow)))
+		})
+
+		It("Uses the code flow if the issuer doesn't support the device flow", func() {
+			issuer := makeIssuer(map[string]any{
+				"authorization_endpoint": "ISSUER/auth",
+			})
+			makeRunner("", false)
+			runner.selectFlow(ctx, issuer)
+			Expect(runner.args.oauthFlow).To(Equal(string(oauth.CodeFlow)))
+		})
+
+		It("Preserves the explicitly selected flow", func() {
+			issuer := makeIssuer(map[string]any{
+				"authorization_endpoint": "ISSUER/auth",
+			})
+			makeRunner("", false, "--oauth-flow", "device")
+			runner.selectFlow(ctx, issuer)
+			Expect(runner.args.oauthFlow).To(Equal(string(oauth.DeviceFlow)))
+		})
+
+		It("Preserves the default flow if discovery fails", func() {
+			issuer := makeIssuer(nil)
+			makeRunner("", false)
+			runner.selectFlow(ctx, issuer+"/junk")
+			Expect(runner.args.oauthFlow).To(Equal(string(oauth.DeviceFlow)))
+		})
+	})
+
+	Describe("Service account selection", func() {
+		var tokenFile string
+
+		BeforeEach(func() {
+			ctx = logging.LoggerIntoContext(ctx, logger)
+			ctx = config.LocationIntoContext(ctx, filepath.Join(GinkgoT().TempDir(), "config.json"))
+			tokenFile = filepath.Join(GinkgoT().TempDir(), "token")
+			err := os.WriteFile(tokenFile, []byte("my_token"), 0600)
+			Expect(err).ToNot(HaveOccurred())
+			GinkgoT().Setenv(serviceaccount.HostEnvVar, "10.0.0.1")
+		})
+
+		It("Uses the service account when running in a pod without configuration", func() {
+			makeRunner("", false, "--service-account-token-file", tokenFile)
+			err := runner.selectServiceAccount(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.serviceAccount).To(BeTrue())
+			Expect(output.String()).To(ContainSubstring("will use the token of its service account"))
+		})
+
+		It("Doesn't use the service account when not running in a pod", func() {
+			GinkgoT().Setenv(serviceaccount.HostEnvVar, "")
+			makeRunner("", false, "--service-account-token-file", tokenFile)
+			err := runner.selectServiceAccount(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.serviceAccount).To(BeFalse())
+		})
+
+		It("Doesn't use the service account if the configuration already exists", func() {
+			cfg, err := config.New(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			cfg.Address = "api.example.com:443"
+			err = config.Save(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+			makeRunner("", false, "--service-account-token-file", tokenFile)
+			err = runner.selectServiceAccount(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.serviceAccount).To(BeFalse())
+		})
+
+		It("Doesn't use the service account if another mechanism has been requested", func() {
+			makeRunner(
+				"", false,
+				"--service-account-token-file", tokenFile,
+				"--oauth-issuer", "https://sso.example.com",
+			)
+			err := runner.selectServiceAccount(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.serviceAccount).To(BeFalse())
+		})
+
+		It("Uses the service account when explicitly requested", func() {
+			GinkgoT().Setenv(serviceaccount.HostEnvVar, "")
+			makeRunner("", false, "--service-account", "--service-account-token-file", tokenFile)
+			err := runner.selectServiceAccount(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.serviceAccount).To(BeTrue())
+			Expect(output.String()).To(BeEmpty())
+		})
+
+		It("Rejects the service account together with a token", func() {
+			makeRunner("", false, "--service-account", "--token", "my_token")
+			err := runner.selectServiceAccount(ctx)
+			Expect(err).To(MatchError(ContainSubstring("can't be used together")))
+		})
+
+		It("Rejects token exchange without service account", func() {
+			makeRunner("", false, "--token-exchange", "--service-account=false")
+			err := runner.selectServiceAccount(ctx)
+			Expect(err).To(MatchError(ContainSubstring("'--token-exchange' can only be used")))
+		})
+	})
+})
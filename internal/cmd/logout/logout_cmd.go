@@ -14,12 +14,17 @@ language governing permissions and limitations under the License.
 package logout
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"time"
+	"log/slog"
 
-	"github.com/innabox/fulfillment-cli/internal/config"
-	"github.com/innabox/fulfillment-common/oauth"
+	"github.com/innabox/fulfillment-common/logging"
 	"github.com/spf13/cobra"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/oidc"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
 )
 
 func Cmd() *cobra.Command {
@@ -27,45 +32,173 @@ func Cmd() *cobra.Command {
 	result := &cobra.Command{
 		Use:   "logout [flags]",
 		Short: "Discard connection and authentication details",
-		RunE:  runner.run,
+		Long: "Revoke the tokens at the OAuth server, if it supports it, and discard the connection and " +
+			"authentication details of the selected context.",
+		Args: cobra.NoArgs,
+		RunE: runner.run,
 	}
+	flags := result.Flags()
+	flags.BoolVar(
+		&runner.args.all,
+		"all",
+		false,
+		"Revoke the tokens of all the contexts and remove the configuration file.",
+	)
 	return result
 }
 
 type runnerContext struct {
+	logger  *slog.Logger
+	console *terminal.Console
+	args    struct {
+		all bool
+	}
 }
 
 func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
 	// Get the context:
 	ctx := cmd.Context()
 
-	// Load the configuration:
+	// Get the logger and the console:
+	c.logger = logging.LoggerFromContext(ctx)
+	c.console = terminal.ConsoleFromContext(ctx)
+
+	if c.args.all {
+		return c.logoutAll(ctx)
+	}
+	return c.logout(ctx)
+}
+
+// logout revokes the tokens of the selected context and clears all its settings. The settings that control the token
+// storage are preserved, as those are a choice of the user and not something that depends on the server.
+func (c *runnerContext) logout(ctx context.Context) error {
 	cfg, err := config.Load(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
-	if cfg == nil {
-		cfg = &config.Config{}
-	}
-
-	// Clear all the details:
-	cfg.AccessToken = ""
-	cfg.Plaintext = false
-	cfg.Insecure = false
-	cfg.Address = ""
-	cfg.RefreshToken = ""
-	cfg.TokenExpiry = time.Time{}
-	cfg.OAuthFlow = oauth.Flow("")
-	cfg.OauthIssuer = ""
-	cfg.OAuthClientId = ""
-	cfg.OAuthClientSecret = ""
-	cfg.OAuthScopes = nil
-
-	// Save the configuration:
-	err = config.Save(cfg)
+	c.revoke(ctx, cfg)
+	blank, err := config.New(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create configuration: %w", err)
+	}
+	err = config.Save(ctx, blank)
 	if err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
+	c.console.Printf(ctx, "Logged out of context '%s'.\n", cfg.Name())
+	return nil
+}
 
+// logoutAll revokes the tokens of all the contexts and then removes the configuration file.
+func (c *runnerContext) logoutAll(ctx context.Context) error {
+	file, err := config.LoadFile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	for _, name := range file.Names() {
+		cfg, err := config.Load(config.ContextNameIntoContext(ctx, name))
+		if err != nil {
+			c.console.Printf(ctx, "Failed to load context '%s', its tokens will not be revoked: %v\n", name, err)
+			continue
+		}
+		c.revoke(ctx, cfg)
+	}
+	err = config.DeleteFile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete configuration: %w", err)
+	}
+	location, err := config.Location(ctx)
+	if err != nil {
+		return err
+	}
+	c.console.Printf(ctx, "Removed all contexts and the configuration file '%s'.\n", location)
 	return nil
 }
+
+// revoke asks the OAuth server to revoke the tokens of the given configuration, and reports the result to the user.
+// Failures aren't errors, as the tokens will be removed locally anyhow.
+func (c *runnerContext) revoke(ctx context.Context, cfg *config.Config) {
+	// Only tokens obtained with OAuth can be revoked:
+	if cfg.OAuthFlow == "" || cfg.OauthIssuer == "" {
+		return
+	}
+	if cfg.AccessToken == "" && cfg.RefreshToken == "" {
+		return
+	}
+
+	// Find the revocation endpoint:
+	httpClient, err := cfg.HttpClient()
+	if err != nil {
+		c.reportFailure(ctx, cfg, err)
+		return
+	}
+	discoveryClient, err := oidc.NewDiscoveryClient().
+		SetLogger(c.logger).
+		SetHttpClient(httpClient).
+		Build()
+	if err != nil {
+		c.reportFailure(ctx, cfg, err)
+		return
+	}
+	metadata, err := discoveryClient.Discover(ctx, cfg.OauthIssuer)
+	if err != nil {
+		c.reportFailure(ctx, cfg, err)
+		return
+	}
+	if metadata.RevocationEndpoint == "" {
+		c.console.Printf(
+			ctx,
+			"The OAuth server of context '%s' doesn't support token revocation, the tokens have only "+
+				"been removed locally.\n",
+			cfg.Name(),
+		)
+		return
+	}
+
+	// Revoke the refresh token first, as for many servers that also revokes the access tokens that were obtained
+	// with it, and then the access token:
+	revoker, err := oidc.NewRevoker().
+		SetLogger(c.logger).
+		SetHttpClient(httpClient).
+		SetEndpoint(metadata.RevocationEndpoint).
+		SetClientId(cfg.OAuthClientId).
+		SetClientSecret(cfg.OAuthClientSecret).
+		Build()
+	if err != nil {
+		c.reportFailure(ctx, cfg, err)
+		return
+	}
+	var errs []error
+	if cfg.RefreshToken != "" {
+		err = revoker.Revoke(ctx, cfg.RefreshToken, oidc.RefreshTokenHint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to revoke refresh token: %w", err))
+		}
+	}
+	if cfg.AccessToken != "" {
+		err = revoker.Revoke(ctx, cfg.AccessToken, oidc.AccessTokenHint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to revoke access token: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		c.reportFailure(ctx, cfg, errors.Join(errs...))
+		return
+	}
+	c.console.Printf(ctx, "Revoked the tokens of context '%s'.\n", cfg.Name())
+}
+
+func (c *runnerContext) reportFailure(ctx context.Context, cfg *config.Config, err error) {
+	c.logger.ErrorContext(
+		ctx,
+		"Failed to revoke tokens",
+		slog.String("context", cfg.Name()),
+		slog.Any("error", err),
+	)
+	c.console.Printf(
+		ctx,
+		"Failed to revoke the tokens of context '%s': %v\n"+
+			"The tokens have been removed locally, but they may still be valid till they expire.\n",
+		cfg.Name(), err,
+	)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package logout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+)
+
+var _ = Describe("Logout command", func() {
+	var (
+		ctx      context.Context
+		output   *bytes.Buffer
+		location string
+		lock     *sync.Mutex
+		revoked  []string
+		status   int
+	)
+
+	// makeIssuer starts an HTTP server that acts as an OAuth server, optionally supporting token revocation, and
+	// returns its URL. The tokens that are revoked are saved to the 'revoked' variable.
+	makeIssuer := func(revocation bool) string {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/.well-known/openid-configuration":
+				body := map[string]any{
+					"issuer":         server.URL,
+					"token_endpoint": server.URL + "/token",
+				}
+				if revocation {
+					body["revocation_endpoint"] = server.URL + "/revoke"
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(body)
+			case "/revoke":
+				lock.Lock()
+				defer lock.Unlock()
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+				revoked = append(revoked, r.PostFormValue("token_type_hint")+"="+r.PostFormValue("token"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+		return server.URL
+	}
+
+	// saveContext saves a context with OAuth tokens obtained from the given issuer.
+	saveContext := func(name, issuer string) {
+		cfg, err := config.New(config.ContextNameIntoContext(ctx, name))
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = name + ".example.com:443"
+		cfg.Private = true
+		cfg.OAuthFlow = "credentials"
+		cfg.OauthIssuer = issuer
+		cfg.OAuthClientId = "my_client"
+		cfg.OAuthClientSecret = "my_secret"
+		cfg.OAuthRedirectUri = "http://localhost/callback"
+		cfg.AccessToken = name + "_access"
+		cfg.RefreshToken = name + "_refresh"
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	// run runs the command with the given arguments.
+	run := func(args ...string) error {
+		cmd := Cmd()
+		cmd.SetArgs(args)
+		return cmd.ExecuteContext(ctx)
+	}
+
+	BeforeEach(func() {
+		logger := slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		output = &bytes.Buffer{}
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		location = filepath.Join(GinkgoT().TempDir(), "config.json")
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = terminal.ConsoleIntoContext(ctx, console)
+		ctx = config.LocationIntoContext(ctx, location)
+		lock = &sync.Mutex{}
+		revoked = nil
+		status = http.StatusOK
+	})
+
+	It("Revokes the tokens and clears the context", func() {
+		issuer := makeIssuer(true)
+		saveContext(config.DefaultContext, issuer)
+		err := run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(revoked).To(Equal([]string{
+			"refresh_token=default_refresh",
+			"access_token=default_access",
+		}))
+		Expect(output.String()).To(ContainSubstring("Revoked the tokens of context 'default'."))
+		Expect(output.String()).To(ContainSubstring("Logged out of context 'default'."))
+
+		cfg, err := config.Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Address).To(BeEmpty())
+		Expect(cfg.Private).To(BeFalse())
+		Expect(cfg.OauthIssuer).To(BeEmpty())
+		Expect(cfg.OAuthClientId).To(BeEmpty())
+		Expect(cfg.OAuthClientSecret).To(BeEmpty())
+		Expect(cfg.OAuthRedirectUri).To(BeEmpty())
+		Expect(cfg.AccessToken).To(BeEmpty())
+		Expect(cfg.RefreshToken).To(BeEmpty())
+		data, err := os.ReadFile(location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).ToNot(ContainSubstring("default_access"))
+		Expect(string(data)).ToNot(ContainSubstring("default_refresh"))
+		Expect(string(data)).ToNot(ContainSubstring("my_secret"))
+	})
+
+	It("Clears the context even if revocation fails", func() {
+		issuer := makeIssuer(true)
+		status = http.StatusServiceUnavailable
+		saveContext(config.DefaultContext, issuer)
+		err := run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring("Failed to revoke the tokens of context 'default'"))
+		Expect(output.String()).To(ContainSubstring("they may still be valid"))
+		cfg, err := config.Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(BeEmpty())
+		Expect(cfg.RefreshToken).To(BeEmpty())
+	})
+
+	It("Explains that the tokens are only removed locally if revocation isn't supported", func() {
+		issuer := makeIssuer(false)
+		saveContext(config.DefaultContext, issuer)
+		err := run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(revoked).To(BeEmpty())
+		Expect(output.String()).To(ContainSubstring("doesn't support token revocation"))
+		cfg, err := config.Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(BeEmpty())
+	})
+
+	It("Doesn't touch other contexts", func() {
+		issuer := makeIssuer(true)
+		saveContext(config.DefaultContext, issuer)
+		saveContext("staging", issuer)
+		err := run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(revoked).To(HaveLen(2))
+		cfg, err := config.Load(config.ContextNameIntoContext(ctx, "staging"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Address).To(Equal("staging.example.com:443"))
+		Expect(cfg.AccessToken).To(Equal("staging_access"))
+	})
+
+	It("Revokes the tokens of all contexts and removes the file", func() {
+		issuer := makeIssuer(true)
+		saveContext(config.DefaultContext, issuer)
+		saveContext("staging", issuer)
+		err := run("--all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(revoked).To(ConsistOf(
+			"refresh_token=default_refresh",
+			"access_token=default_access",
+			"refresh_token=staging_refresh",
+			"access_token=staging_access",
+		))
+		Expect(location).ToNot(BeAnExistingFile())
+		Expect(output.String()).To(ContainSubstring("Removed all contexts"))
+	})
+})
@@ -14,14 +14,19 @@ language governing permissions and limitations under the License.
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/innabox/fulfillment-common/logging"
 	"github.com/spf13/cobra"
 
+	"github.com/innabox/fulfillment-cli/internal/cmd/auth"
+	"github.com/innabox/fulfillment-cli/internal/cmd/auth/status"
+	configcmd "github.com/innabox/fulfillment-cli/internal/cmd/config"
 	"github.com/innabox/fulfillment-cli/internal/cmd/create"
 	"github.com/innabox/fulfillment-cli/internal/cmd/delete"
 	"github.com/innabox/fulfillment-cli/internal/cmd/describe"
@@ -30,24 +35,59 @@ import (
 	"github.com/innabox/fulfillment-cli/internal/cmd/login"
 	"github.com/innabox/fulfillment-cli/internal/cmd/logout"
 	"github.com/innabox/fulfillment-cli/internal/cmd/version"
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/telemetry"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/timeout"
 )
 
 func Root() *cobra.Command {
 	// create the runner and the command:
 	runner := &runnerContext{}
 	result := &cobra.Command{
-		Use:               "fulfillment-cli",
-		Short:             "Command line interface for the fulfillment API",
+		Use:   "fulfillment-cli",
+		Short: "Command line interface for the fulfillment API",
+		Long: "Command line interface for the fulfillment API.\n\n" +
+			"OpenTelemetry traces of the calls are exported when the standard 'OTEL_EXPORTER_OTLP_ENDPOINT' " +
+			"or 'OTEL_TRACES_EXPORTER' environment variables are set. With the 'console' exporter the spans " +
+			"are written to the standard error, or to the file given in the '" + telemetry.FileEnvVar + "' " +
+			"environment variable.",
 		SilenceUsage:      true,
 		SilenceErrors:     true,
 		PersistentPreRunE: runner.persistentPreRun,
+		PersistentPostRun: runner.persistentPostRun,
 	}
 
 	// Add flags:
-	logging.AddFlags(result.PersistentFlags())
+	flags := result.PersistentFlags()
+	logging.AddFlags(flags)
+	flags.StringVar(
+		&runner.args.context,
+		"context",
+		"",
+		"Name of the configuration context to use. The default is to use the current context.",
+	)
+	flags.StringVar(
+		&runner.args.config,
+		"config",
+		"",
+		"Location of the configuration file. Can also be set with the '"+config.LocationEnvVar+"' "+
+			"environment variable. The default is to use 'fulfillment-cli/config.json' inside the user "+
+			"configuration directory.",
+	)
+	flags.DurationVar(
+		&runner.args.timeout,
+		"timeout",
+		0,
+		"Maximum time that the command can take, for example '30s' or '5m'. A value of zero means no limit. "+
+			"The default depends on the command: one minute for commands like 'get', 'describe' or 'delete', "+
+			"ten minutes for 'create', and no limit for 'get --watch'.",
+	)
+	config.AddFlags(flags)
 
 	// Add commands:
+	result.AddCommand(auth.Cmd())
+	result.AddCommand(configcmd.Cmd())
 	result.AddCommand(create.Cmd())
 	result.AddCommand(delete.Cmd())
 	result.AddCommand(describe.Cmd())
@@ -56,11 +96,18 @@ func Root() *cobra.Command {
 	result.AddCommand(login.Cmd())
 	result.AddCommand(logout.Cmd())
 	result.AddCommand(version.Cmd())
+	result.AddCommand(status.WhoamiCmd())
 
 	return result
 }
 
 type runnerContext struct {
+	args struct {
+		context string
+		config  string
+		timeout time.Duration
+	}
+	cancel context.CancelFunc
 }
 
 func (c *runnerContext) persistentPreRun(cmd *cobra.Command, args []string) error {
@@ -103,11 +150,49 @@ func (c *runnerContext) persistentPreRun(cmd *cobra.Command, args []string) erro
 		return fmt.Errorf("failed to create console: %w", err)
 	}
 
-	// Replace the default context with one that contains the logger and the console:
+	// Create the telemetry provider and start the root span of the command. The span is ended, and the provider
+	// shut down, by the main function once the command finishes.
+	telemetryProvider, err := telemetry.NewProvider().
+		SetLogger(logger).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to create telemetry provider: %w", err)
+	}
+	telemetryProvider.Register()
+
+	// Replace the default context with one that contains the logger, the console, the cache directory, the root
+	// span, the name of the selected configuration context and the location of the configuration file:
 	ctx := cmd.Context()
+	ctx = telemetryProvider.Start(ctx, cmd.CommandPath())
 	ctx = logging.LoggerIntoContext(ctx, logger)
 	ctx = terminal.ConsoleIntoContext(ctx, console)
+	ctx = config.CacheDirIntoContext(ctx, cacheDir)
+	if c.args.context != "" {
+		ctx = config.ContextNameIntoContext(ctx, c.args.context)
+	}
+	if c.args.config != "" {
+		ctx = config.LocationIntoContext(ctx, c.args.config)
+	}
+
+	// Set the deadline, either the one explicitly requested by the user or the default for the command:
+	limit := timeout.ForCommand(cmd)
+	if cmd.Flags().Changed("timeout") {
+		limit = c.args.timeout
+	}
+	if limit < 0 {
+		return fmt.Errorf("timeout should be positive or zero, but it is %s", limit)
+	}
+	if limit > 0 {
+		ctx = timeout.IntoContext(ctx, limit)
+		ctx, c.cancel = context.WithTimeout(ctx, limit)
+	}
 	cmd.SetContext(ctx)
 
 	return nil
 }
+
+func (c *runnerContext) persistentPostRun(cmd *cobra.Command, args []string) {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
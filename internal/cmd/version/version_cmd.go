@@ -14,34 +14,180 @@ language governing permissions and limitations under the License.
 package version
 
 import (
+	"embed"
+	"fmt"
+	"log/slog"
+
+	metadatav1 "github.com/innabox/fulfillment-common/api/metadata/v1"
+	"github.com/innabox/fulfillment-common/logging"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/innabox/fulfillment-cli/internal/config"
 	"github.com/innabox/fulfillment-cli/internal/terminal"
 	"github.com/innabox/fulfillment-cli/internal/version"
 )
 
+//go:embed templates
+var templatesFS embed.FS
+
+// Possible output formats:
+const (
+	outputFormatText = "text"
+	outputFormatJson = "json"
+	outputFormatYaml = "yaml"
+)
+
 func Cmd() *cobra.Command {
 	runner := &runnerContext{}
 	result := &cobra.Command{
 		Use:   "version",
 		Short: "Display version details",
+		Args:  cobra.NoArgs,
 		RunE:  runner.run,
 	}
+	flags := result.Flags()
+	flags.BoolVar(
+		&runner.args.server,
+		"server",
+		false,
+		"Also display the version of the server of the current context, and check if it is supported by "+
+			"this version of the CLI.",
+	)
+	flags.StringVarP(
+		&runner.args.format,
+		"output",
+		"o",
+		outputFormatText,
+		fmt.Sprintf(
+			"Output format, one of '%s', '%s' or '%s'.",
+			outputFormatText, outputFormatJson, outputFormatYaml,
+		),
+	)
 	return result
 }
 
 type runnerContext struct {
+	logger  *slog.Logger
+	console *terminal.Console
+	args    struct {
+		server bool
+		format string
+	}
+}
+
+// versionData contains the information that is displayed by the command.
+type versionData struct {
+	Client        string                `json:"client"`
+	Server        string                `json:"server,omitempty"`
+	ServerAddress string                `json:"server_address,omitempty"`
+	Compatibility version.Compatibility `json:"compatibility,omitempty"`
+	MinServer     string                `json:"min_server"`
+	MaxServer     string                `json:"max_server"`
 }
 
 func (c *runnerContext) run(cmd *cobra.Command, args []string) error {
+	var err error
+
 	// Get the context:
 	ctx := cmd.Context()
 
-	// Get the console:
-	console := terminal.ConsoleFromContext(ctx)
+	// Get the logger and the console:
+	c.logger = logging.LoggerFromContext(ctx)
+	c.console = terminal.ConsoleFromContext(ctx)
 
-	// Print the version:
-	console.Printf(ctx, "%s\n", version.Get())
+	// Check the flags:
+	switch c.args.format {
+	case outputFormatText, outputFormatJson, outputFormatYaml:
+	default:
+		return fmt.Errorf(
+			"unknown output format '%s', should be '%s', '%s' or '%s'",
+			c.args.format, outputFormatText, outputFormatJson, outputFormatYaml,
+		)
+	}
+
+	// Load the templates for the console messages:
+	err = c.console.AddTemplates(templatesFS, "templates")
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
 
+	// Collect the data:
+	data := &versionData{
+		Client:    version.Get(),
+		MinServer: version.MinServer,
+		MaxServer: version.MaxServer,
+	}
+	if c.args.server {
+		err = c.fetchServer(cmd, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Display the data. Note that when the server version hasn't been requested the text output is only the
+	// version of the client, as it was before the other details were added, so that scripts that use it don't
+	// break.
+	switch c.args.format {
+	case outputFormatJson:
+		c.console.RenderJson(ctx, data)
+	case outputFormatYaml:
+		c.console.RenderYaml(ctx, data)
+	default:
+		if c.args.server {
+			c.console.Render(ctx, "version.txt", data)
+		} else {
+			c.console.Printf(ctx, "%s\n", data.Client)
+		}
+	}
+
+	return nil
+}
+
+// fetchServer gets the version of the server of the current context, and checks if it is supported. The version is
+// taken from the response of the metadata service, and if the server doesn't send it then the version saved during
+// login is used.
+func (c *runnerContext) fetchServer(cmd *cobra.Command, data *versionData) error {
+	ctx := cmd.Context()
+
+	// Get the configuration:
+	cfg, err := config.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return fmt.Errorf("there is no configuration, run the 'login' command")
+	}
+
+	// Create the gRPC connection from the configuration:
+	conn, err := cfg.Connect(ctx, cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC connection: %w", err)
+	}
+	defer conn.Close()
+
+	// Call the metadata service and get the version from the headers or trailers of the response:
+	var header, trailer metadata.MD
+	client := metadatav1.NewMetadataClient(conn)
+	_, err = client.Get(
+		ctx,
+		metadatav1.MetadataGetRequest_builder{}.Build(),
+		grpc.Header(&header),
+		grpc.Trailer(&trailer),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get server metadata: %w", err)
+	}
+	data.Server = version.ServerFromMetadata(header, trailer)
+	if data.Server == "" {
+		data.Server = cfg.ServerVersion
+	}
+	effective, err := cfg.Override(ctx, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	data.ServerAddress = effective.Address
+	data.Compatibility = version.CheckServer(data.Server)
 	return nil
 }
@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package version
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+
+	metadatav1 "github.com/innabox/fulfillment-common/api/metadata/v1"
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/innabox/fulfillment-cli/internal/config"
+	"github.com/innabox/fulfillment-cli/internal/terminal"
+	"github.com/innabox/fulfillment-cli/internal/testing"
+	"github.com/innabox/fulfillment-cli/internal/version"
+)
+
+var _ = Describe("Version command", func() {
+	var (
+		ctx           context.Context
+		output        *bytes.Buffer
+		server        *testing.Server
+		serverVersion string
+	)
+
+	// run executes the command with the given arguments.
+	run := func(args ...string) error {
+		cmd := Cmd()
+		cmd.SetArgs(args)
+		return cmd.ExecuteContext(ctx)
+	}
+
+	// save saves a configuration that points to the test server.
+	save := func(saved string) {
+		cfg, err := config.New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = server.Address()
+		cfg.Plaintext = true
+		cfg.ServerVersion = saved
+		err = config.Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		logger := slog.New(slog.NewTextHandler(GinkgoWriter, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+		output = &bytes.Buffer{}
+		console, err := terminal.NewConsole().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		ctx = context.Background()
+		ctx = logging.LoggerIntoContext(ctx, logger)
+		ctx = terminal.ConsoleIntoContext(ctx, console)
+		ctx = config.LocationIntoContext(ctx, filepath.Join(GinkgoT().TempDir(), "config.json"))
+
+		// Replace the version of the client, and restore it after the test:
+		previous := version.Get()
+		version.Set("1.2.3")
+		DeferCleanup(func() {
+			version.Set(previous)
+		})
+
+		// Start a server that sends its version in a header:
+		serverVersion = "0.0.30"
+		server = testing.NewServer()
+		DeferCleanup(server.Stop)
+		metadatav1.RegisterMetadataServer(server.Registrar(), &testing.MetadataServerFuncs{
+			GetFunc: func(ctx context.Context,
+				request *metadatav1.MetadataGetRequest) (*metadatav1.MetadataGetResponse, error) {
+				if serverVersion != "" {
+					err := grpc.SetHeader(ctx, metadata.Pairs(version.ServerHeaderName, serverVersion))
+					Expect(err).ToNot(HaveOccurred())
+				}
+				return &metadatav1.MetadataGetResponse{}, nil
+			},
+		})
+		server.Start()
+	})
+
+	It("Prints only the client version by default", func() {
+		err := run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(Equal("1.2.3\n"))
+	})
+
+	It("Prints the client and server versions", func() {
+		save("")
+		err := run("--server")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring("Client version: 1.2.3"))
+		Expect(output.String()).To(ContainSubstring("Server address: " + server.Address()))
+		Expect(output.String()).To(ContainSubstring("Server version: 0.0.30"))
+		Expect(output.String()).ToNot(ContainSubstring("supported"))
+	})
+
+	It("Explains that the server version isn't supported", func() {
+		serverVersion = "1.0.0"
+		save("")
+		err := run("--server")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring("The version of the server is newer"))
+	})
+
+	It("Prints the versions in JSON format", func() {
+		serverVersion = "0.0.0"
+		save("")
+		err := run("--server", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		var data map[string]any
+		err = json.Unmarshal(output.Bytes(), &data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HaveKeyWithValue("client", "1.2.3"))
+		Expect(data).To(HaveKeyWithValue("server", "0.0.0"))
+		Expect(data).To(HaveKeyWithValue("compatibility", "older"))
+		Expect(data).To(HaveKeyWithValue("min_server", version.MinServer))
+		Expect(data).To(HaveKeyWithValue("max_server", version.MaxServer))
+	})
+
+	It("Uses the version saved during login if the server doesn't send it", func() {
+		serverVersion = ""
+		save("0.0.25")
+		err := run("--server", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		var data map[string]any
+		err = json.Unmarshal(output.Bytes(), &data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HaveKeyWithValue("server", "0.0.25"))
+		Expect(data).To(HaveKeyWithValue("compatibility", "compatible"))
+	})
+
+	It("Fails if there is no configuration", func() {
+		err := run("--server")
+		Expect(err).To(MatchError(ContainSubstring("run the 'login' command")))
+	})
+
+	It("Rejects unknown output formats", func() {
+		err := run("-o", "junk")
+		Expect(err).To(MatchError(ContainSubstring("unknown output format 'junk'")))
+	})
+})
@@ -15,13 +15,16 @@ package config
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -32,104 +35,453 @@ import (
 	"github.com/spf13/pflag"
 	"google.golang.org/grpc"
 
+	"github.com/innabox/fulfillment-cli/internal/files"
+	internalnetwork "github.com/innabox/fulfillment-cli/internal/network"
 	"github.com/innabox/fulfillment-cli/internal/packages"
+	"github.com/innabox/fulfillment-cli/internal/reflection"
+	"github.com/innabox/fulfillment-cli/internal/retry"
+	"github.com/innabox/fulfillment-cli/internal/serviceaccount"
+	"github.com/innabox/fulfillment-cli/internal/timeout"
+	"github.com/innabox/fulfillment-cli/internal/trace"
 	"github.com/innabox/fulfillment-cli/internal/version"
 )
 
-// Config is the type used to store the configuration of the client.
+// File is the type used to store the content of the configuration file. The file contains a set of named contexts,
+// each of them with its own connection and authentication details, and the name of the context that is used by
+// default.
+type File struct {
+	Version        int                `json:"version"`
+	CurrentContext string             `json:"current_context,omitempty"`
+	Contexts       map[string]*Config `json:"contexts,omitempty"`
+
+	location string
+
+	// These contain the original content and version of the file when it was migrated from an older version, so
+	// that a backup can be written when the migrated file is saved.
+	originalData    []byte
+	originalVersion int
+}
+
+// Config is the type used to store the configuration of one context of the client.
 type Config struct {
 	TokenScript       string     `json:"token_script,omitempty"`
 	Plaintext         bool       `json:"plaintext,omitempty"`
 	Insecure          bool       `json:"insecure,omitempty"`
 	CaFiles           []CaFile   `json:"ca_files,omitempty"`
+	ClientCert        PemFile    `json:"client_cert,omitzero"`
+	ClientKey         PemFile    `json:"client_key,omitzero" secret:"true"`
 	Address           string     `json:"address,omitempty"`
-	Private           bool       `json:"packages,omitempty"`
-	AccessToken       string     `json:"access_token,omitempty"`
-	RefreshToken      string     `json:"refresh_token,omitempty"`
+	Private           bool       `json:"private,omitempty"`
+	AccessToken       string     `json:"access_token,omitempty" secret:"true"`
+	RefreshToken      string     `json:"refresh_token,omitempty" secret:"true"`
 	TokenExpiry       time.Time  `json:"token_expiry,omitempty"`
 	OAuthFlow         oauth.Flow `json:"oauth_flow,omitempty"`
 	OauthIssuer       string     `json:"oauth_issuer,omitempty"`
 	OAuthClientId     string     `json:"oauth_client_id,omitempty"`
-	OAuthClientSecret string     `json:"oauth_client_secret,omitempty"`
+	OAuthClientSecret string     `json:"oauth_client_secret,omitempty" secret:"true"`
 	OAuthScopes       []string   `json:"oauth_scopes,omitempty"`
 	OAuthRedirectUri  string     `json:"oauth_redirect_uri,omitempty"`
 
-	caPool *x509.CertPool
+	// Settings for authentication with the token of a Kubernetes service account. When token exchange is enabled
+	// the token is exchanged using the OAuth issuer, client and scopes settings.
+	ServiceAccountTokenFile string `json:"service_account_token_file,omitempty"`
+	TokenExchange           bool   `json:"token_exchange,omitempty"`
+
+	// Settings that control how calls that fail with transient errors are retried:
+	RetryMaxAttempts int      `json:"retry_max_attempts,omitempty"`
+	RetryTimeout     Duration `json:"retry_timeout,omitempty"`
+	RetryMethods     []string `json:"retry_methods,omitempty"`
+
+	// ServerVersion is the version of the server, as reported by the server during login:
+	ServerVersion string `json:"server_version,omitempty"`
+
+	// Trace indicates if the details of the calls sent to the server should be written to the standard error:
+	Trace bool `json:"trace,omitempty"`
+
+	// Settings that control if the object types are obtained from the reflection service of the server, and for how
+	// long they are cached:
+	Discovery    bool     `json:"discovery,omitempty"`
+	DiscoveryTtl Duration `json:"discovery_ttl,omitempty"`
+
+	// Settings that control where the tokens and other secrets are stored:
+	TokenStorage        TokenStorage `json:"token_storage,omitempty"`
+	TokenStorageKeyFile string       `json:"token_storage_key_file,omitempty"`
+	TokenStorageHelper  string       `json:"token_storage_helper,omitempty"`
+
+	name              string
+	location          string
+	caPool            *x509.CertPool
+	credentialsLoaded bool
+
+	// changedCredentials contains the keys of the settings kept in the token storage that have been explicitly
+	// changed with the Set or Unset methods.
+	changedCredentials map[string]bool
 }
 
-// CaFile represents a CA certificate file with its name and optionally its content. The content is stored for relative
-// paths to allow the configuration to work when the tool is used from a different directory.
-type CaFile struct {
+// PemFile represents a PEM file, like a certificate or a key, with its name and optionally its content. The content is
+// stored for relative paths to allow the configuration to work when the tool is used from a different directory.
+type PemFile struct {
 	Name    string `json:"name,omitempty"`
 	Content string `json:"content,omitempty"`
 }
 
-// Load loads the configuration from the configuration file.
+// CaFile represents a CA certificate file.
+type CaFile = PemFile
+
+// Duration is a time duration that is stored in the configuration file using the text format of time.Duration, like
+// '30s' or '1m30s'.
+type Duration time.Duration
+
+// MarshalText is the implementation of the encoding.TextMarshaler interface.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText is the implementation of the encoding.TextUnmarshaler interface.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultContext is the name of the context that is used when the configuration file doesn't specify one and no
+// other context has been explicitly selected.
+const DefaultContext = "default"
+
+// New creates a new empty configuration for the context that has been selected in the given Go context, or for the
+// current context of the configuration file if none has been explicitly selected. If that context already exists then
+// its token storage settings are preserved, as those are a choice of the user and not something that depends on the
+// server.
+func New(ctx context.Context) (cfg *Config, err error) {
+	file, err := LoadFile(ctx)
+	if err != nil {
+		return
+	}
+	existing := file.Selected(ctx)
+	cfg = &Config{
+		TokenStorage:        existing.TokenStorage,
+		TokenStorageKeyFile: existing.TokenStorageKeyFile,
+		TokenStorageHelper:  existing.TokenStorageHelper,
+		name:                existing.name,
+		location:            existing.location,
+		credentialsLoaded:   true,
+	}
+	return
+}
+
+// Load loads the configuration of the selected context from the configuration file. The context can be explicitly
+// selected with the ContextNameIntoContext function, otherwise the current context of the file will be used. If the
+// context was explicitly selected and doesn't exist an error will be returned. If it was not explicitly selected and
+// doesn't exist an empty configuration will be returned.
 func Load(ctx context.Context) (cfg *Config, err error) {
-	// Load the file:
-	file, err := Location()
+	// Load the file, migrating it if needed:
+	file, err := LoadFile(ctx)
 	if err != nil {
 		return
 	}
-	_, err = os.Stat(file)
+
+	// Find the context. A context that was explicitly selected must exist, otherwise a mistyped or deleted context
+	// would silently result in a different configuration.
+	cfg = file.Selected(ctx)
+	if file.Contexts[cfg.name] == nil && ContextNameFromContext(ctx) != "" {
+		err = fmt.Errorf(
+			"context '%s' doesn't exist, use the 'config get-contexts' command to list the available contexts",
+			cfg.name,
+		)
+		cfg = nil
+		return
+	}
+
+	// If there is no configuration at all and we are running inside a Kubernetes pod, then use the token of the
+	// service account of the pod:
+	if len(file.Contexts) == 0 {
+		if serviceaccount.Detect(serviceaccount.DefaultTokenFile) {
+			cfg.ServiceAccountTokenFile = serviceaccount.DefaultTokenFile
+		}
+	}
+
+	// Load the credentials, if they are stored outside of the configuration file:
+	err = cfg.loadCredentials(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to load credentials: %w", err)
+		return
+	}
+
+	// Create the CA pool:
+	err = cfg.createCaPool(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to create CA pool: %w", err)
+		return
+	}
+
+	return
+}
+
+// Save saves the given configuration to its context in the configuration file. The rest of the contexts are
+// preserved. If the file doesn't have a current context yet, then the context of the configuration will become the
+// current one. The configuration is saved to the same file that it was loaded from. If the configuration uses a
+// token storage other than the configuration file, then the tokens and other secrets are saved there.
+func Save(ctx context.Context, cfg *Config) error {
+	location, err := locationOrDefault(cfg.location)
+	if err != nil {
+		return err
+	}
+	return withLock(ctx, location, func(ctx context.Context) error {
+		file, err := loadFile(location)
+		if err != nil {
+			return err
+		}
+		name := cfg.name
+		if name == "" {
+			name = file.selectContext(ctx)
+		}
+		if file.Contexts == nil {
+			file.Contexts = map[string]*Config{}
+		}
+		cfg.name = name
+		saved, err := cfg.saveCredentials(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to save credentials: %w", err)
+		}
+		file.Contexts[name] = saved
+		if file.CurrentContext == "" {
+			file.CurrentContext = name
+		}
+		return SaveFile(file)
+	})
+}
+
+// LoadFile loads the complete content of the configuration file, including all the contexts. If the file doesn't
+// exist an empty file will be returned. See the Location function for details about how the location of the file is
+// calculated.
+func LoadFile(ctx context.Context) (file *File, err error) {
+	location, err := Location(ctx)
+	if err != nil {
+		return
+	}
+	file, err = loadFile(location)
+	if err != nil {
+		return
+	}
+
+	// If the file was migrated from an older version then save it, so that the migration happens only once. Note
+	// that another process may be doing the same, so we need to hold the lock and load it again.
+	if file.originalData != nil {
+		err = withLock(ctx, location, func(ctx context.Context) error {
+			file, err = loadFile(location)
+			if err != nil || file.originalData == nil {
+				return err
+			}
+			return SaveFile(file)
+		})
+	}
+	return
+}
+
+func loadFile(location string) (file *File, err error) {
+	_, err = os.Stat(location)
 	if os.IsNotExist(err) {
-		cfg = &Config{}
+		file = &File{
+			location: location,
+		}
 		err = nil
 		return
 	}
 	if err != nil {
-		err = fmt.Errorf("failed to check if config file '%s' exists: %v", file, err)
+		err = fmt.Errorf("failed to check if config file '%s' exists: %v", location, err)
 		return
 	}
-	data, err := os.ReadFile(file)
+	data, err := os.ReadFile(location)
 	if err != nil {
-		err = fmt.Errorf("failed to read config file '%s': %v", file, err)
+		err = fmt.Errorf("failed to read config file '%s': %v", location, err)
 		return
 	}
-	cfg = &Config{}
+	file = &File{
+		location: location,
+	}
 	if len(data) == 0 {
 		return
 	}
-	err = json.Unmarshal(data, cfg)
+
+	// Files written by older versions of the tool may use an older layout, so we need to migrate them:
+	migrated, version, err := migrateSchema(location, data)
 	if err != nil {
-		err = fmt.Errorf("failed to parse config file '%s': %v", file, err)
 		return
 	}
-
-	// Create the CA pool:
-	err = cfg.createCaPool(ctx)
+	if version != currentVersion {
+		file.originalData = data
+		file.originalVersion = version
+	}
+	err = json.Unmarshal(migrated, file)
 	if err != nil {
-		err = fmt.Errorf("failed to create CA pool: %w", err)
+		err = fmt.Errorf("failed to parse config file '%s': %v", location, err)
 		return
 	}
 
+	// The names of the contexts aren't part of the serialized configuration, so we need to set them explicitly:
+	for name, cfg := range file.Contexts {
+		if cfg == nil {
+			cfg = &Config{}
+			file.Contexts[name] = cfg
+		}
+		cfg.name = name
+		cfg.location = location
+	}
+
 	return
 }
 
-// Save saves the given configuration to the configuration file.
-func Save(cfg *Config) error {
-	file, err := Location()
+// SaveFile saves the complete content of the configuration file, including all the contexts. The content is saved to
+// the same file that it was loaded from. The file is replaced atomically, so other processes will never see it
+// partially written. Note that this doesn't protect against other processes modifying the file between the load and
+// the save, the functions that modify the file take care of that.
+func SaveFile(file *File) error {
+	location, err := locationOrDefault(file.location)
 	if err != nil {
 		return err
 	}
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	file.Version = currentVersion
+	data, err := json.MarshalIndent(file, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %v", err)
 	}
-	dir := filepath.Dir(file)
-	err = os.MkdirAll(dir, os.FileMode(0755))
+	if file.originalData != nil {
+		err = writeBackup(location, file.originalVersion, file.originalData)
+		if err != nil {
+			return err
+		}
+		file.originalData = nil
+	}
+	return files.WriteAtomic(location, data, 0600)
+}
+
+// updateFile loads the configuration file, passes it to the given function to modify it and then saves it, holding
+// the lock of the file during the complete process.
+func updateFile(ctx context.Context, update func(ctx context.Context, file *File) error) error {
+	location, err := Location(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create directory %s: %v", dir, err)
+		return err
 	}
-	err = os.WriteFile(file, data, 0600)
+	return withLock(ctx, location, func(ctx context.Context) error {
+		file, err := loadFile(location)
+		if err != nil {
+			return err
+		}
+		err = update(ctx, file)
+		if err != nil {
+			return err
+		}
+		return SaveFile(file)
+	})
+}
+
+// UseContext changes the current context of the configuration file. The context must exist.
+func UseContext(ctx context.Context, name string) error {
+	return updateFile(ctx, func(ctx context.Context, file *File) error {
+		_, ok := file.Contexts[name]
+		if !ok {
+			return fmt.Errorf("context '%s' doesn't exist", name)
+		}
+		file.CurrentContext = name
+		return nil
+	})
+}
+
+// RenameContext changes the name of a context. If the context is the current one then the current context is also
+// updated.
+func RenameContext(ctx context.Context, from, to string) error {
+	return updateFile(ctx, func(ctx context.Context, file *File) error {
+		cfg, ok := file.Contexts[from]
+		if !ok {
+			return fmt.Errorf("context '%s' doesn't exist", from)
+		}
+		_, ok = file.Contexts[to]
+		if ok {
+			return fmt.Errorf("context '%s' already exists", to)
+		}
+		err := cfg.renameCredentials(ctx, to)
+		if err != nil {
+			return fmt.Errorf("failed to rename credentials: %w", err)
+		}
+		delete(file.Contexts, from)
+		file.Contexts[to] = cfg
+		cfg.name = to
+		if file.CurrentContext == from {
+			file.CurrentContext = to
+		}
+		return nil
+	})
+}
+
+// DeleteContext removes a context from the configuration file. If the context is the current one then the file will
+// be left without a current context.
+func DeleteContext(ctx context.Context, name string) error {
+	return updateFile(ctx, func(ctx context.Context, file *File) error {
+		cfg, ok := file.Contexts[name]
+		if !ok {
+			return fmt.Errorf("context '%s' doesn't exist", name)
+		}
+		err := cfg.deleteCredentials(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete credentials: %w", err)
+		}
+		delete(file.Contexts, name)
+		if file.CurrentContext == name {
+			file.CurrentContext = ""
+		}
+		return nil
+	})
+}
+
+// DeleteFile removes the configuration file, together with the credentials of all the contexts that are kept in token
+// storages and the backups created when the file was migrated from older versions, as those may contain tokens too.
+func DeleteFile(ctx context.Context) error {
+	location, err := Location(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to write file '%s': %v", file, err)
+		return err
 	}
-	return nil
+	return withLock(ctx, location, func(ctx context.Context) error {
+		file, err := loadFile(location)
+		if err != nil {
+			return err
+		}
+		for _, name := range file.Names() {
+			err = file.Contexts[name].deleteCredentials(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to delete credentials of context '%s': %w", name, err)
+			}
+		}
+		// This pattern needs to match the names generated by the backupFile function:
+		backups, err := filepath.Glob(location + ".v*.bak")
+		if err != nil {
+			return err
+		}
+		for _, name := range append(backups, location) {
+			err = os.Remove(name)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// Location returns the location of the configuration file.
-func Location() (result string, err error) {
+// Location returns the location of the configuration file. The location can be explicitly set with the
+// LocationIntoContext function, usually from the '--config' command line flag. If it isn't set that way then the
+// value of the FULFILLMENT_CLI_CONFIG environment variable will be used. If that isn't set either then the default
+// location inside the user configuration directory will be used.
+func Location(ctx context.Context) (result string, err error) {
+	result = LocationFromContext(ctx)
+	if result != "" {
+		return
+	}
+	result = os.Getenv(LocationEnvVar)
+	if result != "" {
+		return
+	}
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return
@@ -138,16 +490,107 @@ func Location() (result string, err error) {
 	return
 }
 
+// locationOrDefault returns the given location if it isn't empty, or else the location calculated by the Location
+// function.
+func locationOrDefault(location string) (string, error) {
+	if location != "" {
+		return location, nil
+	}
+	return Location(context.Background())
+}
+
+// selectContext returns the name of the context that should be used: the one explicitly selected in the Go context
+// if any, otherwise the current context of the file, and finally the default context.
+func (f *File) selectContext(ctx context.Context) string {
+	name := ContextNameFromContext(ctx)
+	if name != "" {
+		return name
+	}
+	if f.CurrentContext != "" {
+		return f.CurrentContext
+	}
+	return DefaultContext
+}
+
+// Selected returns the configuration of the context that has been explicitly selected in the Go context, or of the
+// current context if none has been selected. If that context doesn't exist an empty configuration is returned.
+func (f *File) Selected(ctx context.Context) *Config {
+	name := f.selectContext(ctx)
+	cfg := f.Contexts[name]
+	if cfg == nil {
+		cfg = &Config{
+			name:     name,
+			location: f.location,
+		}
+	}
+	return cfg
+}
+
+// Names returns the names of the contexts sorted alphabetically.
+func (f *File) Names() []string {
+	result := make([]string, 0, len(f.Contexts))
+	for name := range f.Contexts {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Blank returns a configuration without any settings that belongs to the same context and configuration file as this
+// one. Note that saving it doesn't remove the tokens kept in a token storage other than the configuration file, unless
+// they had been loaded.
+func (c *Config) Blank() *Config {
+	return &Config{
+		name:     c.name,
+		location: c.location,
+	}
+}
+
+// Name returns the name of the context that this configuration belongs to.
+func (c *Config) Name() string {
+	return c.name
+}
+
 // TokenSource creates a token source from the configuration.
 func (c *Config) TokenSource(ctx context.Context) (result auth.TokenSource, err error) {
 	// Get the logger:
 	logger := logging.LoggerFromContext(ctx)
 
 	// Get the token store:
-	tokenStore := c.TokenStore()
+	tokenStore := c.tokenStore()
+
+	// If a service account token file has been configured, then use it, exchanging the token if needed:
+	if c.ServiceAccountTokenFile != "" {
+		var httpClient *http.Client
+		httpClient, err = c.HttpClient()
+		if err != nil {
+			return
+		}
+		builder := serviceaccount.NewTokenSource().
+			SetLogger(logger).
+			SetFile(c.ServiceAccountTokenFile).
+			SetHttpClient(httpClient)
+		if c.TokenExchange {
+			builder.SetIssuer(c.OauthIssuer).
+				SetClientId(c.OAuthClientId).
+				SetClientSecret(c.OAuthClientSecret).
+				SetScopes(c.OAuthScopes...)
+		}
+		result, err = builder.Build()
+		if err != nil {
+			err = fmt.Errorf("failed to create service account token source: %w", err)
+		}
+		return
+	}
 
 	// If an OAuth flow has been configured, then use it to create a non interactive OAuth token source:
 	if c.OAuthFlow != "" {
+		var certificates []tls.Certificate
+		certificates, err = c.ClientCertificates()
+		if err != nil {
+			return
+		}
+		httpClient := &http.Client{}
 		result, err = oauth.NewTokenSource().
 			SetLogger(logger).
 			SetFlow(c.OAuthFlow).
@@ -159,10 +602,21 @@ func (c *Config) TokenSource(ctx context.Context) (result auth.TokenSource, err
 			SetRedirectUri(c.OAuthRedirectUri).
 			SetInsecure(c.Insecure).
 			SetCaPool(c.caPool).
+			SetHttpClient(httpClient).
 			SetStore(tokenStore).
 			Build()
 		if err != nil {
 			err = fmt.Errorf("failed to create OAuth token source: %w", err)
+			return
+		}
+
+		// The builder replaces the transport of the HTTP client with one that doesn't present client
+		// certificates, so we need to put ours back after building the token source.
+		httpClient.Transport = internalnetwork.NewHttpTransport(c.caPool, c.Insecure, certificates)
+
+		result = &lockingTokenSource{
+			source: result,
+			store:  tokenStore,
 		}
 		return
 	}
@@ -176,6 +630,11 @@ func (c *Config) TokenSource(ctx context.Context) (result auth.TokenSource, err
 			Build()
 		if err != nil {
 			err = fmt.Errorf("failed to create script token source: %w", err)
+			return
+		}
+		result = &lockingTokenSource{
+			source: result,
+			store:  tokenStore,
 		}
 		return
 	}
@@ -199,39 +658,104 @@ func (c *Config) TokenSource(ctx context.Context) (result auth.TokenSource, err
 	return
 }
 
-// Conect creates a gRPC connection from the configuration.
+// Conect creates a gRPC connection from the configuration. The connection settings can be overridden with the command
+// line flags added by the AddFlags function and with the corresponding environment variables. Those overrides only
+// apply to this connection, they aren't saved to the configuration file.
 func (c *Config) Connect(ctx context.Context, flags *pflag.FlagSet) (result *grpc.ClientConn, err error) {
 	// Get the logger:
 	logger := logging.LoggerFromContext(ctx)
 
+	// Apply the overrides:
+	effective, err := c.Override(ctx, flags)
+	if err != nil {
+		return
+	}
+	if effective.Address == "" {
+		err = errors.New("there is no configuration, run the 'login' command")
+		return
+	}
+
 	// Try to create a token source. This will be nil if no token source can be created, which means that the
 	// connection will be anonymous.
-	tokenSource, err := c.TokenSource(ctx)
+	tokenSource, err := effective.TokenSource(ctx)
 	if err != nil {
 		err = fmt.Errorf("failed to create token source: %w", err)
 		return
 	}
 
+	// Load the client certificates:
+	certificates, err := effective.ClientCertificates()
+	if err != nil {
+		return
+	}
+
 	// Create the version interceptor:
 	versionInterceptor, err := version.NewInterceptor().
 		SetLogger(logger).
+		SetCacheDir(CacheDirFromContext(ctx)).
+		SetWriter(os.Stderr).
 		Build()
 	if err != nil {
 		err = fmt.Errorf("failed to create version interceptor: %w", err)
 		return
 	}
 
-	// Create the gRPC client:
-	result, err = network.NewGrpcClient().
+	// Create the retry interceptor:
+	retryInterceptor, err := retry.NewInterceptor().
 		SetLogger(logger).
-		SetPlaintext(c.Plaintext).
-		SetInsecure(c.Insecure).
-		SetCaPool(c.caPool).
+		SetMaxAttempts(effective.RetryMaxAttempts).
+		SetAttemptTimeout(time.Duration(effective.RetryTimeout)).
+		AddMethods(effective.RetryMethods...).
+		Build()
+	if err != nil {
+		err = fmt.Errorf("failed to create retry interceptor: %w", err)
+		return
+	}
+
+	// Create the trace interceptor, only if tracing has been enabled:
+	var traceInterceptor *trace.Interceptor
+	if effective.Trace {
+		traceInterceptor, err = trace.NewInterceptor().
+			SetLogger(logger).
+			SetWriter(os.Stderr).
+			Build()
+		if err != nil {
+			err = fmt.Errorf("failed to create trace interceptor: %w", err)
+			return
+		}
+	}
+
+	// Create the timeout interceptor:
+	timeoutInterceptor, err := timeout.NewInterceptor().
+		SetLogger(logger).
+		Build()
+	if err != nil {
+		err = fmt.Errorf("failed to create timeout interceptor: %w", err)
+		return
+	}
+
+	// Create the gRPC client. Note that the timeout interceptor goes first so that it sees the errors after all the
+	// retries, and the trace interceptor goes last so that it sees each attempt and the headers added by the other
+	// interceptors.
+	builder := internalnetwork.NewGrpcClient().
+		SetLogger(logger).
+		SetPlaintext(effective.Plaintext).
+		SetInsecure(effective.Insecure).
+		SetCaPool(effective.caPool).
+		AddClientCertificates(certificates...).
 		SetTokenSource(tokenSource).
-		SetAddress(c.Address).
+		SetAddress(effective.Address).
+		AddUnaryInterceptor(timeoutInterceptor.UnaryClient).
+		AddUnaryInterceptor(retryInterceptor.UnaryClient).
 		AddUnaryInterceptor(versionInterceptor.UnaryClient).
-		AddStreamInterceptor(versionInterceptor.StreamClient).
-		Build()
+		AddStreamInterceptor(timeoutInterceptor.StreamClient).
+		AddStreamInterceptor(retryInterceptor.StreamClient).
+		AddStreamInterceptor(versionInterceptor.StreamClient)
+	if traceInterceptor != nil {
+		builder.AddUnaryInterceptor(traceInterceptor.UnaryClient)
+		builder.AddStreamInterceptor(traceInterceptor.StreamClient)
+	}
+	result, err = builder.Build()
 	if err != nil {
 		err = fmt.Errorf("failed to create gRPC client: %w", err)
 		return
@@ -240,6 +764,43 @@ func (c *Config) Connect(ctx context.Context, flags *pflag.FlagSet) (result *grp
 	return
 }
 
+// Helper creates the reflection helper for the given connection. If discovery is enabled, either in the configuration
+// or with the command line flags added by the AddFlags function, then the helper will also know the object types
+// obtained from the reflection service of the server.
+func (c *Config) Helper(ctx context.Context, conn *grpc.ClientConn, flags *pflag.FlagSet) (result *reflection.Helper,
+	err error) {
+	// Apply the overrides:
+	effective, err := c.Override(ctx, flags)
+	if err != nil {
+		return
+	}
+
+	// Create the helper:
+	helper, err := reflection.NewHelper().
+		SetLogger(logging.LoggerFromContext(ctx)).
+		SetConnection(conn).
+		AddPackages(effective.Packages()).
+		SetAddress(effective.Address).
+		SetCacheDir(CacheDirFromContext(ctx)).
+		SetCacheTtl(time.Duration(effective.DiscoveryTtl)).
+		Build()
+	if err != nil {
+		err = fmt.Errorf("failed to create reflection tool: %w", err)
+		return
+	}
+
+	// Get the descriptors from the server, if enabled:
+	if effective.Discovery {
+		err = helper.Discover(ctx)
+		if err != nil {
+			return
+		}
+	}
+
+	result = helper
+	return
+}
+
 // Packages returns the list of packages that should be enabled according to the configuration. The public packages
 // will always be enabled, but the private packages will be enabled only if the `private` flag is true.
 //
@@ -263,6 +824,10 @@ func (c *Config) Packages() map[string]int {
 // TokenStore returns an implementation of the auth.TokenStore interface that loads and saves tokens from/to
 // the configuration.
 func (c *Config) TokenStore() auth.TokenStore {
+	return c.tokenStore()
+}
+
+func (c *Config) tokenStore() *configTokenStore {
 	return &configTokenStore{
 		config: c,
 		lock:   &sync.RWMutex{},
@@ -333,6 +898,72 @@ func (c *Config) createCaPool(ctx context.Context) error {
 	return err
 }
 
+// HttpClient creates an HTTP client that uses the TLS settings of the configuration, including the client certificates.
+// This is intended for talking to the OAuth server.
+func (c *Config) HttpClient() (result *http.Client, err error) {
+	certificates, err := c.ClientCertificates()
+	if err != nil {
+		return
+	}
+	result = &http.Client{
+		Timeout:   httpTimeout,
+		Transport: internalnetwork.NewHttpTransport(c.caPool, c.Insecure, certificates),
+	}
+	return
+}
+
+// httpTimeout is the maximum time that the HTTP clients created by the configuration wait for a response.
+const httpTimeout = 30 * time.Second
+
+// ClientCertificates loads the client certificate and key from the configuration. The result will be empty if no client
+// certificate has been configured.
+func (c *Config) ClientCertificates() (result []tls.Certificate, err error) {
+	return LoadClientCertificates(c.ClientCert, c.ClientKey)
+}
+
+// LoadClientCertificates loads the given client certificate and key. The result will be empty if neither of them has
+// been specified. If the key is encrypted the passphrase is taken from the environment variable indicated by the
+// network.ClientKeyPassphraseEnvVar constant.
+func LoadClientCertificates(cert, key PemFile) (result []tls.Certificate, err error) {
+	if cert.Name == "" && key.Name == "" {
+		return
+	}
+	if cert.Name == "" {
+		err = errors.New("client key has been specified but client certificate hasn't")
+		return
+	}
+	if key.Name == "" {
+		err = errors.New("client certificate has been specified but client key hasn't")
+		return
+	}
+	certPEM, err := cert.read()
+	if err != nil {
+		err = fmt.Errorf("failed to read client certificate file: %w", err)
+		return
+	}
+	keyPEM, err := key.read()
+	if err != nil {
+		err = fmt.Errorf("failed to read client key file: %w", err)
+		return
+	}
+	passphrase := os.Getenv(internalnetwork.ClientKeyPassphraseEnvVar)
+	certificate, err := internalnetwork.LoadClientCertificate(certPEM, keyPEM, []byte(passphrase))
+	if err != nil {
+		return
+	}
+	result = []tls.Certificate{certificate}
+	return
+}
+
+// read returns the saved content of the file, or reads it from the filesystem if the content wasn't saved.
+func (f PemFile) read() (result []byte, err error) {
+	if f.Content != "" {
+		result = []byte(f.Content)
+		return
+	}
+	return os.ReadFile(f.Name)
+}
+
 type configTokenStore struct {
 	config *Config
 	lock   *sync.RWMutex
@@ -367,5 +998,84 @@ func (s *configTokenStore) Save(ctx context.Context, token *auth.Token) error {
 	s.config.AccessToken = token.Access
 	s.config.RefreshToken = token.Refresh
 	s.config.TokenExpiry = token.Expiry
-	return Save(s.config)
+	return saveTokens(ctx, s.config)
+}
+
+// fresh checks if the access token can be used without renewing it.
+func (s *configTokenStore) fresh() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.config.AccessToken == "" {
+		return false
+	}
+	expiry := s.config.TokenExpiry
+	return expiry.IsZero() || time.Until(expiry) > tokenFreshness
+}
+
+// reload loads again the tokens from the configuration file, or from the token storage, as they may have been
+// renewed by another process. This should be called while holding the lock of the configuration file.
+func (s *configTokenStore) reload(ctx context.Context, location string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	file, err := loadFile(location)
+	if err != nil {
+		return err
+	}
+	saved := file.Contexts[s.config.name]
+	if saved == nil {
+		return nil
+	}
+	store, err := s.config.credentialsStore(ctx)
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		stored, err := store.Load(ctx, s.config.name)
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			saved.setCredentials(stored)
+		}
+	}
+	s.config.AccessToken = saved.AccessToken
+	s.config.RefreshToken = saved.RefreshToken
+	s.config.TokenExpiry = saved.TokenExpiry
+	return nil
+}
+
+// saveTokens saves the token fields of the given configuration to the corresponding context of the configuration
+// file, preserving the rest of the settings that are in the file. This is needed because the configuration may
+// contain settings that have been overridden with command line flags or environment variables, and those shouldn't
+// be saved. The lock of the configuration file is held while doing this, so that tokens saved simultaneously by
+// other processes aren't lost.
+func saveTokens(ctx context.Context, cfg *Config) error {
+	location, err := locationOrDefault(cfg.location)
+	if err != nil {
+		return err
+	}
+	return withLock(ctx, location, func(ctx context.Context) error {
+		// If the tokens are stored outside of the configuration file then there is no need to touch the file:
+		store, err := cfg.credentialsStore(ctx)
+		if err != nil {
+			return err
+		}
+		if store != nil {
+			return store.Save(ctx, cfg.name, cfg.credentials())
+		}
+
+		// Update only the tokens in the file:
+		file, err := loadFile(location)
+		if err != nil {
+			return err
+		}
+		saved := file.Contexts[cfg.name]
+		if saved == nil {
+			return Save(ctx, cfg)
+		}
+		saved.AccessToken = cfg.AccessToken
+		saved.RefreshToken = cfg.RefreshToken
+		saved.TokenExpiry = cfg.TokenExpiry
+		return SaveFile(file)
+	})
 }
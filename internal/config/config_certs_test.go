@@ -0,0 +1,212 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/innabox/fulfillment-common/logging"
+	"github.com/innabox/fulfillment-common/oauth"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+
+	. "github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Client certificates", func() {
+	var (
+		ctx   context.Context
+		dir   string
+		certs *Certificates
+	)
+
+	BeforeEach(func() {
+		// Create the context:
+		ctx = logging.LoggerIntoContext(context.Background(), logger)
+
+		// Use a temporary directory as the user configuration directory:
+		home := GinkgoT().TempDir()
+		GinkgoT().Setenv("XDG_CONFIG_HOME", home)
+		GinkgoT().Setenv("HOME", home)
+		GinkgoT().Setenv(LocationEnvVar, "")
+
+		// Create the certificates:
+		dir = GinkgoT().TempDir()
+		certs = MakeCertificates(dir)
+	})
+
+	Describe("Connect", func() {
+		var server *Server
+
+		BeforeEach(func() {
+			server = NewTLSServer(certs.ServerConfig())
+			DeferCleanup(server.Stop)
+			healthv1.RegisterHealthServer(server.Registrar(), health.NewServer())
+			server.Start()
+		})
+
+		// check connects to the server using the given configuration and calls the health check method.
+		check := func(cfg *Config) error {
+			conn, err := cfg.Connect(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			client := healthv1.NewHealthClient(conn)
+			_, err = client.Check(ctx, &healthv1.HealthCheckRequest{})
+			return err
+		}
+
+		It("Presents the client certificate saved with relative names", func() {
+			// Create the configuration from a different directory, as the login command would do:
+			GinkgoT().Chdir(dir)
+			cfg, err := New(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			cfg.Address = server.Address()
+			caFile, err := NewCaFile(filepath.Base(certs.CaFile))
+			Expect(err).ToNot(HaveOccurred())
+			cfg.CaFiles = []CaFile{caFile}
+			cfg.ClientCert, err = NewClientCertFile(filepath.Base(certs.ClientCertFile))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.ClientCert.Content).ToNot(BeEmpty())
+			cfg.ClientKey, err = NewClientKeyFile(filepath.Base(certs.ClientKeyFile))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.ClientKey.Name).To(Equal(certs.ClientKeyFile))
+			Expect(cfg.ClientKey.Content).To(BeEmpty())
+			err = Save(ctx, cfg)
+			Expect(err).ToNot(HaveOccurred())
+
+			// The private key should not be in the configuration file:
+			location, err := Location(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := os.ReadFile(location)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).ToNot(ContainSubstring("PRIVATE KEY"))
+
+			// Load it from another directory and connect:
+			GinkgoT().Chdir(GinkgoT().TempDir())
+			cfg, err = Load(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			err = check(cfg)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Presents the client certificate saved with absolute names", func() {
+			cfg := &Config{
+				Address: server.Address(),
+				CaFiles: []CaFile{{
+					Name: certs.CaFile,
+				}},
+				ClientCert: PemFile{
+					Name: certs.ClientCertFile,
+				},
+				ClientKey: PemFile{
+					Name: certs.ClientKeyFile,
+				},
+			}
+			err := cfg.createCaPool(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			err = check(cfg)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Fails if there is no client certificate", func() {
+			cfg := &Config{
+				Address: server.Address(),
+				CaFiles: []CaFile{{
+					Name: certs.CaFile,
+				}},
+			}
+			err := cfg.createCaPool(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			err = check(cfg)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Fails if there is a client certificate but no key", func() {
+			cfg := &Config{
+				Address: server.Address(),
+				ClientCert: PemFile{
+					Name: certs.ClientCertFile,
+				},
+			}
+			_, err := cfg.Connect(ctx, nil)
+			Expect(err).To(MatchError("client certificate has been specified but client key hasn't"))
+		})
+	})
+
+	It("Presents the client certificate to the OAuth token endpoint", func() {
+		// Create an OAuth server that accepts token requests only from clients that present a certificate:
+		var issuer string
+		mux := http.NewServeMux()
+		mux.HandleFunc("/.well-known/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{
+				"issuer":         issuer,
+				"token_endpoint": issuer + "/token",
+			})
+		})
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) == 0 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "my_access",
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			})
+		})
+		oauthServer := httptest.NewUnstartedServer(mux)
+		oauthServer.TLS = certs.ServerConfig()
+		oauthServer.TLS.ClientAuth = tls.VerifyClientCertIfGiven
+		oauthServer.StartTLS()
+		DeferCleanup(oauthServer.Close)
+		issuer = strings.Replace(oauthServer.URL, "127.0.0.1", "localhost", 1)
+
+		// Get a token:
+		cfg := &Config{
+			CaFiles: []CaFile{{
+				Name: certs.CaFile,
+			}},
+			ClientCert: PemFile{
+				Name: certs.ClientCertFile,
+			},
+			ClientKey: PemFile{
+				Name: certs.ClientKeyFile,
+			},
+			OAuthFlow:         oauth.CredentialsFlow,
+			OauthIssuer:       issuer,
+			OAuthClientId:     "my_client",
+			OAuthClientSecret: "my_secret",
+		}
+		err := cfg.createCaPool(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		source, err := cfg.TokenSource(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		token, err := source.Token(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Access).To(Equal("my_access"))
+	})
+})
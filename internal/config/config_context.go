@@ -0,0 +1,67 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+)
+
+// contextKey is the type used to store configuration details in the context.
+type contextKey int
+
+const (
+	contextNameKey contextKey = iota
+	locationKey
+	lockKey
+	cacheDirKey
+)
+
+// ContextNameIntoContext creates a new context that contains the name of the configuration context that should be
+// used by the Load function. This is intended for the global '--context' command line flag.
+func ContextNameIntoContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextNameKey, name)
+}
+
+// ContextNameFromContext returns the name of the configuration context that has been explicitly selected, or an empty
+// string if no context has been selected.
+func ContextNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(contextNameKey).(string)
+	return name
+}
+
+// LocationIntoContext creates a new context that contains the location of the configuration file that should be used
+// instead of the default one. This is intended for the global '--config' command line flag.
+func LocationIntoContext(ctx context.Context, location string) context.Context {
+	return context.WithValue(ctx, locationKey, location)
+}
+
+// LocationFromContext returns the location of the configuration file that has been explicitly selected, or an empty
+// string if no location has been selected.
+func LocationFromContext(ctx context.Context) string {
+	location, _ := ctx.Value(locationKey).(string)
+	return location
+}
+
+// CacheDirIntoContext creates a new context that contains the directory where the tool can save files that aren't
+// part of the configuration, like the time of the last warning about an unsupported server version.
+func CacheDirIntoContext(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, cacheDirKey, dir)
+}
+
+// CacheDirFromContext returns the cache directory stored in the context, or an empty string if there is no cache
+// directory.
+func CacheDirFromContext(ctx context.Context) string {
+	dir, _ := ctx.Value(cacheDirKey).(string)
+	return dir
+}
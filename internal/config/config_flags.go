@@ -0,0 +1,336 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/innabox/fulfillment-common/logging"
+	"github.com/spf13/pflag"
+
+	"github.com/innabox/fulfillment-cli/internal/network"
+	"github.com/innabox/fulfillment-cli/internal/retry"
+)
+
+// AddFlags adds to the given flag set the flags that can be used to override the connection settings of the
+// configuration for a single invocation of the tool.
+func AddFlags(set *pflag.FlagSet) {
+	_ = set.String(
+		addressFlagName,
+		"",
+		"Server address. Overrides the address of the configuration. Can also be set with the "+
+			"'"+addressEnvVar+"' environment variable.",
+	)
+	_ = set.String(
+		tokenFlagName,
+		"",
+		"Access token. Overrides the authentication settings of the configuration. Can also be set with "+
+			"the '"+tokenEnvVar+"' environment variable.",
+	)
+	_ = set.Bool(
+		insecureFlagName,
+		false,
+		"Disables verification of TLS certificates and host names. Can also be set with the "+
+			"'"+insecureEnvVar+"' environment variable.",
+	)
+	_ = set.Bool(
+		plaintextFlagName,
+		false,
+		"Disables use of TLS. Can also be set with the '"+plaintextEnvVar+"' environment variable.",
+	)
+	_ = set.StringArray(
+		caFileFlagName,
+		[]string{},
+		"File or directory containing trusted CA certificates, in addition to the ones in the configuration. "+
+			"Can also be set with the '"+caFileEnvVar+"' environment variable, using the path list "+
+			"separator of the operating system.",
+	)
+	_ = set.Int(
+		retryMaxAttemptsFlagName,
+		0,
+		fmt.Sprintf(
+			"Maximum number of attempts for calls that fail with transient errors, including the first "+
+				"one. Use 1 to disable retries. Only calls that are safe to repeat are retried. The "+
+				"default is %d. Can also be set with the '%s' environment variable.",
+			retry.DefaultMaxAttempts, retryMaxAttemptsEnvVar,
+		),
+	)
+	_ = set.Duration(
+		retryTimeoutFlagName,
+		0,
+		"Maximum time that each attempt of a call can take before it is retried. The default is to "+
+			"not limit it. Can also be set with the '"+retryTimeoutEnvVar+"' environment variable.",
+	)
+	_ = set.Bool(
+		traceFlagName,
+		false,
+		"Writes to the standard error the details of the calls sent to the server: method, request, "+
+			"response, status, trailers and duration. Sensitive values like passwords, kubeconfigs, "+
+			"cookies and authorization headers are redacted. Can also be set with the '"+traceEnvVar+"' "+
+			"environment variable.",
+	)
+	_ = set.Bool(
+		discoveryFlagName,
+		false,
+		"Obtains the object types from the reflection service of the server, so that types that aren't "+
+			"known by this version of the tool can also be used. Can also be set with the "+
+			"'"+discoveryEnvVar+"' environment variable.",
+	)
+}
+
+// Override returns a copy of the configuration with the connection settings overridden by the command line flags
+// added by the AddFlags function and by the corresponding environment variables. Flags take precedence over
+// environment variables. The original configuration isn't modified, and the copy should never be saved, so that the
+// overrides only apply to the current invocation of the tool.
+func (c *Config) Override(ctx context.Context, flags *pflag.FlagSet) (result *Config, err error) {
+	result = &Config{}
+	*result = *c
+
+	// Get the values:
+	address, addressSet, err := overrideString(flags, addressFlagName, addressEnvVar)
+	if err != nil {
+		return
+	}
+	token, tokenSet, err := overrideString(flags, tokenFlagName, tokenEnvVar)
+	if err != nil {
+		return
+	}
+	insecure, insecureSet, err := overrideBool(flags, insecureFlagName, insecureEnvVar)
+	if err != nil {
+		return
+	}
+	plaintext, plaintextSet, err := overrideBool(flags, plaintextFlagName, plaintextEnvVar)
+	if err != nil {
+		return
+	}
+	caFiles, err := overrideList(flags, caFileFlagName, caFileEnvVar)
+	if err != nil {
+		return
+	}
+	retryMaxAttempts, retryMaxAttemptsSet, err := overrideInt(
+		flags, retryMaxAttemptsFlagName, retryMaxAttemptsEnvVar,
+	)
+	if err != nil {
+		return
+	}
+	retryTimeout, retryTimeoutSet, err := overrideDuration(flags, retryTimeoutFlagName, retryTimeoutEnvVar)
+	if err != nil {
+		return
+	}
+	trace, traceSet, err := overrideBool(flags, traceFlagName, traceEnvVar)
+	if err != nil {
+		return
+	}
+	discovery, discoverySet, err := overrideBool(flags, discoveryFlagName, discoveryEnvVar)
+	if err != nil {
+		return
+	}
+
+	// If the address has been overridden then we need to parse it, as it may contain a scheme that indicates if
+	// TLS should be used. That is the same thing that the login command does. But an explicit plaintext setting
+	// takes precedence.
+	if addressSet {
+		var parser *network.AddressParser
+		parser, err = network.NewAddressParser().
+			SetLogger(logging.LoggerFromContext(ctx)).
+			Build()
+		if err != nil {
+			err = fmt.Errorf("failed to create address parser: %w", err)
+			return
+		}
+		result.Address, result.Plaintext, err = parser.Parse(address)
+		if err != nil {
+			err = fmt.Errorf("failed to parse address '%s': %w", address, err)
+			return
+		}
+	}
+	if plaintextSet {
+		result.Plaintext = plaintext
+	}
+	if insecureSet {
+		result.Insecure = insecure
+	}
+	if retryMaxAttemptsSet {
+		result.RetryMaxAttempts = retryMaxAttempts
+	}
+	if retryTimeoutSet {
+		result.RetryTimeout = Duration(retryTimeout)
+	}
+	if traceSet {
+		result.Trace = trace
+	}
+	if discoverySet {
+		result.Discovery = discovery
+	}
+
+	// An explicit token replaces any other authentication mechanism:
+	if tokenSet {
+		result.OAuthFlow = ""
+		result.TokenScript = ""
+		result.ServiceAccountTokenFile = ""
+		result.AccessToken = token
+		result.RefreshToken = ""
+		result.TokenExpiry = time.Time{}
+	}
+
+	// Additional CA files are added to the ones in the configuration, and then the CA pool needs to be created
+	// again:
+	if len(caFiles) > 0 {
+		result.CaFiles = slices.Clone(c.CaFiles)
+		for _, caFile := range caFiles {
+			var absFile string
+			absFile, err = filepath.Abs(caFile)
+			if err != nil {
+				err = fmt.Errorf("failed to get absolute path of CA file '%s': %w", caFile, err)
+				return
+			}
+			result.CaFiles = append(result.CaFiles, CaFile{
+				Name: absFile,
+			})
+		}
+		err = result.createCaPool(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed to create CA pool: %w", err)
+			return
+		}
+	}
+
+	return
+}
+
+func overrideString(flags *pflag.FlagSet, flagName, envVar string) (result string, ok bool, err error) {
+	if flags != nil && flags.Lookup(flagName) != nil && flags.Changed(flagName) {
+		result, err = flags.GetString(flagName)
+		ok = err == nil
+		return
+	}
+	result, ok = os.LookupEnv(envVar)
+	ok = ok && result != ""
+	return
+}
+
+func overrideBool(flags *pflag.FlagSet, flagName, envVar string) (result bool, ok bool, err error) {
+	if flags != nil && flags.Lookup(flagName) != nil && flags.Changed(flagName) {
+		result, err = flags.GetBool(flagName)
+		ok = err == nil
+		return
+	}
+	text, ok := os.LookupEnv(envVar)
+	if !ok || text == "" {
+		ok = false
+		return
+	}
+	result, err = strconv.ParseBool(text)
+	if err != nil {
+		err = fmt.Errorf(
+			"value '%s' of environment variable '%s' isn't a valid boolean: %w",
+			text, envVar, err,
+		)
+		ok = false
+	}
+	return
+}
+
+func overrideInt(flags *pflag.FlagSet, flagName, envVar string) (result int, ok bool, err error) {
+	if flags != nil && flags.Lookup(flagName) != nil && flags.Changed(flagName) {
+		result, err = flags.GetInt(flagName)
+		ok = err == nil
+		return
+	}
+	text, ok := os.LookupEnv(envVar)
+	if !ok || text == "" {
+		ok = false
+		return
+	}
+	result, err = strconv.Atoi(text)
+	if err != nil {
+		err = fmt.Errorf(
+			"value '%s' of environment variable '%s' isn't a valid integer: %w",
+			text, envVar, err,
+		)
+		ok = false
+	}
+	return
+}
+
+func overrideDuration(flags *pflag.FlagSet, flagName, envVar string) (result time.Duration, ok bool, err error) {
+	if flags != nil && flags.Lookup(flagName) != nil && flags.Changed(flagName) {
+		result, err = flags.GetDuration(flagName)
+		ok = err == nil
+		return
+	}
+	text, ok := os.LookupEnv(envVar)
+	if !ok || text == "" {
+		ok = false
+		return
+	}
+	result, err = time.ParseDuration(text)
+	if err != nil {
+		err = fmt.Errorf(
+			"value '%s' of environment variable '%s' isn't a valid duration: %w",
+			text, envVar, err,
+		)
+		ok = false
+	}
+	return
+}
+
+func overrideList(flags *pflag.FlagSet, flagName, envVar string) (result []string, err error) {
+	if flags != nil && flags.Lookup(flagName) != nil && flags.Changed(flagName) {
+		result, err = flags.GetStringArray(flagName)
+		return
+	}
+	text := os.Getenv(envVar)
+	if text != "" {
+		result = filepath.SplitList(text)
+	}
+	return
+}
+
+// Names of the flags:
+const (
+	addressFlagName   = "address"
+	tokenFlagName     = "token"
+	insecureFlagName  = "insecure"
+	plaintextFlagName = "plaintext"
+	caFileFlagName    = "ca-file"
+
+	retryMaxAttemptsFlagName = "retry-max-attempts"
+	retryTimeoutFlagName     = "retry-timeout"
+
+	traceFlagName     = "trace"
+	discoveryFlagName = "discovery"
+)
+
+// Names of the environment variables:
+const (
+	LocationEnvVar  = "FULFILLMENT_CLI_CONFIG"
+	addressEnvVar   = "FULFILLMENT_SERVICE_ADDRESS"
+	tokenEnvVar     = "FULFILLMENT_SERVICE_TOKEN"
+	insecureEnvVar  = "FULFILLMENT_SERVICE_INSECURE"
+	plaintextEnvVar = "FULFILLMENT_SERVICE_PLAINTEXT"
+	caFileEnvVar    = "FULFILLMENT_SERVICE_CA_FILE"
+
+	retryMaxAttemptsEnvVar = "FULFILLMENT_SERVICE_RETRY_MAX_ATTEMPTS"
+	retryTimeoutEnvVar     = "FULFILLMENT_SERVICE_RETRY_TIMEOUT"
+
+	traceEnvVar     = "FULFILLMENT_SERVICE_TRACE"
+	discoveryEnvVar = "FULFILLMENT_SERVICE_DISCOVERY"
+)
@@ -0,0 +1,217 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+
+	. "github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Overrides", func() {
+	var (
+		ctx   context.Context
+		flags *pflag.FlagSet
+		cfg   *Config
+	)
+
+	BeforeEach(func() {
+		ctx = logging.LoggerIntoContext(context.Background(), logger)
+
+		// Make sure that the environment doesn't interfere with the tests:
+		for _, name := range []string{
+			addressEnvVar,
+			tokenEnvVar,
+			insecureEnvVar,
+			plaintextEnvVar,
+			caFileEnvVar,
+			retryMaxAttemptsEnvVar,
+			retryTimeoutEnvVar,
+		} {
+			GinkgoT().Setenv(name, "")
+		}
+
+		// Create the flags:
+		flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+		AddFlags(flags)
+
+		// Create the configuration:
+		cfg = &Config{
+			Address:      "saved.example.com:443",
+			OAuthFlow:    "device",
+			RefreshToken: "saved-refresh",
+		}
+	})
+
+	It("Returns an equivalent copy if nothing is overridden", func() {
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).ToNot(BeIdenticalTo(cfg))
+		Expect(*result).To(Equal(*cfg))
+	})
+
+	It("Accepts a nil flag set", func() {
+		GinkgoT().Setenv(addressEnvVar, "env.example.com:8000")
+		result, err := cfg.Override(ctx, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Address).To(Equal("env.example.com:8000"))
+	})
+
+	It("Overrides the address from the environment", func() {
+		GinkgoT().Setenv(addressEnvVar, "env.example.com:8000")
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Address).To(Equal("env.example.com:8000"))
+		Expect(cfg.Address).To(Equal("saved.example.com:443"))
+	})
+
+	It("Gives precedence to flags over the environment", func() {
+		GinkgoT().Setenv(addressEnvVar, "env.example.com:8000")
+		err := flags.Parse([]string{"--address", "flag.example.com:9000"})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Address).To(Equal("flag.example.com:9000"))
+	})
+
+	It("Derives plaintext from the scheme of the address", func() {
+		err := flags.Parse([]string{"--address", "http://flag.example.com:9000"})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Plaintext).To(BeTrue())
+	})
+
+	It("Gives precedence to explicit plaintext over the scheme of the address", func() {
+		err := flags.Parse([]string{"--address", "http://flag.example.com:9000", "--plaintext=false"})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Plaintext).To(BeFalse())
+	})
+
+	It("Overrides insecure from the environment", func() {
+		GinkgoT().Setenv(insecureEnvVar, "true")
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Insecure).To(BeTrue())
+		Expect(cfg.Insecure).To(BeFalse())
+	})
+
+	It("Rejects invalid boolean in the environment", func() {
+		GinkgoT().Setenv(plaintextEnvVar, "junk")
+		_, err := cfg.Override(ctx, flags)
+		Expect(err).To(MatchError(ContainSubstring(plaintextEnvVar)))
+	})
+
+	It("Overrides the retry settings", func() {
+		GinkgoT().Setenv(retryMaxAttemptsEnvVar, "2")
+		err := flags.Parse([]string{"--retry-timeout", "5s"})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RetryMaxAttempts).To(Equal(2))
+		Expect(result.RetryTimeout).To(Equal(Duration(5 * time.Second)))
+		Expect(cfg.RetryMaxAttempts).To(BeZero())
+	})
+
+	It("Rejects invalid retry settings in the environment", func() {
+		GinkgoT().Setenv(retryTimeoutEnvVar, "junk")
+		_, err := cfg.Override(ctx, flags)
+		Expect(err).To(MatchError(ContainSubstring(retryTimeoutEnvVar)))
+	})
+
+	It("Enables tracing", func() {
+		err := flags.Parse([]string{"--trace"})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Trace).To(BeTrue())
+		Expect(cfg.Trace).To(BeFalse())
+	})
+
+	It("Enables discovery", func() {
+		err := flags.Parse([]string{"--discovery"})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Discovery).To(BeTrue())
+		Expect(cfg.Discovery).To(BeFalse())
+	})
+
+	It("Enables discovery with the environment variable", func() {
+		GinkgoT().Setenv(discoveryEnvVar, "true")
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Discovery).To(BeTrue())
+	})
+
+	It("Replaces authentication settings with the token", func() {
+		GinkgoT().Setenv(tokenEnvVar, "my-token")
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.AccessToken).To(Equal("my-token"))
+		Expect(result.RefreshToken).To(BeEmpty())
+		Expect(result.OAuthFlow).To(BeEmpty())
+		Expect(cfg.OAuthFlow).ToNot(BeEmpty())
+		Expect(cfg.RefreshToken).To(Equal("saved-refresh"))
+	})
+
+	It("Adds CA files without modifying the original configuration", func() {
+		dir := GinkgoT().TempDir()
+		err := flags.Parse([]string{"--ca-file", dir})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.CaFiles).To(ConsistOf(CaFile{Name: dir}))
+		Expect(result.caPool).ToNot(BeNil())
+		Expect(cfg.CaFiles).To(BeEmpty())
+	})
+
+	It("Keeps gRPC targets and uses the explicit plaintext setting", func() {
+		err := flags.Parse([]string{"--address", "unix:///run/fulfillment.sock", "--plaintext"})
+		Expect(err).ToNot(HaveOccurred())
+		result, err := cfg.Override(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Address).To(Equal("unix:///run/fulfillment.sock"))
+		Expect(result.Plaintext).To(BeTrue())
+	})
+
+	It("Connects to a Unix socket", func() {
+		socket := filepath.Join(GinkgoT().TempDir(), "server.sock")
+		server := NewUnixServer(socket)
+		DeferCleanup(server.Stop)
+		healthv1.RegisterHealthServer(server.Registrar(), health.NewServer())
+		server.Start()
+		err := flags.Parse([]string{"--address", "unix://" + socket, "--plaintext"})
+		Expect(err).ToNot(HaveOccurred())
+		cfg.OAuthFlow = ""
+		conn, err := cfg.Connect(ctx, flags)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		client := healthv1.NewHealthClient(conn)
+		response, err := client.Check(ctx, &healthv1.HealthCheckRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Status).To(Equal(healthv1.HealthCheckResponse_SERVING))
+	})
+})
@@ -0,0 +1,297 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/innabox/fulfillment-common/oauth"
+
+	"github.com/innabox/fulfillment-cli/internal/credentials"
+)
+
+// Keys returns the names of the settings that can be changed with the Set and Unset methods. These are the names of
+// the fields of the configuration as they appear in the JSON representation, sorted alphabetically.
+func Keys() []string {
+	fields := configFields()
+	result := make([]string, len(fields))
+	for i, field := range fields {
+		result[i] = field.key
+	}
+	slices.Sort(result)
+	return result
+}
+
+// Set changes the value of the setting with the given key. The text of the value is converted to the type of the
+// setting: booleans are parsed with strconv.ParseBool, lists are comma separated, times use the RFC 3339 format and
+// durations use the format of time.ParseDuration.
+// The value of the 'ca_files' setting is a comma separated list of files, and the content of those files that are
+// relative will be stored as well, in the same way that the 'login' command does it. The same applies to the
+// 'client_cert' setting, but that contains only one file. The 'client_key' setting is different: only its absolute
+// path is stored, never its content.
+func (c *Config) Set(key, value string) error {
+	field, err := c.lookupField(key)
+	if err != nil {
+		return err
+	}
+	c.markCredentialsChanged(key)
+	switch field.Interface().(type) {
+	case string, oauth.Flow:
+		field.SetString(value)
+	case TokenStorage:
+		if value != "" && !slices.Contains(TokenStorages(), TokenStorage(value)) {
+			return fmt.Errorf(
+				"value '%s' of setting '%s' isn't a valid token storage, valid values are '%s', '%s' and '%s'",
+				value, key, FileTokenStorage, EncryptedTokenStorage, HelperTokenStorage,
+			)
+		}
+		field.SetString(value)
+	case int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("value '%s' of setting '%s' isn't a valid integer", value, key)
+		}
+		field.SetInt(int64(parsed))
+	case Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("value '%s' of setting '%s' isn't a valid duration", value, key)
+		}
+		field.SetInt(int64(parsed))
+	case bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("value '%s' of setting '%s' isn't a valid boolean", value, key)
+		}
+		field.SetBool(parsed)
+	case time.Time:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("value '%s' of setting '%s' isn't a valid RFC 3339 time", value, key)
+		}
+		field.Set(reflect.ValueOf(parsed))
+	case []string:
+		field.Set(reflect.ValueOf(splitList(value)))
+	case []CaFile:
+		var caFiles []CaFile
+		for _, name := range splitList(value) {
+			caFile, err := NewCaFile(name)
+			if err != nil {
+				return err
+			}
+			caFiles = append(caFiles, caFile)
+		}
+		field.Set(reflect.ValueOf(caFiles))
+	case PemFile:
+		var pemFile PemFile
+		if value != "" {
+			if key == "client_key" {
+				pemFile, err = NewClientKeyFile(value)
+			} else {
+				pemFile, err = newPemFile(strings.ReplaceAll(key, "_", " "), value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		field.Set(reflect.ValueOf(pemFile))
+	default:
+		return fmt.Errorf("setting '%s' can't be changed", key)
+	}
+	return nil
+}
+
+// Unset restores the setting with the given key to its default value.
+func (c *Config) Unset(key string) error {
+	field, err := c.lookupField(key)
+	if err != nil {
+		return err
+	}
+	c.markCredentialsChanged(key)
+	field.SetZero()
+	return nil
+}
+
+// Redacted returns a copy of the configuration where the values of the settings that contain secrets, like tokens
+// and passwords, have been replaced with a fixed text.
+func (c *Config) Redacted() *Config {
+	result := *c
+	value := reflect.ValueOf(&result).Elem()
+	for _, field := range configFields() {
+		if !field.secret {
+			continue
+		}
+		fieldValue := value.Field(field.index)
+		switch typed := fieldValue.Interface().(type) {
+		case PemFile:
+			if typed.Content != "" {
+				typed.Content = credentials.RedactedText
+				fieldValue.Set(reflect.ValueOf(typed))
+			}
+		default:
+			if fieldValue.Kind() == reflect.String && fieldValue.String() != "" {
+				fieldValue.SetString(credentials.RedactedText)
+			}
+		}
+	}
+	return &result
+}
+
+// Validate checks that the configuration can be used to connect to the server. It checks that the address has been
+// set, that the token storage can be used, that the CA files and the client certificate can be loaded, and that a token
+// source can be created.
+func (c *Config) Validate(ctx context.Context) error {
+	var errs []error
+	if c.Address == "" {
+		errs = append(errs, errors.New("address is mandatory"))
+	}
+	_, err := c.credentialsStore(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to create token storage: %w", err))
+	}
+	_, certificatesErr := c.ClientCertificates()
+	if certificatesErr != nil {
+		errs = append(errs, certificatesErr)
+	}
+	err = c.createCaPool(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to create CA pool: %w", err))
+	} else if certificatesErr == nil {
+		_, err = c.TokenSource(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewCaFile creates the representation of a CA file. If the name of the file is relative then the content will be
+// read and saved, because otherwise it would not be possible to use it when the tool runs in a different directory.
+func NewCaFile(name string) (result CaFile, err error) {
+	return newPemFile("CA", name)
+}
+
+// NewClientCertFile creates the representation of a client certificate file, saving the content for relative names in
+// the same way that NewCaFile does.
+func NewClientCertFile(name string) (result PemFile, err error) {
+	return newPemFile("client certificate", name)
+}
+
+// NewClientKeyFile creates the representation of a client key file. Unlike NewCaFile it never saves the content,
+// because that would write the private key to the configuration file in plain text, regardless of the token storage.
+// Instead relative names are converted to absolute paths, so that the key can be found when the tool runs in a
+// different directory.
+func NewClientKeyFile(name string) (result PemFile, err error) {
+	if !filepath.IsAbs(name) {
+		_, err = os.Stat(name)
+		if err != nil {
+			err = fmt.Errorf("failed to read client key file '%s': %w", name, err)
+			return
+		}
+		name, err = filepath.Abs(name)
+		if err != nil {
+			err = fmt.Errorf("failed to calculate absolute path of client key file '%s': %w", name, err)
+			return
+		}
+	}
+	result = PemFile{
+		Name: name,
+	}
+	return
+}
+
+func newPemFile(kind, name string) (result PemFile, err error) {
+	if filepath.IsAbs(name) {
+		result = PemFile{
+			Name: name,
+		}
+		return
+	}
+	content, err := os.ReadFile(name)
+	if err != nil {
+		err = fmt.Errorf("failed to read %s file '%s': %w", kind, name, err)
+		return
+	}
+	result = PemFile{
+		Name:    name,
+		Content: string(content),
+	}
+	return
+}
+
+// lookupField returns the reflection value of the field of the configuration that corresponds to the given key.
+func (c *Config) lookupField(key string) (result reflect.Value, err error) {
+	for _, field := range configFields() {
+		if field.key == key {
+			result = reflect.ValueOf(c).Elem().Field(field.index)
+			return
+		}
+	}
+	err = fmt.Errorf(
+		"unknown setting '%s', valid settings are '%s'",
+		key, strings.Join(Keys(), "', '"),
+	)
+	return
+}
+
+// configField contains the details of a field of the configuration that is exposed as a setting.
+type configField struct {
+	index  int
+	key    string
+	secret bool
+}
+
+// configFields returns the details of the exported fields of the configuration type that have a JSON name.
+func configFields() []configField {
+	configType := reflect.TypeFor[Config]()
+	var result []configField
+	for i := range configType.NumField() {
+		field := configType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		key, _, _ := strings.Cut(tag, ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		result = append(result, configField{
+			index:  i,
+			key:    key,
+			secret: field.Tag.Get("secret") == "true",
+		})
+	}
+	return result
+}
+
+// splitList splits a comma separated list, removing spaces and empty items.
+func splitList(text string) []string {
+	var result []string
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
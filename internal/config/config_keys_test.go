@@ -0,0 +1,237 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/innabox/fulfillment-common/oauth"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keys", func() {
+	It("Returns the JSON names of the fields", func() {
+		keys := Keys()
+		Expect(keys).To(ContainElements(
+			"access_token",
+			"address",
+			"ca_files",
+			"client_cert",
+			"client_key",
+			"insecure",
+			"oauth_flow",
+			"oauth_scopes",
+			"private",
+			"plaintext",
+			"token_expiry",
+		))
+		Expect(slices.IsSorted(keys)).To(BeTrue())
+	})
+
+	DescribeTable(
+		"Set",
+		func(key, value string, check func(*Config)) {
+			cfg := &Config{}
+			err := cfg.Set(key, value)
+			Expect(err).ToNot(HaveOccurred())
+			check(cfg)
+		},
+		Entry(
+			"String",
+			"address", "api.example.com:443",
+			func(cfg *Config) {
+				Expect(cfg.Address).To(Equal("api.example.com:443"))
+			},
+		),
+		Entry(
+			"Boolean",
+			"private", "true",
+			func(cfg *Config) {
+				Expect(cfg.Private).To(BeTrue())
+			},
+		),
+		Entry(
+			"Flow",
+			"oauth_flow", "code",
+			func(cfg *Config) {
+				Expect(cfg.OAuthFlow).To(Equal(oauth.CodeFlow))
+			},
+		),
+		Entry(
+			"List",
+			"oauth_scopes", "openid, email,,profile",
+			func(cfg *Config) {
+				Expect(cfg.OAuthScopes).To(Equal([]string{"openid", "email", "profile"}))
+			},
+		),
+		Entry(
+			"Time",
+			"token_expiry", "2025-01-02T03:04:05Z",
+			func(cfg *Config) {
+				Expect(cfg.TokenExpiry).To(Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)))
+			},
+		),
+		Entry(
+			"Integer",
+			"retry_max_attempts", "5",
+			func(cfg *Config) {
+				Expect(cfg.RetryMaxAttempts).To(Equal(5))
+			},
+		),
+		Entry(
+			"Duration",
+			"retry_timeout", "1m30s",
+			func(cfg *Config) {
+				Expect(cfg.RetryTimeout).To(Equal(Duration(90 * time.Second)))
+			},
+		),
+		Entry(
+			"Absolute CA file",
+			"ca_files", "/etc/my-ca.pem",
+			func(cfg *Config) {
+				Expect(cfg.CaFiles).To(Equal([]CaFile{{
+					Name: "/etc/my-ca.pem",
+				}}))
+			},
+		),
+	)
+
+	It("Saves the content of relative CA files", func() {
+		dir := GinkgoT().TempDir()
+		err := os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("my-content"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		GinkgoT().Chdir(dir)
+		cfg := &Config{}
+		err = cfg.Set("ca_files", "ca.pem")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.CaFiles).To(Equal([]CaFile{{
+			Name:    "ca.pem",
+			Content: "my-content",
+		}}))
+	})
+
+	It("Saves the content of relative client certificate files", func() {
+		dir := GinkgoT().TempDir()
+		err := os.WriteFile(filepath.Join(dir, "client.crt"), []byte("my-cert"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		GinkgoT().Chdir(dir)
+		cfg := &Config{}
+		err = cfg.Set("client_cert", "client.crt")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ClientCert).To(Equal(PemFile{
+			Name:    "client.crt",
+			Content: "my-cert",
+		}))
+		err = cfg.Set("client_key", "/etc/my-client.key")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ClientKey).To(Equal(PemFile{
+			Name: "/etc/my-client.key",
+		}))
+	})
+
+	It("Saves only the absolute path of relative client key files", func() {
+		dir := GinkgoT().TempDir()
+		err := os.WriteFile(filepath.Join(dir, "client.key"), []byte("my-key"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		GinkgoT().Chdir(dir)
+		cfg := &Config{}
+		err = cfg.Set("client_key", "client.key")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.ClientKey).To(Equal(PemFile{
+			Name: filepath.Join(dir, "client.key"),
+		}))
+	})
+
+	It("Rejects unknown keys", func() {
+		cfg := &Config{}
+		err := cfg.Set("junk", "value")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unknown setting 'junk'"))
+	})
+
+	It("Rejects invalid booleans", func() {
+		cfg := &Config{}
+		err := cfg.Set("insecure", "junk")
+		Expect(err).To(MatchError("value 'junk' of setting 'insecure' isn't a valid boolean"))
+	})
+
+	It("Rejects invalid integers", func() {
+		cfg := &Config{}
+		err := cfg.Set("retry_max_attempts", "junk")
+		Expect(err).To(MatchError("value 'junk' of setting 'retry_max_attempts' isn't a valid integer"))
+	})
+
+	It("Rejects invalid durations", func() {
+		cfg := &Config{}
+		err := cfg.Set("retry_timeout", "junk")
+		Expect(err).To(MatchError("value 'junk' of setting 'retry_timeout' isn't a valid duration"))
+	})
+
+	It("Stores durations as text", func() {
+		cfg := &Config{
+			RetryTimeout: Duration(90 * time.Second),
+		}
+		data, err := json.Marshal(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		var generic map[string]any
+		err = json.Unmarshal(data, &generic)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(generic).To(HaveKeyWithValue("retry_timeout", "1m30s"))
+		var loaded Config
+		err = json.Unmarshal(data, &loaded)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.RetryTimeout).To(Equal(cfg.RetryTimeout))
+	})
+
+	It("Unsets values", func() {
+		cfg := &Config{
+			Address:     "api.example.com:443",
+			OAuthScopes: []string{"openid"},
+		}
+		err := cfg.Unset("address")
+		Expect(err).ToNot(HaveOccurred())
+		err = cfg.Unset("oauth_scopes")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Address).To(BeEmpty())
+		Expect(cfg.OAuthScopes).To(BeNil())
+	})
+
+	It("Redacts secrets", func() {
+		cfg := &Config{
+			Address:           "api.example.com:443",
+			AccessToken:       "my_access",
+			RefreshToken:      "my_refresh",
+			OAuthClientSecret: "my_secret",
+			ClientKey: PemFile{
+				Name:    "client.key",
+				Content: "my_key",
+			},
+		}
+		redacted := cfg.Redacted()
+		Expect(redacted.Address).To(Equal("api.example.com:443"))
+		Expect(redacted.AccessToken).To(Equal("REDACTED"))
+		Expect(redacted.RefreshToken).To(Equal("REDACTED"))
+		Expect(redacted.OAuthClientSecret).To(Equal("REDACTED"))
+		Expect(redacted.ClientKey.Name).To(Equal("client.key"))
+		Expect(redacted.ClientKey.Content).To(Equal("REDACTED"))
+		Expect(cfg.AccessToken).To(Equal("my_access"))
+		Expect(cfg.ClientKey.Content).To(Equal("my_key"))
+	})
+})
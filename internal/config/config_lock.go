@@ -0,0 +1,90 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/innabox/fulfillment-common/auth"
+	"github.com/innabox/fulfillment-common/logging"
+
+	"github.com/innabox/fulfillment-cli/internal/files"
+)
+
+// withLock runs the given action while holding the lock of the configuration file in the given location. This is
+// intended to protect load-modify-save sequences from other processes that may be modifying the same file, for
+// example when several instances of the tool refresh the OAuth tokens simultaneously. The action receives a context
+// that indicates that the lock is already held, so that nested calls don't try to acquire it again.
+func withLock(ctx context.Context, location string, action func(ctx context.Context) error) error {
+	held, _ := ctx.Value(lockKey).(string)
+	if held == location {
+		return action(ctx)
+	}
+	unlock, err := files.Lock(location)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := unlock()
+		if err != nil {
+			logger := logging.LoggerFromContext(ctx)
+			logger.ErrorContext(
+				ctx,
+				"Failed to release configuration lock",
+				slog.String("location", location),
+				slog.Any("error", err),
+			)
+		}
+	}()
+	return action(context.WithValue(ctx, lockKey, location))
+}
+
+// lockingTokenSource is a token source that holds the lock of the configuration file while the wrapped token source
+// renews the tokens. Before renewing, it loads the tokens again from the configuration, so that when another process
+// has already renewed them they are reused instead of renewed again.
+type lockingTokenSource struct {
+	source auth.TokenSource
+	store  *configTokenStore
+}
+
+// Token is the implementation of the auth.TokenSource interface.
+func (s *lockingTokenSource) Token(ctx context.Context) (result *auth.Token, err error) {
+	// If the token that we already have is fresh then the wrapped token source will not try to renew it, so there is
+	// no need to acquire the lock:
+	if s.store.fresh() {
+		result, err = s.source.Token(ctx)
+		return
+	}
+
+	// Acquire the lock, reload the tokens, and then let the wrapped token source decide if they need to be renewed:
+	location, err := locationOrDefault(s.store.config.location)
+	if err != nil {
+		return
+	}
+	err = withLock(ctx, location, func(ctx context.Context) error {
+		err := s.store.reload(ctx, location)
+		if err != nil {
+			return err
+		}
+		result, err = s.source.Token(ctx)
+		return err
+	})
+	return
+}
+
+// tokenFreshness is the minimum remaining life of a token for it to be used without acquiring the lock. This needs to
+// be longer than the margin that the OAuth token source uses to decide when to renew tokens.
+const tokenFreshness = time.Minute
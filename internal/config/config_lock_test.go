@@ -0,0 +1,216 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/innabox/fulfillment-common/auth"
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// When these environment variables are set the test binary runs as a helper process that renews the token and saves
+// a context, instead of running the tests. See the runLockHelper function.
+const (
+	lockHelperConfigEnvVar  = "CONFIG_LOCK_TEST_CONFIG"
+	lockHelperCounterEnvVar = "CONFIG_LOCK_TEST_COUNTER"
+)
+
+func init() {
+	location := os.Getenv(lockHelperConfigEnvVar)
+	if location == "" {
+		return
+	}
+	err := runLockHelper(location, os.Getenv(lockHelperCounterEnvVar), fmt.Sprintf("process-%d", os.Getpid()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runLockHelper gets a token, renewing it if needed, and then saves a new context with the given name. This is used
+// both from goroutines and from helper processes.
+func runLockHelper(location, counter, name string) error {
+	ctx := logging.LoggerIntoContext(context.Background(), slog.New(slog.DiscardHandler))
+	ctx = LocationIntoContext(ctx, location)
+	cfg, err := Load(ctx)
+	if err != nil {
+		return err
+	}
+	store := cfg.tokenStore()
+	source := &lockingTokenSource{
+		source: &countingTokenSource{
+			store:   store,
+			counter: counter,
+			name:    name,
+		},
+		store: store,
+	}
+	_, err = source.Token(ctx)
+	if err != nil {
+		return err
+	}
+	extra, err := New(ContextNameIntoContext(ctx, name))
+	if err != nil {
+		return err
+	}
+	extra.Address = name + ".example.com:443"
+	return Save(ctx, extra)
+}
+
+// countingTokenSource is a token source that renews the token when it isn't fresh, writing a line to a counter file
+// each time that it does, similar to what the OAuth token source does.
+type countingTokenSource struct {
+	store   auth.TokenStore
+	counter string
+	name    string
+}
+
+func (s *countingTokenSource) Token(ctx context.Context) (result *auth.Token, err error) {
+	loaded, err := s.store.Load(ctx)
+	if err != nil {
+		return
+	}
+	if loaded != nil && time.Until(loaded.Expiry) > 30*time.Second {
+		result = loaded
+		return
+	}
+
+	// Wait a bit, so that other goroutines and processes have a chance to try to renew the token simultaneously:
+	time.Sleep(50 * time.Millisecond)
+	file, err := os.OpenFile(s.counter, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	_, err = fmt.Fprintln(file, s.name)
+	if err != nil {
+		return
+	}
+	err = file.Close()
+	if err != nil {
+		return
+	}
+	result = &auth.Token{
+		Access:  "renewed-by-" + s.name,
+		Refresh: "refresh-by-" + s.name,
+		Expiry:  time.Now().Add(time.Hour),
+	}
+	err = s.store.Save(ctx, result)
+	return
+}
+
+var _ = Describe("Locking", func() {
+	var (
+		ctx      context.Context
+		location string
+		counter  string
+	)
+
+	BeforeEach(func() {
+		ctx = logging.LoggerIntoContext(context.Background(), logger)
+
+		// Create a configuration with an expired token:
+		dir := GinkgoT().TempDir()
+		location = filepath.Join(dir, "config.json")
+		counter = filepath.Join(dir, "counter")
+		ctx = LocationIntoContext(ctx, location)
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "my.example.com:443"
+		cfg.AccessToken = "expired"
+		cfg.RefreshToken = "my-refresh"
+		cfg.TokenExpiry = time.Now().Add(-time.Hour)
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	// readCounter returns the names of the goroutines or processes that renewed the token.
+	readCounter := func() []string {
+		data, err := os.ReadFile(counter)
+		Expect(err).ToNot(HaveOccurred())
+		return strings.Fields(string(data))
+	}
+
+	// checkResult checks that the token has been renewed only once, and that the contexts saved by all the
+	// goroutines or processes are in the file.
+	checkResult := func(names []string) {
+		renewers := readCounter()
+		Expect(renewers).To(HaveLen(1))
+		file, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		for _, name := range names {
+			Expect(file.Contexts).To(HaveKey(name))
+			Expect(file.Contexts[name].Address).To(Equal(name + ".example.com:443"))
+		}
+		cfg := file.Contexts[DefaultContext]
+		Expect(cfg).ToNot(BeNil())
+		Expect(cfg.Address).To(Equal("my.example.com:443"))
+		Expect(cfg.AccessToken).To(Equal("renewed-by-" + renewers[0]))
+		Expect(cfg.RefreshToken).To(Equal("refresh-by-" + renewers[0]))
+	}
+
+	It("Renews the token only once from multiple goroutines", func() {
+		const count = 10
+		names := make([]string, count)
+		wg := &sync.WaitGroup{}
+		for i := range count {
+			names[i] = fmt.Sprintf("goroutine-%d", i)
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				err := runLockHelper(location, counter, names[i])
+				Expect(err).ToNot(HaveOccurred())
+			}()
+		}
+		wg.Wait()
+		checkResult(names)
+	})
+
+	It("Renews the token only once from multiple processes", func() {
+		const count = 5
+		cmds := make([]*exec.Cmd, count)
+		for i := range count {
+			cmd := exec.Command(os.Args[0])
+			cmd.Env = append(
+				os.Environ(),
+				lockHelperConfigEnvVar+"="+location,
+				lockHelperCounterEnvVar+"="+counter,
+			)
+			cmd.Stdout = GinkgoWriter
+			cmd.Stderr = GinkgoWriter
+			err := cmd.Start()
+			Expect(err).ToNot(HaveOccurred())
+			cmds[i] = cmd
+		}
+		names := make([]string, count)
+		for i, cmd := range cmds {
+			err := cmd.Wait()
+			Expect(err).ToNot(HaveOccurred())
+			names[i] = fmt.Sprintf("process-%d", cmd.Process.Pid)
+		}
+		checkResult(names)
+	})
+})
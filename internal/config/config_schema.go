@@ -0,0 +1,191 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/innabox/fulfillment-cli/internal/files"
+)
+
+// The layout of the configuration file has changed over time. These are the versions that have existed so far:
+//
+//	0 - All the settings directly in the top level object, without a version field. The 'private' setting was
+//	    called 'packages' and the CA files could be plain strings, without the content.
+//
+//	1 - Settings inside named contexts, still without a version field.
+//
+//	2 - Explicit version field, 'packages' renamed to 'private' and CA files always objects.
+//
+// To change the layout add a new function to the list of migrations. The function receives the generic representation
+// of the file in the previous version and should modify it so that it matches the next version. The current version
+// is the number of migrations.
+var schemaMigrations = []func(data map[string]any) error{
+	migrateSchema0To1,
+	migrateSchema1To2,
+}
+
+// currentVersion is the version of the layout of the configuration file written by this version of the tool.
+var currentVersion = len(schemaMigrations)
+
+// migrateSchema0To1 moves the settings of old versions into the default context.
+func migrateSchema0To1(data map[string]any) error {
+	settings := map[string]any{}
+	for key, value := range data {
+		settings[key] = value
+		delete(data, key)
+	}
+	data["current_context"] = DefaultContext
+	data["contexts"] = map[string]any{
+		DefaultContext: settings,
+	}
+	return nil
+}
+
+// migrateSchema1To2 renames the 'packages' setting to 'private' and converts CA files that are plain strings into
+// objects.
+func migrateSchema1To2(data map[string]any) error {
+	contexts, _ := data["contexts"].(map[string]any)
+	for name, value := range contexts {
+		settings, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		private, ok := settings["packages"]
+		if ok {
+			settings["private"] = private
+			delete(settings, "packages")
+		}
+		caFiles, _ := settings["ca_files"].([]any)
+		for i, caFile := range caFiles {
+			switch caFile := caFile.(type) {
+			case string:
+				caFiles[i] = map[string]any{
+					"name": caFile,
+				}
+			case map[string]any:
+			default:
+				return fmt.Errorf("CA file %d of context '%s' has unexpected type %T", i, name, caFile)
+			}
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the version of the layout of the given generic representation of the configuration file.
+func schemaVersion(data map[string]any) (result int, err error) {
+	value, ok := data["version"]
+	if ok {
+		number, ok := value.(float64)
+		if !ok || number != float64(int(number)) || number < 0 {
+			err = fmt.Errorf("version '%v' isn't a valid non negative integer", value)
+			return
+		}
+		result = int(number)
+		return
+	}
+	_, hasContexts := data["contexts"]
+	_, hasCurrent := data["current_context"]
+	switch {
+	case len(data) == 0:
+		result = currentVersion
+	case hasContexts || hasCurrent:
+		result = 1
+	default:
+		result = 0
+	}
+	return
+}
+
+// migrateSchema checks the version of the layout of the given configuration file content and converts it to the
+// current version if needed. It returns the converted content and the original version.
+func migrateSchema(location string, data []byte) (result []byte, version int, err error) {
+	var generic map[string]any
+	err = json.Unmarshal(data, &generic)
+	if err != nil {
+		err = fmt.Errorf("failed to parse config file '%s': %w", location, err)
+		return
+	}
+	version, err = schemaVersion(generic)
+	if err != nil {
+		err = fmt.Errorf("failed to parse config file '%s': %w", location, err)
+		return
+	}
+	if version > currentVersion {
+		err = &NewerVersionError{
+			Location: location,
+			Version:  version,
+		}
+		return
+	}
+	if version == currentVersion {
+		result = data
+		return
+	}
+	for i := version; i < currentVersion; i++ {
+		err = schemaMigrations[i](generic)
+		if err != nil {
+			err = fmt.Errorf(
+				"failed to migrate config file '%s' from version %d to version %d: %w",
+				location, i, i+1, err,
+			)
+			return
+		}
+	}
+	generic["version"] = currentVersion
+	result, err = json.Marshal(generic)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal migrated config file '%s': %w", location, err)
+	}
+	return
+}
+
+// backupFile returns the name of the file used to keep a copy of a configuration file in the given version, before
+// migrating it to the current version.
+func backupFile(location string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", location, version)
+}
+
+// writeBackup writes a copy of the original content of a configuration file before it is replaced with the migrated
+// content. If a backup for the same version already exists it is preserved, as it is closer to the original.
+func writeBackup(location string, version int, data []byte) error {
+	backup := backupFile(location, version)
+	_, err := os.Stat(backup)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check if backup file '%s' exists: %w", backup, err)
+	}
+	return files.WriteAtomic(backup, data, 0600)
+}
+
+// NewerVersionError is the error returned when the configuration file has been written by a newer version of the
+// tool that uses a layout that this version doesn't understand.
+type NewerVersionError struct {
+	Location string
+	Version  int
+}
+
+// Error is the implementation of the error interface.
+func (e *NewerVersionError) Error() string {
+	return fmt.Sprintf(
+		"config file '%s' has version %d, but this version of the tool only supports up to version %d, "+
+			"it was probably written by a newer version of the tool, please upgrade",
+		e.Location, e.Version, currentVersion,
+	)
+}
@@ -0,0 +1,214 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+	var (
+		ctx      context.Context
+		location string
+	)
+
+	BeforeEach(func() {
+		ctx = logging.LoggerIntoContext(context.Background(), logger)
+		location = filepath.Join(GinkgoT().TempDir(), "config.json")
+		ctx = LocationIntoContext(ctx, location)
+	})
+
+	writeFile := func(data string) {
+		err := os.WriteFile(location, []byte(data), 0600)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	readFile := func() map[string]any {
+		data, err := os.ReadFile(location)
+		Expect(err).ToNot(HaveOccurred())
+		var result map[string]any
+		err = json.Unmarshal(data, &result)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	DescribeTable(
+		"Round trip of historical layouts",
+		func(version int, original string, check func(*File)) {
+			writeFile(original)
+
+			// Load the file and check the result:
+			file, err := LoadFile(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			check(file)
+
+			// Check that the file has been saved with the current version, and that a backup of the original has
+			// been written, if needed:
+			Expect(readFile()).To(HaveKeyWithValue("version", BeEquivalentTo(currentVersion)))
+			backup := backupFile(location, version)
+			if version < currentVersion {
+				data, err := os.ReadFile(backup)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal(original))
+			} else {
+				Expect(backup).ToNot(BeAnExistingFile())
+			}
+
+			// Save and load again, the result should be the same:
+			err = SaveFile(file)
+			Expect(err).ToNot(HaveOccurred())
+			reloaded, err := LoadFile(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			check(reloaded)
+			Expect(reloaded.Contexts).To(Equal(file.Contexts))
+		},
+		Entry(
+			"Version 0 with plain CA files",
+			0,
+			`{
+				"address": "my.example.com:443",
+				"packages": true,
+				"ca_files": ["/etc/my-ca.pem"],
+				"access_token": "my-access"
+			}`,
+			func(file *File) {
+				Expect(file.CurrentContext).To(Equal(DefaultContext))
+				Expect(file.Contexts).To(HaveLen(1))
+				cfg := file.Contexts[DefaultContext]
+				Expect(cfg.Address).To(Equal("my.example.com:443"))
+				Expect(cfg.Private).To(BeTrue())
+				Expect(cfg.CaFiles).To(Equal([]CaFile{{
+					Name: "/etc/my-ca.pem",
+				}}))
+				Expect(cfg.AccessToken).To(Equal("my-access"))
+			},
+		),
+		Entry(
+			"Version 0 with CA file content",
+			0,
+			`{
+				"address": "my.example.com:443",
+				"ca_files": [
+					{
+						"name": "my-ca.pem",
+						"content": "my-content"
+					}
+				]
+			}`,
+			func(file *File) {
+				cfg := file.Contexts[DefaultContext]
+				Expect(cfg.Address).To(Equal("my.example.com:443"))
+				Expect(cfg.Private).To(BeFalse())
+				Expect(cfg.CaFiles).To(Equal([]CaFile{{
+					Name:    "my-ca.pem",
+					Content: "my-content",
+				}}))
+			},
+		),
+		Entry(
+			"Version 1",
+			1,
+			`{
+				"current_context": "staging",
+				"contexts": {
+					"production": {
+						"address": "production.example.com:443",
+						"packages": true
+					},
+					"staging": {
+						"address": "staging.example.com:443"
+					}
+				}
+			}`,
+			func(file *File) {
+				Expect(file.CurrentContext).To(Equal("staging"))
+				Expect(file.Contexts).To(HaveLen(2))
+				Expect(file.Contexts["production"].Address).To(Equal("production.example.com:443"))
+				Expect(file.Contexts["production"].Private).To(BeTrue())
+				Expect(file.Contexts["staging"].Address).To(Equal("staging.example.com:443"))
+				Expect(file.Contexts["staging"].Private).To(BeFalse())
+			},
+		),
+		Entry(
+			"Version 2",
+			2,
+			`{
+				"version": 2,
+				"current_context": "production",
+				"contexts": {
+					"production": {
+						"address": "production.example.com:443",
+						"private": true
+					}
+				}
+			}`,
+			func(file *File) {
+				Expect(file.CurrentContext).To(Equal("production"))
+				Expect(file.Contexts["production"].Private).To(BeTrue())
+			},
+		),
+	)
+
+	It("Preserves the first backup", func() {
+		writeFile(`{ "address": "first.example.com:443" }`)
+		_, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		writeFile(`{ "address": "second.example.com:443" }`)
+		_, err = LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := os.ReadFile(backupFile(location, 0))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("first.example.com:443"))
+	})
+
+	It("Doesn't modify empty file", func() {
+		writeFile("")
+		file, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Contexts).To(BeEmpty())
+		data, err := os.ReadFile(location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(BeEmpty())
+	})
+
+	It("Rejects file written by a newer version", func() {
+		writeFile(`{
+			"version": 1000,
+			"contexts": {}
+		}`)
+		_, err := LoadFile(ctx)
+		Expect(err).To(HaveOccurred())
+		var newerErr *NewerVersionError
+		Expect(err).To(BeAssignableToTypeOf(newerErr))
+		Expect(err.Error()).To(ContainSubstring("version 1000"))
+		Expect(err.Error()).To(ContainSubstring("please upgrade"))
+
+		// The file should not have been modified:
+		Expect(readFile()).To(HaveKeyWithValue("version", BeEquivalentTo(1000)))
+	})
+
+	It("Rejects invalid version", func() {
+		writeFile(`{ "version": "junk" }`)
+		_, err := LoadFile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("isn't a valid non negative integer")))
+	})
+})
@@ -0,0 +1,316 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/innabox/fulfillment-common/logging"
+
+	"github.com/innabox/fulfillment-cli/internal/credentials"
+)
+
+// TokenStorage is the type of backend used to store the tokens and other secrets of a configuration context.
+type TokenStorage string
+
+const (
+	// FileTokenStorage stores the tokens in plain text inside the configuration file. This is the default.
+	FileTokenStorage TokenStorage = "file"
+
+	// EncryptedTokenStorage stores the tokens in a separate file, encrypted with a key that is taken from the
+	// environment variable indicated by the TokenKeyEnvVar constant, or from the file indicated by the
+	// 'token_storage_key_file' setting.
+	EncryptedTokenStorage TokenStorage = "encrypted"
+
+	// HelperTokenStorage sends the tokens to the external helper program indicated by the 'token_storage_helper'
+	// setting, similar to git credential helpers.
+	HelperTokenStorage TokenStorage = "helper"
+)
+
+// TokenKeyEnvVar is the name of the environment variable that contains the key used by the encrypted token storage.
+const TokenKeyEnvVar = "FULFILLMENT_CLI_TOKEN_KEY"
+
+// TokenStorages returns the valid token storage types.
+func TokenStorages() []TokenStorage {
+	return []TokenStorage{
+		FileTokenStorage,
+		EncryptedTokenStorage,
+		HelperTokenStorage,
+	}
+}
+
+// credentialsStore returns the store where the tokens and other secrets of the configuration are kept. It returns nil
+// if they are kept in the configuration file itself.
+func (c *Config) credentialsStore(ctx context.Context) (result credentials.Store, err error) {
+	logger := logging.LoggerFromContext(ctx)
+	switch c.TokenStorage {
+	case "", FileTokenStorage:
+		return
+	case EncryptedTokenStorage:
+		var key []byte
+		key, err = c.tokenStorageKey()
+		if err != nil {
+			return
+		}
+		var location string
+		location, err = locationOrDefault(c.location)
+		if err != nil {
+			return
+		}
+		file := strings.TrimSuffix(location, filepath.Ext(location)) + ".credentials"
+		result, err = credentials.NewEncryptedStore().
+			SetLogger(logger).
+			SetFile(file).
+			SetKey(key).
+			Build()
+	case HelperTokenStorage:
+		if c.TokenStorageHelper == "" {
+			err = fmt.Errorf(
+				"token storage '%s' requires the 'token_storage_helper' setting",
+				HelperTokenStorage,
+			)
+			return
+		}
+		result, err = credentials.NewHelperStore().
+			SetLogger(logger).
+			SetCommand(c.TokenStorageHelper).
+			Build()
+	default:
+		err = fmt.Errorf(
+			"unknown token storage '%s', valid values are '%s', '%s' and '%s'",
+			c.TokenStorage, FileTokenStorage, EncryptedTokenStorage, HelperTokenStorage,
+		)
+	}
+	return
+}
+
+// tokenStorageKey returns the key material for the encrypted token storage.
+func (c *Config) tokenStorageKey() (result []byte, err error) {
+	text := os.Getenv(TokenKeyEnvVar)
+	if text != "" {
+		result = []byte(text)
+		return
+	}
+	if c.TokenStorageKeyFile == "" {
+		err = fmt.Errorf(
+			"token storage '%s' requires a key, set the '%s' environment variable or the "+
+				"'token_storage_key_file' setting",
+			EncryptedTokenStorage, TokenKeyEnvVar,
+		)
+		return
+	}
+	data, err := os.ReadFile(c.TokenStorageKeyFile)
+	if err != nil {
+		err = fmt.Errorf("failed to read token storage key file '%s': %w", c.TokenStorageKeyFile, err)
+		return
+	}
+	result = bytes.TrimSpace(data)
+	if len(result) == 0 {
+		err = fmt.Errorf("token storage key file '%s' is empty", c.TokenStorageKeyFile)
+	}
+	return
+}
+
+// loadCredentials loads the tokens and other secrets from the token storage. If the configuration file still contains
+// those secrets in plain text, for example because the token storage has been changed since they were saved, then they
+// are moved to the token storage and removed from the configuration file.
+func (c *Config) loadCredentials(ctx context.Context) error {
+	c.credentialsLoaded = true
+	store, err := c.credentialsStore(ctx)
+	if err != nil || store == nil {
+		return err
+	}
+	if !c.credentials().Empty() {
+		err = c.migrateCredentials(ctx, store)
+		if err != nil {
+			return err
+		}
+	}
+	stored, err := store.Load(ctx, c.name)
+	if err != nil {
+		return err
+	}
+	c.setCredentials(stored)
+	return nil
+}
+
+// migrateCredentials moves the plain text tokens and other secrets from the configuration file to the token storage.
+func (c *Config) migrateCredentials(ctx context.Context, store credentials.Store) error {
+	location, err := locationOrDefault(c.location)
+	if err != nil {
+		return err
+	}
+	return withLock(ctx, location, func(ctx context.Context) error {
+		// Load the file again, as another process may have already migrated the credentials:
+		file, err := loadFile(location)
+		if err != nil {
+			return err
+		}
+		saved := file.Contexts[c.name]
+		if saved == nil || saved.credentials().Empty() {
+			return nil
+		}
+		logger := logging.LoggerFromContext(ctx)
+		logger.InfoContext(
+			ctx,
+			"Migrating plain text credentials to token storage",
+			slog.String("context", c.name),
+			slog.Any("storage", c.TokenStorage),
+		)
+		err = store.Save(ctx, c.name, saved.credentials())
+		if err != nil {
+			return err
+		}
+		saved.setCredentials(nil)
+		return SaveFile(file)
+	})
+}
+
+// saveCredentials saves the tokens and other secrets to the token storage, if it isn't the configuration file. It
+// returns the configuration that should be written to the configuration file, which will be a copy without the secrets
+// in that case.
+func (c *Config) saveCredentials(ctx context.Context) (result *Config, err error) {
+	store, err := c.credentialsStore(ctx)
+	if err != nil {
+		return
+	}
+	if store == nil {
+		result = c
+		return
+	}
+
+	// If the credentials weren't loaded from the storage, for example when the 'config set' or 'config unset'
+	// commands are used, then we only know the values that have been explicitly changed, so the rest need to be
+	// taken from the storage. If nothing has been changed then the storage is left untouched.
+	creds := c.credentials()
+	save := c.credentialsLoaded
+	if !c.credentialsLoaded && (len(c.changedCredentials) > 0 || !creds.Empty()) {
+		var stored *credentials.Credentials
+		stored, err = store.Load(ctx, c.name)
+		if err != nil {
+			return
+		}
+		creds = c.mergeCredentials(stored)
+		save = true
+	}
+	if save {
+		if !creds.Empty() {
+			err = store.Save(ctx, c.name, creds)
+		} else {
+			err = store.Delete(ctx, c.name)
+		}
+		if err != nil {
+			return
+		}
+	}
+	result = &Config{}
+	*result = *c
+	result.setCredentials(nil)
+	return
+}
+
+// deleteCredentials removes the tokens and other secrets from the token storage, if it isn't the configuration file.
+func (c *Config) deleteCredentials(ctx context.Context) error {
+	store, err := c.credentialsStore(ctx)
+	if err != nil || store == nil {
+		return err
+	}
+	return store.Delete(ctx, c.name)
+}
+
+// renameCredentials moves the tokens and other secrets of the configuration to a new key in the token storage, if it
+// isn't the configuration file.
+func (c *Config) renameCredentials(ctx context.Context, to string) error {
+	store, err := c.credentialsStore(ctx)
+	if err != nil || store == nil {
+		return err
+	}
+	stored, err := store.Load(ctx, c.name)
+	if err != nil || stored.Empty() {
+		return err
+	}
+	err = store.Save(ctx, to, stored)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, c.name)
+}
+
+// credentialsKeys are the keys of the settings that are kept in the token storage.
+var credentialsKeys = []string{
+	"access_token",
+	"refresh_token",
+	"token_expiry",
+	"oauth_client_secret",
+}
+
+// markCredentialsChanged remembers that the setting with the given key has been explicitly changed, if it is one of
+// the settings that are kept in the token storage.
+func (c *Config) markCredentialsChanged(key string) {
+	if !slices.Contains(credentialsKeys, key) {
+		return
+	}
+	if c.changedCredentials == nil {
+		c.changedCredentials = map[string]bool{}
+	}
+	c.changedCredentials[key] = true
+}
+
+// mergeCredentials returns a copy of the given stored credentials, updated with the values of this configuration that
+// aren't empty or that have been explicitly changed.
+func (c *Config) mergeCredentials(stored *credentials.Credentials) *credentials.Credentials {
+	result := &credentials.Credentials{}
+	if stored != nil {
+		*result = *stored
+	}
+	if c.AccessToken != "" || c.changedCredentials["access_token"] {
+		result.AccessToken = c.AccessToken
+	}
+	if c.RefreshToken != "" || c.changedCredentials["refresh_token"] {
+		result.RefreshToken = c.RefreshToken
+	}
+	if !c.TokenExpiry.IsZero() || c.changedCredentials["token_expiry"] {
+		result.TokenExpiry = c.TokenExpiry
+	}
+	if c.OAuthClientSecret != "" || c.changedCredentials["oauth_client_secret"] {
+		result.OAuthClientSecret = c.OAuthClientSecret
+	}
+	return result
+}
+
+func (c *Config) credentials() *credentials.Credentials {
+	return &credentials.Credentials{
+		AccessToken:       c.AccessToken,
+		RefreshToken:      c.RefreshToken,
+		TokenExpiry:       c.TokenExpiry,
+		OAuthClientSecret: c.OAuthClientSecret,
+	}
+}
+
+func (c *Config) setCredentials(value *credentials.Credentials) {
+	if value == nil {
+		value = &credentials.Credentials{}
+	}
+	c.AccessToken = value.AccessToken
+	c.RefreshToken = value.RefreshToken
+	c.TokenExpiry = value.TokenExpiry
+	c.OAuthClientSecret = value.OAuthClientSecret
+}
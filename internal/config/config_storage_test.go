@@ -0,0 +1,291 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/innabox/fulfillment-common/auth"
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Token storage", func() {
+	var (
+		ctx         context.Context
+		file        string
+		credentials string
+	)
+
+	BeforeEach(func() {
+		ctx = logging.LoggerIntoContext(context.Background(), logger)
+		dir := GinkgoT().TempDir()
+		file = filepath.Join(dir, "config.json")
+		credentials = filepath.Join(dir, "config.credentials")
+		ctx = LocationIntoContext(ctx, file)
+		GinkgoT().Setenv(TokenKeyEnvVar, "my-key")
+	})
+
+	readFile := func() string {
+		data, err := os.ReadFile(file)
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("Keeps tokens in the configuration file by default", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.AccessToken = "my-access"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile()).To(ContainSubstring("my-access"))
+		Expect(credentials).ToNot(BeAnExistingFile())
+	})
+
+	It("Keeps tokens out of the configuration file when encrypted", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		cfg.OAuthClientSecret = "my-secret"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile()).ToNot(ContainSubstring("my-access"))
+		Expect(readFile()).ToNot(ContainSubstring("my-secret"))
+		Expect(credentials).To(BeARegularFile())
+
+		// The tokens should still be in memory:
+		Expect(cfg.AccessToken).To(Equal("my-access"))
+
+		// And they should be loaded:
+		loaded, err := Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.AccessToken).To(Equal("my-access"))
+		Expect(loaded.OAuthClientSecret).To(Equal("my-secret"))
+	})
+
+	It("Migrates plain text tokens the first time they are read", func() {
+		err := os.WriteFile(file, []byte(`{
+			"current_context": "default",
+			"contexts": {
+				"default": {
+					"address": "my.example.com:443",
+					"token_storage": "encrypted",
+					"access_token": "my-access",
+					"refresh_token": "my-refresh"
+				}
+			}
+		}`), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err := Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(Equal("my-access"))
+		Expect(cfg.RefreshToken).To(Equal("my-refresh"))
+		Expect(readFile()).ToNot(ContainSubstring("my-access"))
+		Expect(readFile()).ToNot(ContainSubstring("my-refresh"))
+		Expect(readFile()).To(ContainSubstring("my.example.com:443"))
+
+		// Loading again should return the migrated tokens:
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(Equal("my-access"))
+		Expect(cfg.RefreshToken).To(Equal("my-refresh"))
+	})
+
+	It("Fails to load encrypted tokens without a key", func() {
+		GinkgoT().Setenv(TokenKeyEnvVar, "")
+		cfg := &Config{
+			TokenStorage: EncryptedTokenStorage,
+		}
+		err := cfg.Validate(ctx)
+		Expect(err).To(MatchError(ContainSubstring(TokenKeyEnvVar)))
+	})
+
+	It("Reads the key from the key file", func() {
+		GinkgoT().Setenv(TokenKeyEnvVar, "")
+		keyFile := filepath.Join(GinkgoT().TempDir(), "key")
+		err := os.WriteFile(keyFile, []byte("my-key\n"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.TokenStorageKeyFile = keyFile
+		cfg.AccessToken = "my-access"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The key from the file and from the environment should be equivalent:
+		GinkgoT().Setenv(TokenKeyEnvVar, "my-key")
+		loaded, err := Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.AccessToken).To(Equal("my-access"))
+	})
+
+	It("Removes tokens from the storage when they are cleared", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.AccessToken = ""
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(BeEmpty())
+	})
+
+	It("Preserves tokens when other settings are changed", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		// This is what the 'config set' command does:
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg = loaded.Selected(ctx)
+		err = cfg.Set("address", "your.example.com:443")
+		Expect(err).ToNot(HaveOccurred())
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Address).To(Equal("your.example.com:443"))
+		Expect(cfg.AccessToken).To(Equal("my-access"))
+	})
+
+	It("Preserves the other secrets when one is changed with 'config set'", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		cfg.RefreshToken = "my-refresh"
+		cfg.OAuthClientSecret = "my-secret"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		// This is what the 'config set' command does:
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg = loaded.Selected(ctx)
+		err = cfg.Set("access_token", "your-access")
+		Expect(err).ToNot(HaveOccurred())
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile()).ToNot(ContainSubstring("your-access"))
+
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(Equal("your-access"))
+		Expect(cfg.RefreshToken).To(Equal("my-refresh"))
+		Expect(cfg.OAuthClientSecret).To(Equal("my-secret"))
+	})
+
+	It("Removes only the secret that is removed with 'config unset'", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		cfg.RefreshToken = "my-refresh"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		// This is what the 'config unset' command does:
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg = loaded.Selected(ctx)
+		err = cfg.Unset("refresh_token")
+		Expect(err).ToNot(HaveOccurred())
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(Equal("my-access"))
+		Expect(cfg.RefreshToken).To(BeEmpty())
+	})
+
+	It("Removes the stored entry when the last secret is removed with 'config unset'", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg = loaded.Selected(ctx)
+		err = cfg.Unset("access_token")
+		Expect(err).ToNot(HaveOccurred())
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(BeEmpty())
+	})
+
+	It("Moves tokens when the context is renamed", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		err = RenameContext(ctx, DefaultContext, "renamed")
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Name()).To(Equal("renamed"))
+		Expect(cfg.AccessToken).To(Equal("my-access"))
+	})
+
+	It("Saves refreshed tokens to the storage", func() {
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.TokenStorage = EncryptedTokenStorage
+		cfg.AccessToken = "my-access"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		err = cfg.TokenStore().Save(ctx, &auth.Token{
+			Access:  "new-access",
+			Refresh: "new-refresh",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile()).ToNot(ContainSubstring("new-access"))
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.AccessToken).To(Equal("new-access"))
+		Expect(cfg.RefreshToken).To(Equal("new-refresh"))
+	})
+
+	It("Rejects unknown token storage", func() {
+		cfg := &Config{}
+		err := cfg.Set("token_storage", "junk")
+		Expect(err).To(MatchError(ContainSubstring("isn't a valid token storage")))
+	})
+})
@@ -0,0 +1,270 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/innabox/fulfillment-common/logging"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Contexts", func() {
+	var (
+		ctx  context.Context
+		file string
+	)
+
+	BeforeEach(func() {
+		// Create the context:
+		ctx = logging.LoggerIntoContext(context.Background(), logger)
+
+		// Use a temporary directory as the user configuration directory:
+		dir := GinkgoT().TempDir()
+		GinkgoT().Setenv("XDG_CONFIG_HOME", dir)
+		GinkgoT().Setenv("HOME", dir)
+		GinkgoT().Setenv(LocationEnvVar, "")
+		var err error
+		file, err = Location(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file).To(HavePrefix(dir))
+	})
+
+	writeFile := func(data string) {
+		err := os.MkdirAll(filepath.Dir(file), 0700)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(file, []byte(data), 0600)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("Returns empty configuration if the file doesn't exist", func() {
+		cfg, err := Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Address).To(BeEmpty())
+		Expect(cfg.Name()).To(Equal(DefaultContext))
+	})
+
+	It("Loads legacy file without contexts into the default context", func() {
+		writeFile(`{
+			"address": "legacy.example.com:443",
+			"access_token": "my_token"
+		}`)
+		cfg, err := Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Name()).To(Equal(DefaultContext))
+		Expect(cfg.Address).To(Equal("legacy.example.com:443"))
+		Expect(cfg.AccessToken).To(Equal("my_token"))
+	})
+
+	It("Loads the current context", func() {
+		writeFile(`{
+			"current_context": "production",
+			"contexts": {
+				"staging": {
+					"address": "staging.example.com:443"
+				},
+				"production": {
+					"address": "production.example.com:443"
+				}
+			}
+		}`)
+		cfg, err := Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Name()).To(Equal("production"))
+		Expect(cfg.Address).To(Equal("production.example.com:443"))
+	})
+
+	It("Loads the explicitly selected context", func() {
+		writeFile(`{
+			"current_context": "production",
+			"contexts": {
+				"staging": {
+					"address": "staging.example.com:443"
+				},
+				"production": {
+					"address": "production.example.com:443"
+				}
+			}
+		}`)
+		cfg, err := Load(ContextNameIntoContext(ctx, "staging"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Name()).To(Equal("staging"))
+		Expect(cfg.Address).To(Equal("staging.example.com:443"))
+	})
+
+	It("Fails if the explicitly selected context doesn't exist", func() {
+		writeFile(`{
+			"current_context": "production",
+			"contexts": {
+				"production": {
+					"address": "production.example.com:443"
+				}
+			}
+		}`)
+		cfg, err := Load(ContextNameIntoContext(ctx, "prodution"))
+		Expect(err).To(MatchError(HavePrefix("context 'prodution' doesn't exist")))
+		Expect(cfg).To(BeNil())
+	})
+
+	It("Fails if the explicitly selected context doesn't exist and there is no file", func() {
+		_, err := Load(ContextNameIntoContext(ctx, "staging"))
+		Expect(err).To(MatchError(HavePrefix("context 'staging' doesn't exist")))
+	})
+
+	It("Saves a context without changing the others", func() {
+		writeFile(`{
+			"current_context": "production",
+			"contexts": {
+				"production": {
+					"address": "production.example.com:443"
+				}
+			}
+		}`)
+		cfg, err := New(ContextNameIntoContext(ctx, "staging"))
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "staging.example.com:443"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.CurrentContext).To(Equal("production"))
+		Expect(loaded.Names()).To(Equal([]string{"production", "staging"}))
+		Expect(loaded.Contexts["production"].Address).To(Equal("production.example.com:443"))
+		Expect(loaded.Contexts["staging"].Address).To(Equal("staging.example.com:443"))
+	})
+
+	It("Changes the current context", func() {
+		writeFile(`{
+			"current_context": "production",
+			"contexts": {
+				"staging": {},
+				"production": {}
+			}
+		}`)
+		err := UseContext(ctx, "staging")
+		Expect(err).ToNot(HaveOccurred())
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.CurrentContext).To(Equal("staging"))
+	})
+
+	It("Can't use a context that doesn't exist", func() {
+		err := UseContext(ctx, "missing")
+		Expect(err).To(MatchError("context 'missing' doesn't exist"))
+	})
+
+	It("Renames the current context", func() {
+		writeFile(`{
+			"current_context": "production",
+			"contexts": {
+				"production": {
+					"address": "production.example.com:443"
+				}
+			}
+		}`)
+		err := RenameContext(ctx, "production", "prod")
+		Expect(err).ToNot(HaveOccurred())
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.CurrentContext).To(Equal("prod"))
+		Expect(loaded.Names()).To(Equal([]string{"prod"}))
+		Expect(loaded.Contexts["prod"].Address).To(Equal("production.example.com:443"))
+	})
+
+	It("Can't rename a context to an existing name", func() {
+		writeFile(`{
+			"contexts": {
+				"staging": {},
+				"production": {}
+			}
+		}`)
+		err := RenameContext(ctx, "staging", "production")
+		Expect(err).To(MatchError("context 'production' already exists"))
+	})
+
+	It("Deletes the current context", func() {
+		writeFile(`{
+			"current_context": "production",
+			"contexts": {
+				"staging": {},
+				"production": {}
+			}
+		}`)
+		err := DeleteContext(ctx, "production")
+		Expect(err).ToNot(HaveOccurred())
+		loaded, err := LoadFile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.CurrentContext).To(BeEmpty())
+		Expect(loaded.Names()).To(Equal([]string{"staging"}))
+	})
+
+	It("Uses the location from the environment", func() {
+		location := filepath.Join(GinkgoT().TempDir(), "my.json")
+		GinkgoT().Setenv(LocationEnvVar, location)
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "env.example.com:443"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(location).To(BeARegularFile())
+		Expect(file).ToNot(BeAnExistingFile())
+	})
+
+	It("Gives precedence to the location from the context", func() {
+		GinkgoT().Setenv(LocationEnvVar, filepath.Join(GinkgoT().TempDir(), "env.json"))
+		location := filepath.Join(GinkgoT().TempDir(), "ctx.json")
+		ctx = LocationIntoContext(ctx, location)
+		actual, err := Location(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(location))
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "ctx.example.com:443"
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		loaded, err := Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Address).To(Equal("ctx.example.com:443"))
+		Expect(file).ToNot(BeAnExistingFile())
+	})
+
+	It("Reads the service account token for every request", func() {
+		tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+		err := os.WriteFile(tokenFile, []byte("first_token"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		cfg, err := New(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		cfg.Address = "api.example.com:443"
+		cfg.ServiceAccountTokenFile = tokenFile
+		err = Save(ctx, cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err = Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		source, err := cfg.TokenSource(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		token, err := source.Token(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Access).To(Equal("first_token"))
+		err = os.WriteFile(tokenFile, []byte("second_token"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		token, err = source.Token(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Access).To(Equal("second_token"))
+	})
+})
@@ -0,0 +1,51 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+// Package credentials contains the backends that can be used to store security sensitive data, like access and
+// refresh tokens, outside of the plain text configuration file.
+package credentials
+
+import (
+	"context"
+	"time"
+)
+
+// Credentials contains the security sensitive details of a configuration context.
+type Credentials struct {
+	AccessToken       string    `json:"access_token,omitempty"`
+	RefreshToken      string    `json:"refresh_token,omitempty"`
+	TokenExpiry       time.Time `json:"token_expiry,omitzero"`
+	OAuthClientSecret string    `json:"oauth_client_secret,omitempty"`
+}
+
+// Store is the interface implemented by the credentials storage backends. Credentials are identified by a key, usually
+// the name of the configuration context.
+type Store interface {
+	// Load loads the credentials for the given key. It returns nil if there are no credentials for that key.
+	Load(ctx context.Context, key string) (result *Credentials, err error)
+
+	// Save saves the credentials for the given key, replacing any existing ones.
+	Save(ctx context.Context, key string, credentials *Credentials) error
+
+	// Delete removes the credentials for the given key. It isn't an error if there are no credentials for that key.
+	Delete(ctx context.Context, key string) error
+}
+
+// RedactedText is the text that replaces security sensitive values when they are displayed, for example by the
+// 'config view' command or in the traces of the calls sent to the server.
+const RedactedText = "REDACTED"
+
+// Empty returns true if the credentials don't contain any data.
+func (c *Credentials) Empty() bool {
+	return c == nil || *c == Credentials{}
+}
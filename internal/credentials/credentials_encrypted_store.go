@@ -0,0 +1,243 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/innabox/fulfillment-cli/internal/files"
+)
+
+// EncryptedStoreBuilder contains the data and logic needed to create a credentials store that saves all the credentials
+// to a single file encrypted with AES-GCM.
+type EncryptedStoreBuilder struct {
+	logger *slog.Logger
+	file   string
+	key    []byte
+}
+
+// EncryptedStore is a credentials store that saves the credentials to an encrypted file. Don't create instances of
+// this type directly, use the NewEncryptedStore function instead.
+type EncryptedStore struct {
+	logger *slog.Logger
+	file   string
+	aead   cipher.AEAD
+	lock   *sync.Mutex
+}
+
+// encryptedFile is the content of the encrypted file. The data is the JSON representation of a map containing the
+// credentials indexed by key, encrypted with the given nonce.
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// NewEncryptedStore creates a builder that can then be used to configure and create a new encrypted credentials store.
+func NewEncryptedStore() *EncryptedStoreBuilder {
+	return &EncryptedStoreBuilder{}
+}
+
+// SetLogger sets the logger that the store will use to write messages to the log. This is mandatory.
+func (b *EncryptedStoreBuilder) SetLogger(value *slog.Logger) *EncryptedStoreBuilder {
+	b.logger = value
+	return b
+}
+
+// SetFile sets the path of the encrypted file. This is mandatory. The file will be created if it doesn't exist.
+func (b *EncryptedStoreBuilder) SetFile(value string) *EncryptedStoreBuilder {
+	b.file = value
+	return b
+}
+
+// SetKey sets the key material used to encrypt the file. This is mandatory. It doesn't need to have any specific
+// length, as the actual encryption key is derived from it, but it should be a long random value.
+func (b *EncryptedStoreBuilder) SetKey(value []byte) *EncryptedStoreBuilder {
+	b.key = value
+	return b
+}
+
+// Build uses the data stored in the builder to create a new encrypted credentials store.
+func (b *EncryptedStoreBuilder) Build() (result *EncryptedStore, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.file == "" {
+		err = errors.New("file is mandatory")
+		return
+	}
+	if len(b.key) == 0 {
+		err = errors.New("key is mandatory")
+		return
+	}
+
+	// Derive the encryption key from the key material:
+	key, err := hkdf.Key(sha256.New, b.key, nil, encryptedStoreInfo, 32)
+	if err != nil {
+		err = fmt.Errorf("failed to derive encryption key: %w", err)
+		return
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		err = fmt.Errorf("failed to create cipher: %w", err)
+		return
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		err = fmt.Errorf("failed to create AEAD: %w", err)
+		return
+	}
+
+	// Create and populate the object:
+	result = &EncryptedStore{
+		logger: b.logger,
+		file:   b.file,
+		aead:   aead,
+		lock:   &sync.Mutex{},
+	}
+	return
+}
+
+// Load is the implementation of the Store interface.
+func (s *EncryptedStore) Load(ctx context.Context, key string) (result *Credentials, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	all, err := s.read()
+	if err != nil {
+		return
+	}
+	result = all[key]
+	return
+}
+
+// Save is the implementation of the Store interface.
+func (s *EncryptedStore) Save(ctx context.Context, key string, credentials *Credentials) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	all[key] = credentials
+	s.logger.DebugContext(
+		ctx,
+		"Saving encrypted credentials",
+		slog.String("file", s.file),
+		slog.String("key", key),
+	)
+	return s.write(all)
+}
+
+// Delete is the implementation of the Store interface.
+func (s *EncryptedStore) Delete(ctx context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	_, ok := all[key]
+	if !ok {
+		return nil
+	}
+	delete(all, key)
+	s.logger.DebugContext(
+		ctx,
+		"Deleting encrypted credentials",
+		slog.String("file", s.file),
+		slog.String("key", key),
+	)
+	return s.write(all)
+}
+
+func (s *EncryptedStore) read() (result map[string]*Credentials, err error) {
+	result = map[string]*Credentials{}
+	data, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to read credentials file '%s': %w", s.file, err)
+		return
+	}
+	var envelope encryptedFile
+	err = json.Unmarshal(data, &envelope)
+	if err != nil {
+		err = fmt.Errorf("failed to parse credentials file '%s': %w", s.file, err)
+		return
+	}
+	if envelope.Version != encryptedFileVersion {
+		err = fmt.Errorf(
+			"credentials file '%s' has unsupported version %d, only version %d is supported",
+			s.file, envelope.Version, encryptedFileVersion,
+		)
+		return
+	}
+	plain, err := s.aead.Open(nil, envelope.Nonce, envelope.Data, nil)
+	if err != nil {
+		err = fmt.Errorf(
+			"failed to decrypt credentials file '%s', check that the key is correct: %w",
+			s.file, err,
+		)
+		return
+	}
+	err = json.Unmarshal(plain, &result)
+	if err != nil {
+		err = fmt.Errorf("failed to parse decrypted credentials file '%s': %w", s.file, err)
+	}
+	return
+}
+
+func (s *EncryptedStore) write(all map[string]*Credentials) error {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	envelope := encryptedFile{
+		Version: encryptedFileVersion,
+		Nonce:   nonce,
+		Data:    s.aead.Seal(nil, nonce, plain, nil),
+	}
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials file: %w", err)
+	}
+	return files.WriteAtomic(s.file, data, 0600)
+}
+
+// encryptedStoreInfo is the context information used to derive the encryption key, so that the same key material
+// used for other purposes doesn't result in the same encryption key.
+const encryptedStoreInfo = "fulfillment-cli credentials"
+
+// encryptedFileVersion is the version of the format of the encrypted file.
+const encryptedFileVersion = 1
@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encrypted store", func() {
+	var (
+		ctx  context.Context
+		file string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		file = filepath.Join(GinkgoT().TempDir(), "credentials")
+	})
+
+	newStore := func(key string) *EncryptedStore {
+		store, err := NewEncryptedStore().
+			SetLogger(logger).
+			SetFile(file).
+			SetKey([]byte(key)).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		return store
+	}
+
+	It("Can't be created without a key", func() {
+		store, err := NewEncryptedStore().
+			SetLogger(logger).
+			SetFile(file).
+			Build()
+		Expect(err).To(MatchError("key is mandatory"))
+		Expect(store).To(BeNil())
+	})
+
+	It("Returns nil if the file doesn't exist", func() {
+		store := newStore("my-key")
+		credentials, err := store.Load(ctx, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credentials).To(BeNil())
+	})
+
+	It("Loads the saved credentials", func() {
+		store := newStore("my-key")
+		saved := &Credentials{
+			AccessToken:       "my-access",
+			RefreshToken:      "my-refresh",
+			TokenExpiry:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			OAuthClientSecret: "my-secret",
+		}
+		err := store.Save(ctx, "default", saved)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Use a different store to make sure that the data is read from the file:
+		loaded, err := newStore("my-key").Load(ctx, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(Equal(saved))
+	})
+
+	It("Doesn't write the credentials in plain text", func() {
+		store := newStore("my-key")
+		err := store.Save(ctx, "default", &Credentials{
+			AccessToken: "my-access",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		data, err := os.ReadFile(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).ToNot(ContainSubstring("my-access"))
+		info, err := os.Stat(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("Fails to load with the wrong key", func() {
+		err := newStore("my-key").Save(ctx, "default", &Credentials{
+			AccessToken: "my-access",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = newStore("your-key").Load(ctx, "default")
+		Expect(err).To(MatchError(ContainSubstring("check that the key is correct")))
+	})
+
+	It("Keeps the credentials of other keys", func() {
+		store := newStore("my-key")
+		err := store.Save(ctx, "first", &Credentials{
+			AccessToken: "first-access",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		err = store.Save(ctx, "second", &Credentials{
+			AccessToken: "second-access",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		err = store.Delete(ctx, "first")
+		Expect(err).ToNot(HaveOccurred())
+		first, err := store.Load(ctx, "first")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first).To(BeNil())
+		second, err := store.Load(ctx, "second")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second.AccessToken).To(Equal("second-access"))
+	})
+})
@@ -0,0 +1,224 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// HelperStoreBuilder contains the data and logic needed to create a credentials store that delegates to an external
+// helper program.
+//
+// The helper is executed with '/bin/sh -c', like git does with credential helpers, regardless of the shell of the
+// user, adding the name of the operation as the last argument: 'get', 'store' or
+// 'erase'. The input and output of the helper use the same format used by git credential helpers: a sequence of lines
+// containing an attribute name, an equals sign and the attribute value, terminated by an empty line or by the end of
+// the stream. The input always contains the 'key' attribute. For the 'store' operation it also contains the
+// 'access_token', 'refresh_token', 'token_expiry' and 'oauth_client_secret' attributes, and for the 'get' operation the
+// helper is expected to write those to the output. An empty output means that there are no credentials for that key.
+// The token expiry uses the RFC 3339 format.
+type HelperStoreBuilder struct {
+	logger  *slog.Logger
+	command string
+}
+
+// HelperStore is a credentials store that delegates to an external helper program. Don't create instances of this
+// type directly, use the NewHelperStore function instead.
+type HelperStore struct {
+	logger  *slog.Logger
+	command string
+}
+
+// NewHelperStore creates a builder that can then be used to configure and create a new helper credentials store.
+func NewHelperStore() *HelperStoreBuilder {
+	return &HelperStoreBuilder{}
+}
+
+// SetLogger sets the logger that the store will use to write messages to the log. This is mandatory.
+func (b *HelperStoreBuilder) SetLogger(value *slog.Logger) *HelperStoreBuilder {
+	b.logger = value
+	return b
+}
+
+// SetCommand sets the command that will be used to run the helper. This is mandatory.
+func (b *HelperStoreBuilder) SetCommand(value string) *HelperStoreBuilder {
+	b.command = value
+	return b
+}
+
+// Build uses the data stored in the builder to create a new helper credentials store.
+func (b *HelperStoreBuilder) Build() (result *HelperStore, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.command == "" {
+		err = errors.New("command is mandatory")
+		return
+	}
+
+	// Create and populate the object:
+	result = &HelperStore{
+		logger:  b.logger,
+		command: b.command,
+	}
+	return
+}
+
+// Load is the implementation of the Store interface.
+func (s *HelperStore) Load(ctx context.Context, key string) (result *Credentials, err error) {
+	output, err := s.run(ctx, helperGetOp, [][2]string{
+		{helperKeyAttr, key},
+	})
+	if err != nil {
+		return
+	}
+	attrs, err := s.parse(output)
+	if err != nil {
+		return
+	}
+	if len(attrs) == 0 {
+		return
+	}
+	credentials := &Credentials{
+		AccessToken:       attrs[helperAccessTokenAttr],
+		RefreshToken:      attrs[helperRefreshTokenAttr],
+		OAuthClientSecret: attrs[helperOAuthClientSecretAttr],
+	}
+	expiry := attrs[helperTokenExpiryAttr]
+	if expiry != "" {
+		credentials.TokenExpiry, err = time.Parse(time.RFC3339, expiry)
+		if err != nil {
+			err = fmt.Errorf("failed to parse token expiry '%s' returned by credentials helper: %w", expiry, err)
+			return
+		}
+	}
+	result = credentials
+	return
+}
+
+// Save is the implementation of the Store interface.
+func (s *HelperStore) Save(ctx context.Context, key string, credentials *Credentials) error {
+	attrs := [][2]string{
+		{helperKeyAttr, key},
+		{helperAccessTokenAttr, credentials.AccessToken},
+		{helperRefreshTokenAttr, credentials.RefreshToken},
+		{helperOAuthClientSecretAttr, credentials.OAuthClientSecret},
+	}
+	if !credentials.TokenExpiry.IsZero() {
+		attrs = append(attrs, [2]string{
+			helperTokenExpiryAttr,
+			credentials.TokenExpiry.Format(time.RFC3339),
+		})
+	}
+	_, err := s.run(ctx, helperStoreOp, attrs)
+	return err
+}
+
+// Delete is the implementation of the Store interface.
+func (s *HelperStore) Delete(ctx context.Context, key string) error {
+	_, err := s.run(ctx, helperEraseOp, [][2]string{
+		{helperKeyAttr, key},
+	})
+	return err
+}
+
+func (s *HelperStore) run(ctx context.Context, op string, attrs [][2]string) (result []byte, err error) {
+	// Prepare the input:
+	input := &bytes.Buffer{}
+	for _, attr := range attrs {
+		name, value := attr[0], attr[1]
+		if value == "" {
+			continue
+		}
+		if strings.ContainsAny(value, "\n\x00") {
+			err = fmt.Errorf("value of attribute '%s' contains a new line or null character", name)
+			return
+		}
+		fmt.Fprintf(input, "%s=%s\n", name, value)
+	}
+	input.WriteString("\n")
+
+	// Run the helper:
+	output := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, helperShell, "-c", s.command+" "+op)
+	cmd.Stdin = input
+	cmd.Stdout = output
+	cmd.Stderr = stderr
+	s.logger.DebugContext(
+		ctx,
+		"Running credentials helper",
+		slog.String("command", s.command),
+		slog.String("op", op),
+	)
+	err = cmd.Run()
+	if err != nil {
+		err = fmt.Errorf(
+			"credentials helper '%s' failed to %s credentials: %w: %s",
+			s.command, op, err, strings.TrimSpace(stderr.String()),
+		)
+		return
+	}
+	result = output.Bytes()
+	return
+}
+
+func (s *HelperStore) parse(data []byte) (result map[string]string, err error) {
+	result = map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			err = errors.New("credentials helper returned a line without an equals sign")
+			return
+		}
+		result[name] = value
+	}
+	err = scanner.Err()
+	return
+}
+
+// helperShell is the shell used to run the helper. It is always the POSIX shell, because the shell of the user may be
+// something like fish or csh, which quote arguments differently.
+const helperShell = "/bin/sh"
+
+// Operations supported by the helper:
+const (
+	helperGetOp   = "get"
+	helperStoreOp = "store"
+	helperEraseOp = "erase"
+)
+
+// Names of the attributes exchanged with the helper:
+const (
+	helperKeyAttr               = "key"
+	helperAccessTokenAttr       = "access_token"
+	helperRefreshTokenAttr      = "refresh_token"
+	helperTokenExpiryAttr       = "token_expiry"
+	helperOAuthClientSecretAttr = "oauth_client_secret"
+)
@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Helper store", func() {
+	var (
+		ctx   context.Context
+		dir   string
+		store *HelperStore
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		// Create a helper that saves the input of each key to a file, and returns the content of that file:
+		dir = GinkgoT().TempDir()
+		helper := filepath.Join(dir, "helper.sh")
+		err := os.WriteFile(helper, []byte(`#!/bin/sh
+dir=$(dirname "$0")
+input=$(cat)
+key=$(echo "$input" | sed -n 's/^key=//p')
+case "$1" in
+get)
+  if [ -f "$dir/$key.txt" ]; then
+    cat "$dir/$key.txt"
+  fi
+  ;;
+store)
+  echo "$input" > "$dir/$key.txt"
+  ;;
+erase)
+  rm -f "$dir/$key.txt"
+  ;;
+esac
+`), 0700)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Create the store:
+		store, err = NewHelperStore().
+			SetLogger(logger).
+			SetCommand(helper).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Can't be created without a command", func() {
+		store, err := NewHelperStore().
+			SetLogger(logger).
+			Build()
+		Expect(err).To(MatchError("command is mandatory"))
+		Expect(store).To(BeNil())
+	})
+
+	It("Ignores the shell of the user", func() {
+		GinkgoT().Setenv("SHELL", filepath.Join(dir, "junk"))
+		err := store.Save(ctx, "default", &Credentials{
+			AccessToken: "my-access",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		credentials, err := store.Load(ctx, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credentials.AccessToken).To(Equal("my-access"))
+	})
+
+	It("Returns nil if the helper doesn't return anything", func() {
+		credentials, err := store.Load(ctx, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credentials).To(BeNil())
+	})
+
+	It("Sends the credentials to the helper", func() {
+		err := store.Save(ctx, "default", &Credentials{
+			AccessToken:  "my-access",
+			RefreshToken: "my-refresh",
+			TokenExpiry:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		data, err := os.ReadFile(filepath.Join(dir, "default.txt"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(
+			"key=default\n" +
+				"access_token=my-access\n" +
+				"refresh_token=my-refresh\n" +
+				"token_expiry=2025-01-02T03:04:05Z\n",
+		))
+	})
+
+	It("Loads the credentials returned by the helper", func() {
+		saved := &Credentials{
+			AccessToken:       "my-access",
+			RefreshToken:      "my-refresh",
+			TokenExpiry:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			OAuthClientSecret: "my-secret",
+		}
+		err := store.Save(ctx, "default", saved)
+		Expect(err).ToNot(HaveOccurred())
+		loaded, err := store.Load(ctx, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(Equal(saved))
+	})
+
+	It("Asks the helper to erase the credentials", func() {
+		err := store.Save(ctx, "default", &Credentials{
+			AccessToken: "my-access",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		err = store.Delete(ctx, "default")
+		Expect(err).ToNot(HaveOccurred())
+		loaded, err := store.Load(ctx, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(BeNil())
+	})
+
+	It("Rejects values containing new lines", func() {
+		err := store.Save(ctx, "default", &Credentials{
+			AccessToken: "my\naccess",
+		})
+		Expect(err).To(MatchError(ContainSubstring("new line")))
+	})
+
+	It("Reports failures of the helper", func() {
+		store, err := NewHelperStore().
+			SetLogger(logger).
+			SetCommand("echo 'no way' >&2; false").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = store.Load(ctx, "default")
+		Expect(err).To(MatchError(ContainSubstring("no way")))
+	})
+})
@@ -28,3 +28,7 @@ func (e Error) Error() string {
 func (e Error) Code() int {
 	return int(e)
 }
+
+// Timeout is the exit code used when the command doesn't complete before the deadline set with the '--timeout' flag.
+// The value is the same that the 'timeout' command of coreutils uses.
+const Timeout Error = 124
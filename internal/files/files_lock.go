@@ -0,0 +1,56 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Lock acquires an exclusive advisory lock associated to the given file, waiting till it is available. The lock is
+// held on a separate file with the same name and the '.lock' suffix, so that the file itself can be replaced while the
+// lock is held. The returned function releases the lock.
+//
+// The lock excludes other processes, and also other goroutines of the same process that call this function, but it
+// isn't reentrant: calling it again from the same goroutine while holding the lock will block forever.
+func Lock(path string) (unlock func() error, err error) {
+	lockPath := path + ".lock"
+	dir := filepath.Dir(lockPath)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		err = fmt.Errorf("failed to create directory '%s': %w", dir, err)
+		return
+	}
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		err = fmt.Errorf("failed to open lock file '%s': %w", lockPath, err)
+		return
+	}
+	err = lockFile(file)
+	if err != nil {
+		_ = file.Close()
+		err = fmt.Errorf("failed to lock file '%s': %w", lockPath, err)
+		return
+	}
+	unlock = func() error {
+		err := unlockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return fmt.Errorf("failed to unlock file '%s': %w", lockPath, err)
+		}
+		return file.Close()
+	}
+	return
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Files", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	Describe("Write atomic", func() {
+		It("Writes the data and leaves no temporary files", func() {
+			path := filepath.Join(dir, "sub", "my.json")
+			err := WriteAtomic(path, []byte("my-data"), 0600)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("my-data"))
+			info, err := os.Stat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			entries, err := os.ReadDir(filepath.Dir(path))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+
+		It("Replaces existing file", func() {
+			path := filepath.Join(dir, "my.json")
+			err := os.WriteFile(path, []byte("old-data"), 0600)
+			Expect(err).ToNot(HaveOccurred())
+			err = WriteAtomic(path, []byte("new-data"), 0600)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("new-data"))
+		})
+	})
+
+	Describe("Lock", func() {
+		It("Excludes other goroutines", func() {
+			// Each goroutine reads a counter, waits a bit so that others have a chance to run, and then writes
+			// the incremented counter. Without the lock some of the increments would be lost.
+			path := filepath.Join(dir, "counter")
+			err := os.WriteFile(path, []byte("0"), 0600)
+			Expect(err).ToNot(HaveOccurred())
+			const count = 10
+			wg := &sync.WaitGroup{}
+			for range count {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					unlock, err := Lock(path)
+					Expect(err).ToNot(HaveOccurred())
+					defer func() {
+						err := unlock()
+						Expect(err).ToNot(HaveOccurred())
+					}()
+					data, err := os.ReadFile(path)
+					Expect(err).ToNot(HaveOccurred())
+					value, err := strconv.Atoi(string(data))
+					Expect(err).ToNot(HaveOccurred())
+					time.Sleep(5 * time.Millisecond)
+					err = WriteAtomic(path, []byte(strconv.Itoa(value+1)), 0600)
+					Expect(err).ToNot(HaveOccurred())
+				}()
+			}
+			wg.Wait()
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(strconv.Itoa(count)))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+// Package files contains functions to safely update files that may be used by multiple processes simultaneously.
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic writes the given data to the given file. The data is first written to a temporary file in the same
+// directory, and then that temporary file is renamed, so that other processes reading the file will see either the
+// old content or the new content, but never a partially written file. The directory is created if it doesn't exist.
+func WriteAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		err = fmt.Errorf("failed to create directory '%s': %w", dir, err)
+		return
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		err = fmt.Errorf("failed to create temporary file for '%s': %w", path, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	err = tmp.Chmod(perm)
+	if err != nil {
+		err = fmt.Errorf("failed to change permissions of temporary file for '%s': %w", path, err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if err != nil {
+		err = fmt.Errorf("failed to write temporary file for '%s': %w", path, err)
+		return
+	}
+	err = tmp.Sync()
+	if err != nil {
+		err = fmt.Errorf("failed to sync temporary file for '%s': %w", path, err)
+		return
+	}
+	err = tmp.Close()
+	if err != nil {
+		err = fmt.Errorf("failed to close temporary file for '%s': %w", path, err)
+		return
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		err = fmt.Errorf("failed to rename temporary file to '%s': %w", path, err)
+	}
+	return
+}
@@ -14,6 +14,7 @@ language governing permissions and limitations under the License.
 package network
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -63,8 +64,27 @@ func (b *AddressParserBuilder) Build() (result *AddressParser, err error) {
 // or https:// schemes). It returns the parsed address (host:port), a boolean indicating if the connection should
 // use plaintext (true means plaintext/http, false means TLS/https), and an error if parsing fails. If the address
 // doesn't have a scheme and doesn't have a port, the default port 443 is added and plaintext is disabled (TLS is used).
-// Only http:// and https:// schemes are supported; other schemes will result in an error.
+// IPv6 addresses need to be enclosed in brackets when they have a port, for example '[::1]:8000'.
+//
+// The address can also be a gRPC target with the 'unix', 'unix-abstract' or 'dns' schemes, for example
+// 'unix:///run/fulfillment.sock' or 'dns:///api.example.com:443'. Those targets are returned unchanged, except that
+// the default port is added to 'dns' targets that don't have one. Those schemes don't say anything about TLS, so
+// plaintext will be false and the caller should honor any explicit plaintext setting, see the IsTarget function.
+// Other schemes will result in an error.
 func (p *AddressParser) Parse(address string) (parsedAddress string, plaintext bool, err error) {
+	// Check first for gRPC targets, as some of them don't contain '://':
+	scheme, rest, ok := strings.Cut(address, ":")
+	if ok {
+		switch scheme {
+		case unixScheme:
+			return p.parseUnix(address, rest)
+		case unixAbstractScheme:
+			return p.parseUnixAbstract(address, rest)
+		case dnsScheme:
+			return p.parseDns(address, rest)
+		}
+	}
+
 	// If the address looks like a URL, try to parse it as such:
 	if strings.Contains(address, "://") {
 		var url *neturl.URL
@@ -86,7 +106,7 @@ func (p *AddressParser) Parse(address string) (parsedAddress string, plaintext b
 }
 
 func (p *AddressParser) parseHostPort(host, port string) (address string, plaintext bool, err error) {
-	address = fmt.Sprintf("%s:%s", host, port)
+	address = net.JoinHostPort(host, port)
 	plaintext = false
 	return
 }
@@ -99,7 +119,7 @@ func (p *AddressParser) parseUrl(url *neturl.URL) (address string, plaintext boo
 		if port == "" {
 			port = "80"
 		}
-		address = fmt.Sprintf("%s:%s", host, port)
+		address = net.JoinHostPort(host, port)
 		plaintext = true
 		return
 	case "https":
@@ -108,19 +128,113 @@ func (p *AddressParser) parseUrl(url *neturl.URL) (address string, plaintext boo
 		if port == "" {
 			port = "443"
 		}
-		address = fmt.Sprintf("%s:%s", host, port)
+		address = net.JoinHostPort(host, port)
 		plaintext = false
 	default:
 		err = fmt.Errorf(
-			"unsupported scheme '%s' in address '%s', only 'http' and 'https' are supported",
-			url.Scheme, url.String(),
+			"unsupported scheme '%s' in address '%s', only 'http', 'https', '%s', '%s' and '%s' are "+
+				"supported",
+			url.Scheme, url.String(), unixScheme, unixAbstractScheme, dnsScheme,
 		)
 	}
 	return
 }
 
 func (p *AddressParser) parseHost(host string) (address string, plaintext bool, err error) {
-	address = fmt.Sprintf("%s:443", host)
+	// Remove the brackets that may surround an IPv6 address, as they will be added again when joining the host
+	// and the port:
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	if host == "" {
+		err = errors.New("address is empty")
+		return
+	}
+	address = net.JoinHostPort(host, "443")
 	plaintext = false
 	return
 }
+
+// parseUnix checks a target like 'unix:///path/to/socket' or 'unix:relative/path/to/socket'. Note that gRPC doesn't
+// support the authority part, so something like 'unix://host/path' is an error.
+func (p *AddressParser) parseUnix(address, rest string) (result string, plaintext bool, err error) {
+	path := rest
+	if strings.HasPrefix(rest, "//") {
+		path = strings.TrimPrefix(rest, "//")
+		if !strings.HasPrefix(path, "/") {
+			err = fmt.Errorf(
+				"address '%s' isn't valid, Unix socket paths with '%s://' should be absolute, like "+
+					"'%s:///run/my.sock'",
+				address, unixScheme, unixScheme,
+			)
+			return
+		}
+	}
+	if path == "" {
+		err = fmt.Errorf("address '%s' doesn't contain the path of the Unix socket", address)
+		return
+	}
+	result = address
+	return
+}
+
+// parseUnixAbstract checks a target like 'unix-abstract:name'.
+func (p *AddressParser) parseUnixAbstract(address, rest string) (result string, plaintext bool, err error) {
+	if rest == "" {
+		err = fmt.Errorf("address '%s' doesn't contain the name of the abstract Unix socket", address)
+		return
+	}
+	result = address
+	return
+}
+
+// parseDns checks a target like 'dns:///host:port' or 'dns://authority/host:port', adding the default port if needed.
+func (p *AddressParser) parseDns(address, rest string) (result string, plaintext bool, err error) {
+	authority := ""
+	endpoint := rest
+	hasAuthority := strings.HasPrefix(rest, "//")
+	if hasAuthority {
+		authority, endpoint, _ = strings.Cut(strings.TrimPrefix(rest, "//"), "/")
+	}
+	if endpoint == "" {
+		err = fmt.Errorf("address '%s' doesn't contain the host name", address)
+		return
+	}
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		endpoint, _, err = p.parseHost(endpoint)
+		if err != nil {
+			return
+		}
+	} else {
+		endpoint = net.JoinHostPort(host, port)
+	}
+	if hasAuthority {
+		result = fmt.Sprintf("%s://%s/%s", dnsScheme, authority, endpoint)
+	} else {
+		result = fmt.Sprintf("%s:%s", dnsScheme, endpoint)
+	}
+	return
+}
+
+// IsTarget returns true if the address is a gRPC target with the 'unix', 'unix-abstract' or 'dns' schemes. Those can be
+// passed directly to the gRPC client, and they don't say anything about TLS.
+func IsTarget(address string) bool {
+	scheme, _, ok := strings.Cut(address, ":")
+	if !ok {
+		return false
+	}
+	switch scheme {
+	case unixScheme, unixAbstractScheme, dnsScheme:
+		return true
+	default:
+		return false
+	}
+}
+
+// Schemes of the gRPC targets that are supported:
+const (
+	unixScheme         = "unix"
+	unixAbstractScheme = "unix-abstract"
+	dnsScheme          = "dns"
+)
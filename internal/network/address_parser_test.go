@@ -123,6 +123,84 @@ var _ = Describe("AddressParser", func() {
 			"192.168.1.1:443",
 			false,
 		),
+		Entry(
+			"Bracketed IPv6 address with port",
+			"[::1]:8000",
+			"[::1]:8000",
+			false,
+		),
+		Entry(
+			"Bracketed IPv6 address without port (adds default 443)",
+			"[2001:db8::1]",
+			"[2001:db8::1]:443",
+			false,
+		),
+		Entry(
+			"IPv6 address without brackets and without port (adds default 443)",
+			"2001:db8::1",
+			"[2001:db8::1]:443",
+			false,
+		),
+		Entry(
+			"http:// URL with IPv6 address and port",
+			"http://[::1]:8000",
+			"[::1]:8000",
+			true,
+		),
+		Entry(
+			"https:// URL with IPv6 address and default port",
+			"https://[2001:db8::1]",
+			"[2001:db8::1]:443",
+			false,
+		),
+		Entry(
+			"unix:/// target",
+			"unix:///run/fulfillment.sock",
+			"unix:///run/fulfillment.sock",
+			false,
+		),
+		Entry(
+			"unix: target with relative path",
+			"unix:fulfillment.sock",
+			"unix:fulfillment.sock",
+			false,
+		),
+		Entry(
+			"unix-abstract: target",
+			"unix-abstract:fulfillment",
+			"unix-abstract:fulfillment",
+			false,
+		),
+		Entry(
+			"dns:/// target with port",
+			"dns:///api.example.com:8000",
+			"dns:///api.example.com:8000",
+			false,
+		),
+		Entry(
+			"dns:/// target without port (adds default 443)",
+			"dns:///api.example.com",
+			"dns:///api.example.com:443",
+			false,
+		),
+		Entry(
+			"dns:/// target with IPv6 address",
+			"dns:///[::1]:8000",
+			"dns:///[::1]:8000",
+			false,
+		),
+		Entry(
+			"dns:// target with authority",
+			"dns://8.8.8.8/api.example.com",
+			"dns://8.8.8.8/api.example.com:443",
+			false,
+		),
+		Entry(
+			"dns: target without slashes",
+			"dns:api.example.com:8000",
+			"dns:api.example.com:8000",
+			false,
+		),
 	)
 
 	DescribeTable(
@@ -154,5 +232,38 @@ var _ = Describe("AddressParser", func() {
 			"wss://example.com",
 			"unsupported scheme 'wss'",
 		),
+		Entry(
+			"unix: target without path",
+			"unix:",
+			"doesn't contain the path of the Unix socket",
+		),
+		Entry(
+			"unix:// target with authority",
+			"unix://host/run/fulfillment.sock",
+			"should be absolute",
+		),
+		Entry(
+			"unix-abstract: target without name",
+			"unix-abstract:",
+			"doesn't contain the name of the abstract Unix socket",
+		),
+		Entry(
+			"dns:/// target without host",
+			"dns:///",
+			"doesn't contain the host name",
+		),
+	)
+
+	DescribeTable(
+		"Detection of gRPC targets",
+		func(input string, expected bool) {
+			Expect(IsTarget(input)).To(Equal(expected))
+		},
+		Entry("unix:///", "unix:///run/fulfillment.sock", true),
+		Entry("unix-abstract:", "unix-abstract:fulfillment", true),
+		Entry("dns:///", "dns:///api.example.com:443", true),
+		Entry("host:port", "api.example.com:443", false),
+		Entry("https://", "https://api.example.com", false),
+		Entry("IPv6", "[::1]:443", false),
 	)
 })
@@ -0,0 +1,259 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package network
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ClientKeyPassphraseEnvVar is the name of the environment variable that contains the passphrase used to decrypt
+// client keys that are encrypted.
+const ClientKeyPassphraseEnvVar = "FULFILLMENT_CLI_CLIENT_KEY_PASSPHRASE"
+
+// LoadClientCertificate creates a TLS certificate from the given PEM encoded certificate and key. The key may be
+// encrypted, either using the PKCS#8 'ENCRYPTED PRIVATE KEY' format or the legacy OpenSSL 'Proc-Type' headers, and
+// in that case the passphrase is used to decrypt it.
+func LoadClientCertificate(certPEM, keyPEM, passphrase []byte) (result tls.Certificate, err error) {
+	keyPEM, err = decryptKey(keyPEM, passphrase)
+	if err != nil {
+		return
+	}
+	result, err = tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		err = fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	return
+}
+
+// decryptKey returns the key with all the encrypted blocks replaced by their decrypted equivalent. Keys that aren't
+// encrypted are returned unchanged.
+func decryptKey(keyPEM, passphrase []byte) (result []byte, err error) {
+	buffer := &bytes.Buffer{}
+	rest := keyPEM
+	encrypted := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		var der []byte
+		switch {
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			if len(passphrase) == 0 {
+				err = errClientKeyPassphrase
+				return
+			}
+			der, err = decryptPKCS8(block.Bytes, passphrase)
+			if err != nil {
+				return
+			}
+			block = &pem.Block{
+				Type:  "PRIVATE KEY",
+				Bytes: der,
+			}
+			encrypted = true
+		case x509.IsEncryptedPEMBlock(block):
+			// This format is deprecated because it is insecure, but it is what older versions of OpenSSL
+			// generate, so we still accept it.
+			if len(passphrase) == 0 {
+				err = errClientKeyPassphrase
+				return
+			}
+			der, err = x509.DecryptPEMBlock(block, passphrase)
+			if err != nil {
+				err = fmt.Errorf("failed to decrypt client key: %w", err)
+				return
+			}
+			block = &pem.Block{
+				Type:  block.Type,
+				Bytes: der,
+			}
+			encrypted = true
+		}
+		err = pem.Encode(buffer, block)
+		if err != nil {
+			return
+		}
+	}
+	if !encrypted {
+		result = keyPEM
+		return
+	}
+	result = buffer.Bytes()
+	return
+}
+
+// decryptPKCS8 decrypts a PKCS#8 encrypted private key, as described in RFC 5958. Only the PBES2 scheme with the
+// PBKDF2 key derivation function and the AES-CBC ciphers is supported, as that is what current versions of OpenSSL
+// generate by default.
+func decryptPKCS8(der, passphrase []byte) (result []byte, err error) {
+	var info encryptedPrivateKeyInfo
+	_, err = asn1.Unmarshal(der, &info)
+	if err != nil {
+		err = fmt.Errorf("failed to parse encrypted client key: %w", err)
+		return
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		err = fmt.Errorf(
+			"encryption algorithm '%s' of client key isn't supported, only PBES2 is supported",
+			info.Algorithm.Algorithm,
+		)
+		return
+	}
+	var params pbes2Params
+	_, err = asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params)
+	if err != nil {
+		err = fmt.Errorf("failed to parse encryption parameters of client key: %w", err)
+		return
+	}
+
+	// Select the cipher:
+	var keyLength int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength = 32
+	default:
+		err = fmt.Errorf(
+			"cipher '%s' of client key isn't supported, only AES-CBC is supported",
+			params.EncryptionScheme.Algorithm,
+		)
+		return
+	}
+	var iv []byte
+	_, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv)
+	if err != nil {
+		err = fmt.Errorf("failed to parse initialization vector of client key: %w", err)
+		return
+	}
+	if len(iv) != aes.BlockSize {
+		err = fmt.Errorf("initialization vector of client key should have %d bytes but has %d", aes.BlockSize, len(iv))
+		return
+	}
+
+	// Derive the key:
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		err = fmt.Errorf(
+			"key derivation function '%s' of client key isn't supported, only PBKDF2 is supported",
+			params.KeyDerivationFunc.Algorithm,
+		)
+		return
+	}
+	var kdfParams pbkdf2Params
+	_, err = asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams)
+	if err != nil {
+		err = fmt.Errorf("failed to parse key derivation parameters of client key: %w", err)
+		return
+	}
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		err = fmt.Errorf(
+			"pseudo random function '%s' of client key isn't supported, only HMAC with SHA-1 or SHA-256 "+
+				"are supported",
+			kdfParams.PRF.Algorithm,
+		)
+		return
+	}
+	key, err := pbkdf2.Key(prf, string(passphrase), kdfParams.Salt, kdfParams.IterationCount, keyLength)
+	if err != nil {
+		err = fmt.Errorf("failed to derive key for client key: %w", err)
+		return
+	}
+
+	// Decrypt the data and remove the padding:
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		err = errClientKeyDecrypt
+		return
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		err = errClientKeyDecrypt
+		return
+	}
+	for _, b := range plain[len(plain)-padding:] {
+		if int(b) != padding {
+			err = errClientKeyDecrypt
+			return
+		}
+	}
+	result = plain[:len(plain)-padding]
+	return
+}
+
+// encryptedPrivateKeyInfo is the ASN.1 structure of PKCS#8 encrypted private keys.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params is the ASN.1 structure of the parameters of the PBES2 encryption scheme described in RFC 8018.
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params is the ASN.1 structure of the parameters of the PBKDF2 key derivation function described in RFC 8018.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var (
+	errClientKeyPassphrase = fmt.Errorf(
+		"client key is encrypted, set the passphrase in the '%s' environment variable",
+		ClientKeyPassphraseEnvVar,
+	)
+	errClientKeyDecrypt = errors.New("failed to decrypt client key, check that the passphrase is correct")
+)
@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package network
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+
+	. "github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Client certificate", func() {
+	var (
+		certs   *Certificates
+		certPEM []byte
+		keyDER  []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		certs = MakeCertificates(GinkgoT().TempDir())
+		certPEM, err = os.ReadFile(certs.ClientCertFile)
+		Expect(err).ToNot(HaveOccurred())
+		keyDER, err = x509.MarshalPKCS8PrivateKey(certs.ClientKey)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Loads key that isn't encrypted", func() {
+		keyPEM, err := os.ReadFile(certs.ClientKeyFile)
+		Expect(err).ToNot(HaveOccurred())
+		certificate, err := LoadClientCertificate(certPEM, keyPEM, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(certificate.Leaf.Subject.CommonName).To(Equal("client"))
+	})
+
+	It("Loads PKCS#8 encrypted key", func() {
+		keyPEM := encryptPKCS8(keyDER, "my-passphrase")
+		certificate, err := LoadClientCertificate(certPEM, keyPEM, []byte("my-passphrase"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(certificate.Leaf.Subject.CommonName).To(Equal("client"))
+	})
+
+	It("Loads legacy encrypted key", func() {
+		ecDER, err := x509.MarshalECPrivateKey(certs.ClientKey)
+		Expect(err).ToNot(HaveOccurred())
+		block, err := x509.EncryptPEMBlock(
+			rand.Reader, "EC PRIVATE KEY", ecDER, []byte("my-passphrase"), x509.PEMCipherAES256,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		keyPEM := pem.EncodeToMemory(block)
+		certificate, err := LoadClientCertificate(certPEM, keyPEM, []byte("my-passphrase"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(certificate.Leaf.Subject.CommonName).To(Equal("client"))
+	})
+
+	It("Fails if the key is encrypted and there is no passphrase", func() {
+		keyPEM := encryptPKCS8(keyDER, "my-passphrase")
+		_, err := LoadClientCertificate(certPEM, keyPEM, nil)
+		Expect(err).To(MatchError(ContainSubstring(ClientKeyPassphraseEnvVar)))
+	})
+
+	It("Fails if the passphrase is wrong", func() {
+		keyPEM := encryptPKCS8(keyDER, "my-passphrase")
+		_, err := LoadClientCertificate(certPEM, keyPEM, []byte("junk"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Fails if the key doesn't match the certificate", func() {
+		other := MakeCertificates(GinkgoT().TempDir())
+		keyPEM, err := os.ReadFile(other.ClientKeyFile)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = LoadClientCertificate(certPEM, keyPEM, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// encryptPKCS8 encrypts the given private key using PBES2 with PBKDF2, HMAC with SHA-256 and AES-256-CBC, which is
+// what OpenSSL does by default.
+func encryptPKCS8(der []byte, passphrase string) []byte {
+	salt := make([]byte, 8)
+	_, err := rand.Read(salt)
+	Expect(err).ToNot(HaveOccurred())
+	iv := make([]byte, aes.BlockSize)
+	_, err = rand.Read(iv)
+	Expect(err).ToNot(HaveOccurred())
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, 2048, 32)
+	Expect(err).ToNot(HaveOccurred())
+
+	// Encrypt the data, adding the padding:
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	plain := append([]byte{}, der...)
+	for range padding {
+		plain = append(plain, byte(padding))
+	}
+	block, err := aes.NewCipher(key)
+	Expect(err).ToNot(HaveOccurred())
+	data := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, plain)
+
+	// Encode the parameters:
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: 2048,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACWithSHA256,
+			Parameters: asn1.NullRawValue,
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	ivParams, err := asn1.Marshal(iv)
+	Expect(err).ToNot(HaveOccurred())
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBKDF2,
+			Parameters: asn1.RawValue{FullBytes: kdfParams},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  oidAES256CBC,
+			Parameters: asn1.RawValue{FullBytes: ivParams},
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	info, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBES2,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedData: data,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "ENCRYPTED PRIVATE KEY",
+		Bytes: info,
+	})
+}
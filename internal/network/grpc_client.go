@@ -0,0 +1,234 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/innabox/fulfillment-common/auth"
+	"github.com/innabox/fulfillment-common/logging"
+	"github.com/innabox/fulfillment-common/network"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	experimentalcredentials "google.golang.org/grpc/experimental/credentials"
+)
+
+// GrpcClientBuilder contains the data and logic needed to create a gRPC client. It is similar to the builder provided
+// by the common library, but it also supports presenting client certificates to the server. Don't create instances of
+// this object directly, use the NewGrpcClient function instead.
+type GrpcClientBuilder struct {
+	logger             *slog.Logger
+	flags              *pflag.FlagSet
+	network            string
+	address            string
+	plaintext          bool
+	insecure           bool
+	caPool             *x509.CertPool
+	certificates       []tls.Certificate
+	tokenSource        auth.TokenSource
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+}
+
+// NewGrpcClient creates a builder that can then used to configure and create a gRPC client.
+func NewGrpcClient() *GrpcClientBuilder {
+	return &GrpcClientBuilder{}
+}
+
+// SetLogger sets the logger that the client will use to send messages to the log. This is mandatory.
+func (b *GrpcClientBuilder) SetLogger(value *slog.Logger) *GrpcClientBuilder {
+	b.logger = value
+	return b
+}
+
+// SetFlags sets the command line flags that will be used to configure the logging of the requests and responses. This
+// is optional.
+func (b *GrpcClientBuilder) SetFlags(value *pflag.FlagSet) *GrpcClientBuilder {
+	b.flags = value
+	return b
+}
+
+// SetNetwork sets the server network. It can be 'tcp' or 'unix'. This is optional and the default is 'tcp'.
+func (b *GrpcClientBuilder) SetNetwork(value string) *GrpcClientBuilder {
+	b.network = value
+	return b
+}
+
+// SetAddress sets the server address. It can be a 'host:port' address or a gRPC target with the 'unix',
+// 'unix-abstract' or 'dns' schemes, as returned by the address parser. This is mandatory.
+func (b *GrpcClientBuilder) SetAddress(value string) *GrpcClientBuilder {
+	b.address = value
+	return b
+}
+
+// SetPlaintext when set to true configures the client for a server that doesn't use TLS. The default is false.
+func (b *GrpcClientBuilder) SetPlaintext(value bool) *GrpcClientBuilder {
+	b.plaintext = value
+	return b
+}
+
+// SetInsecure when set to true configures the client for use TLS but to not verify the certificate presented
+// by the server. This shouldn't be used in production environments. The default is false.
+func (b *GrpcClientBuilder) SetInsecure(value bool) *GrpcClientBuilder {
+	b.insecure = value
+	return b
+}
+
+// SetCaPool sets the certificate pool that contains the certificates of the certificate authorities that are trusted
+// when connecting using TLS. This is optional, and the default is to use trust the certificate authorities trusted by
+// the operating system.
+func (b *GrpcClientBuilder) SetCaPool(value *x509.CertPool) *GrpcClientBuilder {
+	b.caPool = value
+	return b
+}
+
+// AddClientCertificates adds certificates that the client will present to the server when it requests them during
+// the TLS handshake. This is optional, by default no client certificate is presented.
+func (b *GrpcClientBuilder) AddClientCertificates(values ...tls.Certificate) *GrpcClientBuilder {
+	b.certificates = append(b.certificates, values...)
+	return b
+}
+
+// SetTokenSource sets the token source that the client will use to authenticate to the server. This is optional, by
+// default no authentication credentials are sent.
+func (b *GrpcClientBuilder) SetTokenSource(value auth.TokenSource) *GrpcClientBuilder {
+	b.tokenSource = value
+	return b
+}
+
+// AddUnaryInterceptor adds a unary interceptor to the client.
+func (b *GrpcClientBuilder) AddUnaryInterceptor(value grpc.UnaryClientInterceptor) *GrpcClientBuilder {
+	b.unaryInterceptors = append(b.unaryInterceptors, value)
+	return b
+}
+
+// AddStreamInterceptor adds a stream interceptor to the client.
+func (b *GrpcClientBuilder) AddStreamInterceptor(value grpc.StreamClientInterceptor) *GrpcClientBuilder {
+	b.streamInterceptors = append(b.streamInterceptors, value)
+	return b
+}
+
+// Build uses the data stored in the builder to create a new gRPC client.
+func (b *GrpcClientBuilder) Build() (result *grpc.ClientConn, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.address == "" {
+		err = errors.New("server address is mandatory")
+		return
+	}
+
+	// Calculate the endpoint:
+	var endpoint string
+	switch b.network {
+	case "", "tcp":
+		if IsTarget(b.address) {
+			endpoint = b.address
+		} else {
+			endpoint = fmt.Sprintf("dns:///%s", b.address)
+		}
+	case "unix":
+		if filepath.IsAbs(b.address) {
+			endpoint = fmt.Sprintf("unix://%s", b.address)
+		} else {
+			endpoint = fmt.Sprintf("unix:%s", b.address)
+		}
+	default:
+		err = fmt.Errorf("unknown network '%s'", b.network)
+		return
+	}
+
+	// Set the transport credentials:
+	var options []grpc.DialOption
+	var transportCredentials credentials.TransportCredentials
+	if b.plaintext {
+		transportCredentials = insecure.NewCredentials()
+	} else {
+		caPool := b.caPool
+		if caPool == nil {
+			caPool, err = network.NewCertPool().
+				SetLogger(b.logger).
+				AddSystemFiles(true).
+				AddKubernetesFiles(true).
+				Build()
+			if err != nil {
+				err = fmt.Errorf("failed to build CA pool: %w", err)
+				return
+			}
+		}
+		tlsConfig := &tls.Config{
+			RootCAs:            caPool,
+			InsecureSkipVerify: b.insecure,
+			Certificates:       b.certificates,
+		}
+
+		// We use the experimental package because the OpenShift router doesn't seem to support ALPN, and the
+		// regular credentials package requires it since version 1.67 of gRPC. This is the same that the common
+		// library does. See here for details:
+		//
+		// https://github.com/grpc/grpc-go/issues/434
+		// https://github.com/grpc/grpc-go/pull/7980
+		transportCredentials = experimentalcredentials.NewTLSWithALPNDisabled(tlsConfig)
+	}
+	options = append(options, grpc.WithTransportCredentials(transportCredentials))
+
+	// Set the token credentials:
+	if b.tokenSource != nil {
+		var tokenCredentials credentials.PerRPCCredentials
+		tokenCredentials, err = auth.NewTokenCredentials().
+			SetLogger(b.logger).
+			SetSource(b.tokenSource).
+			Build()
+		if err != nil {
+			err = fmt.Errorf("failed to create token credentials: %w", err)
+			return
+		}
+		options = append(options, grpc.WithPerRPCCredentials(tokenCredentials))
+	}
+
+	// Add the logging interceptor after the interceptors configured by the user:
+	loggingInterceptor, err := logging.NewInterceptor().
+		SetLogger(b.logger).
+		SetFlags(b.flags).
+		Build()
+	if err != nil {
+		err = fmt.Errorf("failed to create logging interceptor: %w", err)
+		return
+	}
+	unaryInterceptors := append(b.unaryInterceptors, loggingInterceptor.UnaryClient)
+	streamInterceptors := append(b.streamInterceptors, loggingInterceptor.StreamClient)
+	options = append(
+		options,
+		grpc.WithChainUnaryInterceptor(unaryInterceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
+	)
+
+	// Record calls as OpenTelemetry spans, and propagate the trace context in the metadata. This does nothing if
+	// no tracer provider has been registered.
+	options = append(options, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+
+	// Create the client:
+	result, err = grpc.NewClient(endpoint, options...)
+	return
+}
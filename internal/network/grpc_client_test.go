@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+
+	. "github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("gRPC client", func() {
+	var (
+		ctx    context.Context
+		certs  *Certificates
+		server *Server
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		// Create a server that requires client certificates:
+		certs = MakeCertificates(GinkgoT().TempDir())
+		server = NewTLSServer(certs.ServerConfig())
+		DeferCleanup(server.Stop)
+		healthv1.RegisterHealthServer(server.Registrar(), health.NewServer())
+		server.Start()
+	})
+
+	It("Can't be created without a logger", func() {
+		_, err := NewGrpcClient().
+			SetAddress(server.Address()).
+			Build()
+		Expect(err).To(MatchError("logger is mandatory"))
+	})
+
+	It("Can't be created without an address", func() {
+		_, err := NewGrpcClient().
+			SetLogger(logger).
+			Build()
+		Expect(err).To(MatchError("server address is mandatory"))
+	})
+
+	It("Presents the client certificate", func() {
+		certificates, err := LoadClientCertificate(
+			readFile(certs.ClientCertFile),
+			readFile(certs.ClientKeyFile),
+			nil,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		conn, err := NewGrpcClient().
+			SetLogger(logger).
+			SetAddress(server.Address()).
+			SetCaPool(certs.CaPool).
+			AddClientCertificates(certificates).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		client := healthv1.NewHealthClient(conn)
+		response, err := client.Check(ctx, &healthv1.HealthCheckRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Status).To(Equal(healthv1.HealthCheckResponse_SERVING))
+	})
+
+	It("Fails if the server requires a client certificate and there is none", func() {
+		conn, err := NewGrpcClient().
+			SetLogger(logger).
+			SetAddress(server.Address()).
+			SetCaPool(certs.CaPool).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		client := healthv1.NewHealthClient(conn)
+		_, err = client.Check(ctx, &healthv1.HealthCheckRequest{})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("gRPC client with Unix sockets", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	// check starts a server listening in the given socket and then connects to it using the given address.
+	check := func(socket, address string) {
+		server := NewUnixServer(socket)
+		DeferCleanup(server.Stop)
+		healthv1.RegisterHealthServer(server.Registrar(), health.NewServer())
+		server.Start()
+		conn, err := NewGrpcClient().
+			SetLogger(logger).
+			SetAddress(address).
+			SetPlaintext(true).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		client := healthv1.NewHealthClient(conn)
+		response, err := client.Check(ctx, &healthv1.HealthCheckRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Status).To(Equal(healthv1.HealthCheckResponse_SERVING))
+	}
+
+	It("Connects to unix:/// target", func() {
+		socket := filepath.Join(GinkgoT().TempDir(), "server.sock")
+		check(socket, "unix://"+socket)
+	})
+
+	It("Connects to unix-abstract: target", func() {
+		if runtime.GOOS != "linux" {
+			Skip("Abstract Unix sockets are only supported in Linux")
+		}
+		name := fmt.Sprintf("fulfillment-cli-test-%d", os.Getpid())
+		check("@"+name, "unix-abstract:"+name)
+	})
+})
+
+func readFile(path string) []byte {
+	data, err := os.ReadFile(path)
+	Expect(err).ToNot(HaveOccurred())
+	return data
+}
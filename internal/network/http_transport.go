@@ -0,0 +1,36 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewHttpTransport creates an HTTP transport that trusts the given CA pool and presents the given client certificates
+// when the server requests them. If insecure is true the certificate presented by the server isn't verified. Requests
+// are recorded as OpenTelemetry spans, and the trace context is propagated in the request headers.
+func NewHttpTransport(caPool *x509.CertPool, insecure bool, certificates []tls.Certificate) http.RoundTripper {
+	return otelhttp.NewTransport(&http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{
+			RootCAs:            caPool,
+			InsecureSkipVerify: insecure,
+			Certificates:       certificates,
+		},
+	})
+}
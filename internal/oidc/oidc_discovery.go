@@ -0,0 +1,213 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/innabox/fulfillment-common/oauth"
+)
+
+// Metadata contains the subset of the metadata of an OAuth authorization server, as defined in RFC 8414 and in the
+// OpenID Connect Discovery 1.0 specification, that is used by the CLI.
+type Metadata struct {
+	Issuer                      string   `json:"issuer,omitempty"`
+	AuthorizationEndpoint       string   `json:"authorization_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint string   `json:"device_authorization_endpoint,omitempty"`
+	TokenEndpoint               string   `json:"token_endpoint,omitempty"`
+	RevocationEndpoint          string   `json:"revocation_endpoint,omitempty"`
+	GrantTypesSupported         []string `json:"grant_types_supported,omitempty"`
+	ScopesSupported             []string `json:"scopes_supported,omitempty"`
+}
+
+// Grant types used by the OAuth flows:
+const (
+	authorizationCodeGrantType = "authorization_code"
+	clientCredentialsGrantType = "client_credentials"
+	deviceCodeGrantType        = "urn:ietf:params:oauth:grant-type:device_code"
+	tokenExchangeGrantType     = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// SupportsFlow checks if the server supports the given flow. Servers that don't advertise the supported grant types
+// are assumed to support all the flows that they have endpoints for.
+func (m *Metadata) SupportsFlow(flow oauth.Flow) bool {
+	switch flow {
+	case oauth.CodeFlow:
+		return m.AuthorizationEndpoint != "" && m.supportsGrantType(authorizationCodeGrantType)
+	case oauth.DeviceFlow:
+		return m.DeviceAuthorizationEndpoint != "" && m.supportsGrantType(deviceCodeGrantType)
+	case oauth.CredentialsFlow:
+		return m.TokenEndpoint != "" && m.supportsGrantType(clientCredentialsGrantType)
+	default:
+		return false
+	}
+}
+
+// Flows returns the interactive flows supported by the server, in order of preference.
+func (m *Metadata) Flows() []oauth.Flow {
+	var result []oauth.Flow
+	for _, flow := range []oauth.Flow{oauth.DeviceFlow, oauth.CodeFlow} {
+		if m.SupportsFlow(flow) {
+			result = append(result, flow)
+		}
+	}
+	return result
+}
+
+// SupportsTokenExchange checks if the server supports the token exchange grant defined in RFC 8693.
+func (m *Metadata) SupportsTokenExchange() bool {
+	return m.TokenEndpoint != "" && m.supportsGrantType(tokenExchangeGrantType)
+}
+
+func (m *Metadata) supportsGrantType(grantType string) bool {
+	return len(m.GrantTypesSupported) == 0 || slices.Contains(m.GrantTypesSupported, grantType)
+}
+
+// DiscoveryClientBuilder contains the data and logic needed to create a discovery client. Don't create instances of
+// this type directly, use the NewDiscoveryClient function instead.
+type DiscoveryClientBuilder struct {
+	logger     *slog.Logger
+	httpClient *http.Client
+}
+
+// DiscoveryClient knows how to fetch the metadata of OAuth authorization servers. Don't create instances of this type
+// directly, use the NewDiscoveryClient function instead.
+type DiscoveryClient struct {
+	logger     *slog.Logger
+	httpClient *http.Client
+}
+
+// NewDiscoveryClient creates a builder that can then be used to configure and create a discovery client.
+func NewDiscoveryClient() *DiscoveryClientBuilder {
+	return &DiscoveryClientBuilder{}
+}
+
+// SetLogger sets the logger that the client will use to write to the log. This is mandatory.
+func (b *DiscoveryClientBuilder) SetLogger(value *slog.Logger) *DiscoveryClientBuilder {
+	b.logger = value
+	return b
+}
+
+// SetHttpClient sets the HTTP client that will be used to fetch the metadata. This is optional, by default the
+// default HTTP client of the Go library is used.
+func (b *DiscoveryClientBuilder) SetHttpClient(value *http.Client) *DiscoveryClientBuilder {
+	b.httpClient = value
+	return b
+}
+
+// Build uses the data stored in the builder to create a new discovery client.
+func (b *DiscoveryClientBuilder) Build() (result *DiscoveryClient, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+
+	// Set defaults:
+	httpClient := b.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// Create and populate the object:
+	result = &DiscoveryClient{
+		logger:     b.logger,
+		httpClient: httpClient,
+	}
+	return
+}
+
+// Discover fetches the metadata of the given issuer. It tries first the OpenID Connect discovery document, and then
+// the OAuth authorization server metadata document.
+func (c *DiscoveryClient) Discover(ctx context.Context, issuer string) (result *Metadata, err error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	var errs []error
+	for _, name := range []string{"openid-configuration", "oauth-authorization-server"} {
+		url := fmt.Sprintf("%s/.well-known/%s", issuer, name)
+		result, err = c.fetch(ctx, url)
+		if err == nil {
+			c.logger.DebugContext(
+				ctx,
+				"Discovered issuer metadata",
+				slog.String("issuer", issuer),
+				slog.String("url", url),
+				slog.Any("metadata", result),
+			)
+			return
+		}
+		c.logger.DebugContext(
+			ctx,
+			"Failed to fetch issuer metadata",
+			slog.String("issuer", issuer),
+			slog.String("url", url),
+			slog.Any("error", err),
+		)
+		errs = append(errs, err)
+	}
+	err = fmt.Errorf("failed to discover metadata of issuer '%s': %w", issuer, errors.Join(errs...))
+	return
+}
+
+func (c *DiscoveryClient) fetch(ctx context.Context, url string) (result *Metadata, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			c.logger.ErrorContext(
+				ctx,
+				"Failed to close response body",
+				slog.String("url", url),
+				slog.Any("error", err),
+			)
+		}
+	}()
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d from '%s'", response.StatusCode, url)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		err = fmt.Errorf("expected 'application/json' content type from '%s', but got '%s'", url, mediaType)
+		return
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return
+	}
+	metadata := &Metadata{}
+	err = json.Unmarshal(data, metadata)
+	if err != nil {
+		err = fmt.Errorf("failed to parse metadata from '%s': %w", url, err)
+		return
+	}
+	result = metadata
+	return
+}
@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/innabox/fulfillment-common/oauth"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Discovery", func() {
+	var (
+		ctx    context.Context
+		mux    *http.ServeMux
+		server *httptest.Server
+		client *DiscoveryClient
+	)
+
+	BeforeEach(func() {
+		var err error
+		ctx = context.Background()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+		client, err = NewDiscoveryClient().
+			SetLogger(logger).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	serve := func(path string, metadata map[string]any) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(metadata)
+		})
+	}
+
+	It("Can't be created without a logger", func() {
+		_, err := NewDiscoveryClient().Build()
+		Expect(err).To(MatchError("logger is mandatory"))
+	})
+
+	It("Uses the OpenID Connect discovery document", func() {
+		serve("/.well-known/openid-configuration", map[string]any{
+			"issuer":                        server.URL,
+			"authorization_endpoint":        server.URL + "/auth",
+			"device_authorization_endpoint": server.URL + "/device",
+			"token_endpoint":                server.URL + "/token",
+			"revocation_endpoint":           server.URL + "/revoke",
+			"grant_types_supported":         []string{"authorization_code"},
+		})
+		metadata, err := client.Discover(ctx, server.URL+"/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metadata.Issuer).To(Equal(server.URL))
+		Expect(metadata.RevocationEndpoint).To(Equal(server.URL + "/revoke"))
+		Expect(metadata.Flows()).To(Equal([]oauth.Flow{oauth.CodeFlow}))
+	})
+
+	It("Falls back to the OAuth metadata document", func() {
+		serve("/.well-known/oauth-authorization-server", map[string]any{
+			"issuer":         server.URL,
+			"token_endpoint": server.URL + "/token",
+		})
+		metadata, err := client.Discover(ctx, server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metadata.TokenEndpoint).To(Equal(server.URL + "/token"))
+	})
+
+	It("Fails if there is no metadata", func() {
+		_, err := client.Discover(ctx, server.URL)
+		Expect(err).To(MatchError(ContainSubstring("failed to discover metadata of issuer")))
+	})
+
+	DescribeTable(
+		"Supported flows",
+		func(metadata *Metadata, expected []oauth.Flow) {
+			Expect(metadata.Flows()).To(Equal(expected))
+		},
+		Entry(
+			"Device and code",
+			&Metadata{
+				AuthorizationEndpoint:       "https://example.com/auth",
+				DeviceAuthorizationEndpoint: "https://example.com/device",
+			},
+			[]oauth.Flow{oauth.DeviceFlow, oauth.CodeFlow},
+		),
+		Entry(
+			"Only code",
+			&Metadata{
+				AuthorizationEndpoint: "https://example.com/auth",
+			},
+			[]oauth.Flow{oauth.CodeFlow},
+		),
+		Entry(
+			"Device endpoint but grant type not supported",
+			&Metadata{
+				AuthorizationEndpoint:       "https://example.com/auth",
+				DeviceAuthorizationEndpoint: "https://example.com/device",
+				GrantTypesSupported:         []string{"authorization_code", "refresh_token"},
+			},
+			[]oauth.Flow{oauth.CodeFlow},
+		),
+		Entry(
+			"None",
+			&Metadata{
+				TokenEndpoint: "https://example.com/token",
+			},
+			nil,
+		),
+	)
+})
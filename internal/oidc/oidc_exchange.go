@@ -0,0 +1,221 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/innabox/fulfillment-common/auth"
+)
+
+// Token types defined in RFC 8693:
+const (
+	AccessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+	JwtTokenType    = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// ExchangerBuilder contains the data and logic needed to create a token exchanger. Don't create instances of this type
+// directly, use the NewExchanger function instead.
+type ExchangerBuilder struct {
+	logger       *slog.Logger
+	httpClient   *http.Client
+	endpoint     string
+	clientId     string
+	clientSecret string
+	scopes       []string
+}
+
+// Exchanger knows how to exchange tokens using the token exchange grant described in RFC 8693. Don't create instances
+// of this type directly, use the NewExchanger function instead.
+type Exchanger struct {
+	logger       *slog.Logger
+	httpClient   *http.Client
+	endpoint     string
+	clientId     string
+	clientSecret string
+	scopes       []string
+}
+
+// NewExchanger creates a builder that can then be used to configure and create a token exchanger.
+func NewExchanger() *ExchangerBuilder {
+	return &ExchangerBuilder{}
+}
+
+// SetLogger sets the logger that the exchanger will use to write to the log. This is mandatory.
+func (b *ExchangerBuilder) SetLogger(value *slog.Logger) *ExchangerBuilder {
+	b.logger = value
+	return b
+}
+
+// SetHttpClient sets the HTTP client that will be used to send the exchange requests. This is optional, by default
+// the default HTTP client of the Go library is used.
+func (b *ExchangerBuilder) SetHttpClient(value *http.Client) *ExchangerBuilder {
+	b.httpClient = value
+	return b
+}
+
+// SetEndpoint sets the URL of the token endpoint, usually obtained from the metadata of the issuer. This is mandatory.
+func (b *ExchangerBuilder) SetEndpoint(value string) *ExchangerBuilder {
+	b.endpoint = value
+	return b
+}
+
+// SetClientId sets the identifier of the client. This is optional, as some servers accept the subject token as the
+// only authentication.
+func (b *ExchangerBuilder) SetClientId(value string) *ExchangerBuilder {
+	b.clientId = value
+	return b
+}
+
+// SetClientSecret sets the secret of the client. This is optional, and only needed for confidential clients. When it
+// is set the client authenticates with the HTTP basic authentication scheme.
+func (b *ExchangerBuilder) SetClientSecret(value string) *ExchangerBuilder {
+	b.clientSecret = value
+	return b
+}
+
+// SetScopes sets the scopes that will be requested for the new token. This is optional.
+func (b *ExchangerBuilder) SetScopes(values ...string) *ExchangerBuilder {
+	b.scopes = values
+	return b
+}
+
+// Build uses the data stored in the builder to create a new token exchanger.
+func (b *ExchangerBuilder) Build() (result *Exchanger, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.endpoint == "" {
+		err = errors.New("endpoint is mandatory")
+		return
+	}
+	if b.clientSecret != "" && b.clientId == "" {
+		err = errors.New("client identifier is mandatory when the client secret is set")
+		return
+	}
+
+	// Set defaults:
+	httpClient := b.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// Create and populate the object:
+	result = &Exchanger{
+		logger:       b.logger,
+		httpClient:   httpClient,
+		endpoint:     b.endpoint,
+		clientId:     b.clientId,
+		clientSecret: b.clientSecret,
+		scopes:       b.scopes,
+	}
+	return
+}
+
+// Exchange sends the given subject token to the token endpoint and returns the access token issued in exchange. The
+// subject token type should be one of the token types defined in RFC 8693, like JwtTokenType.
+func (e *Exchanger) Exchange(ctx context.Context, subjectToken, subjectTokenType string) (result *auth.Token,
+	err error) {
+	// Prepare the form:
+	form := url.Values{}
+	form.Set("grant_type", tokenExchangeGrantType)
+	form.Set("subject_token", subjectToken)
+	form.Set("subject_token_type", subjectTokenType)
+	form.Set("requested_token_type", AccessTokenType)
+	if len(e.scopes) > 0 {
+		form.Set("scope", strings.Join(e.scopes, " "))
+	}
+	if e.clientId != "" && e.clientSecret == "" {
+		form.Set("client_id", e.clientId)
+	}
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		e.endpoint,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if e.clientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(e.clientId), url.QueryEscape(e.clientSecret))
+	}
+
+	// Send the request:
+	start := time.Now()
+	response, err := e.httpClient.Do(request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			e.logger.ErrorContext(
+				ctx,
+				"Failed to close response body",
+				slog.String("endpoint", e.endpoint),
+				slog.Any("error", err),
+			)
+		}
+	}()
+	if response.StatusCode != http.StatusOK {
+		err = responseError("token", e.endpoint, response)
+		return
+	}
+
+	// Parse the response, as described in section 2.2.1 of RFC 8693:
+	var body struct {
+		AccessToken     string `json:"access_token"`
+		IssuedTokenType string `json:"issued_token_type"`
+		TokenType       string `json:"token_type"`
+		ExpiresIn       int64  `json:"expires_in"`
+		RefreshToken    string `json:"refresh_token"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		err = fmt.Errorf("failed to decode response from token endpoint '%s': %w", e.endpoint, err)
+		return
+	}
+	if body.AccessToken == "" {
+		err = fmt.Errorf("response from token endpoint '%s' doesn't contain an access token", e.endpoint)
+		return
+	}
+	result = &auth.Token{
+		Access:  body.AccessToken,
+		Refresh: body.RefreshToken,
+	}
+	if body.ExpiresIn > 0 {
+		result.Expiry = start.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	e.logger.DebugContext(
+		ctx,
+		"Exchanged token",
+		slog.String("endpoint", e.endpoint),
+		slog.String("issued_token_type", body.IssuedTokenType),
+		slog.Time("expiry", result.Expiry),
+	)
+	return
+}
@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exchange", func() {
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		requests []*http.Request
+		status   int
+		body     string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		requests = nil
+		status = http.StatusOK
+		body = `{
+			"access_token": "my_access",
+			"issued_token_type": "urn:ietf:params:oauth:token-type:access_token",
+			"token_type": "Bearer",
+			"expires_in": 300
+		}`
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := r.ParseForm()
+			Expect(err).ToNot(HaveOccurred())
+			requests = append(requests, r)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("Can't be created without an endpoint", func() {
+		_, err := NewExchanger().
+			SetLogger(logger).
+			Build()
+		Expect(err).To(MatchError("endpoint is mandatory"))
+	})
+
+	It("Sends the subject token and returns the issued token", func() {
+		exchanger, err := NewExchanger().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			SetClientId("my_client").
+			SetScopes("openid", "fulfillment").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		before := time.Now()
+		token, err := exchanger.Exchange(ctx, "my_subject", JwtTokenType)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Access).To(Equal("my_access"))
+		Expect(token.Refresh).To(BeEmpty())
+		Expect(token.Expiry).To(BeTemporally(">=", before.Add(300*time.Second)))
+		Expect(token.Expiry).To(BeTemporally("<=", time.Now().Add(300*time.Second)))
+		Expect(requests).To(HaveLen(1))
+		form := requests[0].PostForm
+		Expect(form.Get("grant_type")).To(Equal("urn:ietf:params:oauth:grant-type:token-exchange"))
+		Expect(form.Get("subject_token")).To(Equal("my_subject"))
+		Expect(form.Get("subject_token_type")).To(Equal(JwtTokenType))
+		Expect(form.Get("requested_token_type")).To(Equal(AccessTokenType))
+		Expect(form.Get("scope")).To(Equal("openid fulfillment"))
+		Expect(form.Get("client_id")).To(Equal("my_client"))
+	})
+
+	It("Uses basic authentication for confidential clients", func() {
+		exchanger, err := NewExchanger().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			SetClientId("my_client").
+			SetClientSecret("my_secret").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = exchanger.Exchange(ctx, "my_subject", JwtTokenType)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(1))
+		user, password, ok := requests[0].BasicAuth()
+		Expect(ok).To(BeTrue())
+		Expect(user).To(Equal("my_client"))
+		Expect(password).To(Equal("my_secret"))
+		Expect(requests[0].PostForm.Has("client_id")).To(BeFalse())
+	})
+
+	It("Returns the error sent by the server", func() {
+		status = http.StatusBadRequest
+		body = `{
+			"error": "invalid_grant",
+			"error_description": "Subject token isn't trusted"
+		}`
+		exchanger, err := NewExchanger().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = exchanger.Exchange(ctx, "my_subject", JwtTokenType)
+		Expect(err).To(MatchError(ContainSubstring(
+			"responded with error 'invalid_grant': Subject token isn't trusted",
+		)))
+	})
+
+	It("Fails if the response doesn't contain an access token", func() {
+		body = `{}`
+		exchanger, err := NewExchanger().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = exchanger.Exchange(ctx, "my_subject", JwtTokenType)
+		Expect(err).To(MatchError(ContainSubstring("doesn't contain an access token")))
+	})
+})
@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Token type hints defined in RFC 7009:
+const (
+	AccessTokenHint  = "access_token"
+	RefreshTokenHint = "refresh_token"
+)
+
+// RevokerBuilder contains the data and logic needed to create a token revoker. Don't create instances of this type
+// directly, use the NewRevoker function instead.
+type RevokerBuilder struct {
+	logger       *slog.Logger
+	httpClient   *http.Client
+	endpoint     string
+	clientId     string
+	clientSecret string
+}
+
+// Revoker knows how to revoke tokens using the revocation endpoint described in RFC 7009. Don't create instances of
+// this type directly, use the NewRevoker function instead.
+type Revoker struct {
+	logger       *slog.Logger
+	httpClient   *http.Client
+	endpoint     string
+	clientId     string
+	clientSecret string
+}
+
+// NewRevoker creates a builder that can then be used to configure and create a token revoker.
+func NewRevoker() *RevokerBuilder {
+	return &RevokerBuilder{}
+}
+
+// SetLogger sets the logger that the revoker will use to write to the log. This is mandatory.
+func (b *RevokerBuilder) SetLogger(value *slog.Logger) *RevokerBuilder {
+	b.logger = value
+	return b
+}
+
+// SetHttpClient sets the HTTP client that will be used to send the revocation requests. This is optional, by default
+// the default HTTP client of the Go library is used.
+func (b *RevokerBuilder) SetHttpClient(value *http.Client) *RevokerBuilder {
+	b.httpClient = value
+	return b
+}
+
+// SetEndpoint sets the URL of the revocation endpoint, usually obtained from the metadata of the issuer. This is
+// mandatory.
+func (b *RevokerBuilder) SetEndpoint(value string) *RevokerBuilder {
+	b.endpoint = value
+	return b
+}
+
+// SetClientId sets the identifier of the client that the tokens were issued to. This is mandatory.
+func (b *RevokerBuilder) SetClientId(value string) *RevokerBuilder {
+	b.clientId = value
+	return b
+}
+
+// SetClientSecret sets the secret of the client. This is optional, and only needed for confidential clients. When it
+// is set the client authenticates with the HTTP basic authentication scheme.
+func (b *RevokerBuilder) SetClientSecret(value string) *RevokerBuilder {
+	b.clientSecret = value
+	return b
+}
+
+// Build uses the data stored in the builder to create a new token revoker.
+func (b *RevokerBuilder) Build() (result *Revoker, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.endpoint == "" {
+		err = errors.New("endpoint is mandatory")
+		return
+	}
+	if b.clientId == "" {
+		err = errors.New("client identifier is mandatory")
+		return
+	}
+
+	// Set defaults:
+	httpClient := b.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// Create and populate the object:
+	result = &Revoker{
+		logger:       b.logger,
+		httpClient:   httpClient,
+		endpoint:     b.endpoint,
+		clientId:     b.clientId,
+		clientSecret: b.clientSecret,
+	}
+	return
+}
+
+// Revoke asks the server to revoke the given token. The hint should be AccessTokenHint or RefreshTokenHint. Note that
+// according to RFC 7009 the server also responds with success when the token was already invalid.
+func (r *Revoker) Revoke(ctx context.Context, token, hint string) error {
+	// Prepare the form:
+	form := url.Values{}
+	form.Set("token", token)
+	if hint != "" {
+		form.Set("token_type_hint", hint)
+	}
+	if r.clientSecret == "" {
+		form.Set("client_id", r.clientId)
+	}
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		r.endpoint,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if r.clientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(r.clientId), url.QueryEscape(r.clientSecret))
+	}
+
+	// Send the request:
+	response, err := r.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			r.logger.ErrorContext(
+				ctx,
+				"Failed to close response body",
+				slog.String("endpoint", r.endpoint),
+				slog.Any("error", err),
+			)
+		}
+	}()
+	if response.StatusCode == http.StatusOK {
+		r.logger.DebugContext(
+			ctx,
+			"Revoked token",
+			slog.String("endpoint", r.endpoint),
+			slog.String("hint", hint),
+		)
+		return nil
+	}
+
+	return responseError("revocation", r.endpoint, response)
+}
+
+// responseError creates an error from a response that wasn't successful, extracting the error code and description
+// from the body as described in section 5.2 of RFC 6749 if possible.
+func responseError(kind, endpoint string, response *http.Response) error {
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	var body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	err = json.Unmarshal(data, &body)
+	if err != nil || body.Error == "" {
+		return fmt.Errorf("%s endpoint '%s' responded with status code %d", kind, endpoint, response.StatusCode)
+	}
+	if body.ErrorDescription != "" {
+		return fmt.Errorf(
+			"%s endpoint '%s' responded with error '%s': %s",
+			kind, endpoint, body.Error, body.ErrorDescription,
+		)
+	}
+	return fmt.Errorf("%s endpoint '%s' responded with error '%s'", kind, endpoint, body.Error)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Revocation", func() {
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		requests []*http.Request
+		status   int
+		body     string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		requests = nil
+		status = http.StatusOK
+		body = ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := r.ParseForm()
+			Expect(err).ToNot(HaveOccurred())
+			requests = append(requests, r)
+			if body != "" {
+				w.Header().Set("Content-Type", "application/json")
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("Can't be created without an endpoint", func() {
+		_, err := NewRevoker().
+			SetLogger(logger).
+			SetClientId("my_client").
+			Build()
+		Expect(err).To(MatchError("endpoint is mandatory"))
+	})
+
+	It("Sends the client identifier in the form for public clients", func() {
+		revoker, err := NewRevoker().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			SetClientId("my_client").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = revoker.Revoke(ctx, "my_token", RefreshTokenHint)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(1))
+		request := requests[0]
+		Expect(request.Method).To(Equal(http.MethodPost))
+		Expect(request.PostForm.Get("token")).To(Equal("my_token"))
+		Expect(request.PostForm.Get("token_type_hint")).To(Equal("refresh_token"))
+		Expect(request.PostForm.Get("client_id")).To(Equal("my_client"))
+		_, _, ok := request.BasicAuth()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Uses basic authentication for confidential clients", func() {
+		revoker, err := NewRevoker().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			SetClientId("my_client").
+			SetClientSecret("my_secret").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = revoker.Revoke(ctx, "my_token", AccessTokenHint)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(1))
+		user, password, ok := requests[0].BasicAuth()
+		Expect(ok).To(BeTrue())
+		Expect(user).To(Equal("my_client"))
+		Expect(password).To(Equal("my_secret"))
+		Expect(requests[0].PostForm.Has("client_id")).To(BeFalse())
+	})
+
+	It("Returns the error reported by the server", func() {
+		status = http.StatusBadRequest
+		body = `{"error": "unsupported_token_type", "error_description": "Not supported"}`
+		revoker, err := NewRevoker().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			SetClientId("my_client").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = revoker.Revoke(ctx, "my_token", AccessTokenHint)
+		Expect(err).To(MatchError(ContainSubstring("'unsupported_token_type': Not supported")))
+	})
+
+	It("Returns the status code if there is no error in the body", func() {
+		status = http.StatusServiceUnavailable
+		revoker, err := NewRevoker().
+			SetLogger(logger).
+			SetEndpoint(server.URL).
+			SetClientId("my_client").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = revoker.Revoke(ctx, "my_token", AccessTokenHint)
+		Expect(err).To(MatchError(ContainSubstring("status code 503")))
+	})
+})
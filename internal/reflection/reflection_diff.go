@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package reflection
+
+import (
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Diff compares two objects and returns the paths of the fields that are different, using the syntax of field masks,
+// for example `metadata.name`. Nested messages are compared field by field, except the well known types, like
+// timestamps, that are compared as a whole. Repeated and map fields are also compared as a whole. The result is sorted,
+// and it is empty if the objects are equal.
+func (h *ObjectHelper) Diff(before, after proto.Message) []string {
+	var result []string
+	diffMessages(before.ProtoReflect(), after.ProtoReflect(), "", &result)
+	slices.Sort(result)
+	return result
+}
+
+// diffMessages adds to the result the paths of the fields that are different in the two given messages, which must be
+// of the same type.
+func diffMessages(before, after protoreflect.Message, prefix string, result *[]string) {
+	fields := before.Descriptor().Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		beforeHas := before.Has(field)
+		afterHas := after.Has(field)
+		if !beforeHas && !afterHas {
+			continue
+		}
+		path := prefix + string(field.Name())
+		if beforeHas && afterHas && isNestedMessage(field) {
+			diffMessages(before.Get(field).Message(), after.Get(field).Message(), path+".", result)
+			continue
+		}
+		if !fieldsEqual(before, after, field) {
+			*result = append(*result, path)
+		}
+	}
+}
+
+// isNestedMessage checks if the given field is a singular message that should be compared field by field.
+func isNestedMessage(field protoreflect.FieldDescriptor) bool {
+	if field.Message() == nil || field.IsList() || field.IsMap() {
+		return false
+	}
+	return !strings.HasPrefix(string(field.Message().FullName()), "google.protobuf.")
+}
+
+// fieldsEqual checks if the given field has the same value in the two messages. To do so it copies the value of the
+// field to new empty messages and compares them, so that the rules of proto.Equal are used for all types of fields.
+func fieldsEqual(before, after protoreflect.Message, field protoreflect.FieldDescriptor) bool {
+	beforeCopy := before.New()
+	if before.Has(field) {
+		beforeCopy.Set(field, before.Get(field))
+	}
+	afterCopy := after.New()
+	if after.Has(field) {
+		afterCopy.Set(field, after.Get(field))
+	}
+	return proto.Equal(beforeCopy.Interface(), afterCopy.Interface())
+}
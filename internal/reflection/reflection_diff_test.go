@@ -0,0 +1,179 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package reflection
+
+import (
+	"context"
+	"time"
+
+	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	sharedv1 "github.com/innabox/fulfillment-common/api/shared/v1"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Diff and update masks", func() {
+	var (
+		ctx          context.Context
+		objectHelper *ObjectHelper
+		requests     []*ffv1.ClustersUpdateRequest
+		original     *ffv1.Cluster
+	)
+
+	BeforeEach(func() {
+		// Create a context:
+		ctx = context.Background()
+
+		// Prepare the original cluster:
+		original = ffv1.Cluster_builder{
+			Id: "123",
+			Metadata: sharedv1.Metadata_builder{
+				Name:              "my-cluster",
+				CreationTimestamp: timestamppb.New(time.Unix(1000, 0)),
+			}.Build(),
+			Spec: ffv1.ClusterSpec_builder{
+				Template: "my-template",
+				NodeSets: map[string]*ffv1.ClusterNodeSet{
+					"xyz": ffv1.ClusterNodeSet_builder{
+						HostClass: "acme_1tib",
+						Size:      3,
+					}.Build(),
+				},
+			}.Build(),
+		}.Build()
+		requests = nil
+
+		// Create a server that records the update requests and returns the object unchanged:
+		server := testing.NewServer()
+		DeferCleanup(server.Stop)
+		ffv1.RegisterClustersServer(server.Registrar(), &testing.ClustersServerFuncs{
+			UpdateFunc: func(ctx context.Context,
+				request *ffv1.ClustersUpdateRequest) (*ffv1.ClustersUpdateResponse, error) {
+				requests = append(requests, request)
+				return ffv1.ClustersUpdateResponse_builder{
+					Object: request.GetObject(),
+				}.Build(), nil
+			},
+		})
+		server.Start()
+
+		// Create the client connection:
+		connection, err := grpc.NewClient(
+			server.Address(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(connection.Close)
+
+		// Create the helper:
+		helper, err := NewHelper().
+			SetLogger(logger).
+			SetConnection(connection).
+			AddPackage("fulfillment.v1", 1).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		objectHelper = helper.Lookup("cluster")
+		Expect(objectHelper).ToNot(BeNil())
+	})
+
+	// edit returns a copy of the original cluster modified by the given function.
+	edit := func(change func(cluster *ffv1.Cluster)) *ffv1.Cluster {
+		result := proto.Clone(original).(*ffv1.Cluster)
+		change(result)
+		return result
+	}
+
+	Describe("Diff", func() {
+		It("Returns nothing if there are no changes", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {})
+			Expect(objectHelper.Diff(original, edited)).To(BeEmpty())
+		})
+
+		It("Returns the path of a changed nested field", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {
+				cluster.GetMetadata().SetName("your-cluster")
+			})
+			Expect(objectHelper.Diff(original, edited)).To(Equal([]string{"metadata.name"}))
+		})
+
+		It("Returns the path of a removed nested field", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {
+				cluster.GetSpec().SetTemplate("")
+			})
+			Expect(objectHelper.Diff(original, edited)).To(Equal([]string{"spec.template"}))
+		})
+
+		It("Returns the path of a message that has been added", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {
+				cluster.SetStatus(ffv1.ClusterStatus_builder{
+					ApiUrl: "https://api.example.com",
+				}.Build())
+			})
+			Expect(objectHelper.Diff(original, edited)).To(Equal([]string{"status"}))
+		})
+
+		It("Compares well known types as a whole", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {
+				cluster.GetMetadata().SetCreationTimestamp(timestamppb.New(time.Unix(2000, 0)))
+			})
+			Expect(objectHelper.Diff(original, edited)).To(Equal([]string{"metadata.creation_timestamp"}))
+		})
+
+		It("Compares maps as a whole", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {
+				cluster.GetSpec().GetNodeSets()["xyz"].SetSize(5)
+			})
+			Expect(objectHelper.Diff(original, edited)).To(Equal([]string{"spec.node_sets"}))
+		})
+
+		It("Returns multiple paths sorted", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {
+				cluster.GetSpec().SetTemplate("your-template")
+				cluster.GetMetadata().SetName("your-cluster")
+			})
+			Expect(objectHelper.Diff(original, edited)).To(Equal([]string{
+				"metadata.name",
+				"spec.template",
+			}))
+		})
+	})
+
+	Describe("Update", func() {
+		It("Sends the paths in the update mask", func() {
+			edited := edit(func(cluster *ffv1.Cluster) {
+				cluster.GetMetadata().SetName("your-cluster")
+			})
+			_, err := objectHelper.Update(ctx, edited, objectHelper.Diff(original, edited)...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests).To(HaveLen(1))
+			request := requests[0]
+			Expect(request.HasUpdateMask()).To(BeTrue())
+			Expect(request.GetUpdateMask().GetPaths()).To(Equal([]string{"metadata.name"}))
+			Expect(proto.Equal(request.GetObject(), edited)).To(BeTrue())
+		})
+
+		It("Doesn't send the update mask if there are no paths", func() {
+			_, err := objectHelper.Update(ctx, original)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].HasUpdateMask()).To(BeFalse())
+		})
+	})
+})
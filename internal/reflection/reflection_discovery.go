@@ -0,0 +1,422 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package reflection
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/innabox/fulfillment-cli/internal/files"
+)
+
+// DefaultCacheTtl is the default time that the descriptors obtained from the server are kept in the cache.
+const DefaultCacheTtl = time.Hour
+
+// cacheSubdir is the subdirectory of the cache directory where the descriptors are saved.
+const cacheSubdir = "descriptors"
+
+// Discover obtains the file descriptors from the reflection service of the server, so that the object types that
+// aren't compiled into the binary can also be used. The descriptors are saved to the cache directory, and they are
+// loaded from there instead of from the server till the cache TTL expires. If the server doesn't support reflection,
+// or the descriptors can't be obtained or built for any other reason, a warning is written to the log and only the
+// types compiled into the binary will be available. The only error returned is the one of the context.
+//
+// This needs to be called before any other method of the helper.
+func (h *Helper) Discover(ctx context.Context) error {
+	// Try first to load the descriptors from the cache, and if that fails get them from the server:
+	set, err := h.loadCache()
+	if err != nil {
+		h.logger.DebugContext(
+			ctx,
+			"Failed to load descriptors from cache",
+			slog.Any("error", err),
+		)
+	}
+	var files *protoregistry.Files
+	if set != nil {
+		files, err = h.buildFiles(set)
+		if err != nil {
+			h.logger.WarnContext(
+				ctx,
+				"Failed to build cached descriptors, will get them again from the server",
+				slog.String("file", h.cacheFile()),
+				slog.Any("error", err),
+			)
+			h.removeCache(ctx)
+			set = nil
+		}
+	}
+	if set == nil {
+		set, err = h.fetchFiles(ctx)
+		if status.Code(err) == codes.Unimplemented {
+			h.logger.DebugContext(
+				ctx,
+				"Server doesn't support reflection, will use only the types compiled into the binary",
+				slog.String("address", h.address),
+			)
+			return nil
+		}
+		if err != nil {
+			return h.fallback(ctx, "Failed to get descriptors from server", err)
+		}
+		files, err = h.buildFiles(set)
+		if err != nil {
+			return h.fallback(ctx, "Failed to build descriptors obtained from server", err)
+		}
+		err = h.saveCache(set)
+		if err != nil {
+			h.logger.WarnContext(
+				ctx,
+				"Failed to save descriptors to cache",
+				slog.Any("error", err),
+			)
+		}
+	}
+	h.logger.DebugContext(
+		ctx,
+		"Discovered descriptors",
+		slog.String("address", h.address),
+		slog.Int("files", files.NumFiles()),
+	)
+	h.files = files
+	h.types = dynamicpb.NewTypes(files)
+	return nil
+}
+
+// fallback is called when discovery fails. It writes a warning to the log and returns nil, so that the types compiled
+// into the binary are used, unless the context has been cancelled.
+func (h *Helper) fallback(ctx context.Context, msg string, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	h.logger.WarnContext(
+		ctx,
+		msg+", will use only the types compiled into the binary",
+		slog.String("address", h.address),
+		slog.Any("error", err),
+	)
+	return nil
+}
+
+// fetchFiles gets from the reflection service of the server the descriptors of the files that define the services,
+// including their dependencies.
+func (h *Helper) fetchFiles(ctx context.Context) (result *descriptorpb.FileDescriptorSet, err error) {
+	client := reflectionv1.NewServerReflectionClient(h.connection)
+	stream, err := client.ServerReflectionInfo(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		closeErr := stream.CloseSend()
+		if closeErr != nil {
+			h.logger.DebugContext(
+				ctx,
+				"Failed to close reflection stream",
+				slog.Any("error", closeErr),
+			)
+		}
+	}()
+	send := func(request *reflectionv1.ServerReflectionRequest) (response *reflectionv1.ServerReflectionResponse,
+		err error) {
+		err = stream.Send(request)
+		if err != nil {
+			return
+		}
+		response, err = stream.Recv()
+		if err != nil {
+			return
+		}
+		errorResponse := response.GetErrorResponse()
+		if errorResponse != nil {
+			err = status.Error(codes.Code(errorResponse.GetErrorCode()), errorResponse.GetErrorMessage())
+		}
+		return
+	}
+
+	// Get the list of services:
+	response, err := send(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return
+	}
+	services := response.GetListServicesResponse().GetService()
+
+	// Get the files that contain the services. The server usually sends the dependencies as well, but that
+	// isn't guaranteed, so we request explicitly those that are missing.
+	files := map[string]*descriptorpb.FileDescriptorProto{}
+	order := []string{}
+	add := func(response *reflectionv1.ServerReflectionResponse) error {
+		for _, data := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			err := proto.Unmarshal(data, file)
+			if err != nil {
+				return err
+			}
+			if _, ok := files[file.GetName()]; !ok {
+				files[file.GetName()] = file
+				order = append(order, file.GetName())
+			}
+		}
+		return nil
+	}
+	for _, service := range services {
+		response, err = send(&reflectionv1.ServerReflectionRequest{
+			MessageRequest: &reflectionv1.ServerReflectionRequest_FileContainingSymbol{
+				FileContainingSymbol: service.GetName(),
+			},
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to get file for service '%s': %w", service.GetName(), err)
+			return
+		}
+		err = add(response)
+		if err != nil {
+			return
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, dependency := range files[order[i]].GetDependency() {
+			if _, ok := files[dependency]; ok {
+				continue
+			}
+			response, err = send(&reflectionv1.ServerReflectionRequest{
+				MessageRequest: &reflectionv1.ServerReflectionRequest_FileByFilename{
+					FileByFilename: dependency,
+				},
+			})
+			if err != nil {
+				err = fmt.Errorf("failed to get file '%s': %w", dependency, err)
+				return
+			}
+			err = add(response)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	// Return the files in the order that they were received:
+	result = &descriptorpb.FileDescriptorSet{
+		File: make([]*descriptorpb.FileDescriptorProto, len(order)),
+	}
+	for i, name := range order {
+		result.File[i] = files[name]
+	}
+	return
+}
+
+// buildFiles creates the registry of files from the given set of descriptors. Files that are compiled into the binary
+// are taken from the global registry instead of being built again, so that the generated types are used for them and
+// for the types of other files that reference them.
+func (h *Helper) buildFiles(set *descriptorpb.FileDescriptorSet) (result *protoregistry.Files, err error) {
+	protos := make(map[string]*descriptorpb.FileDescriptorProto, len(set.GetFile()))
+	for _, file := range set.GetFile() {
+		protos[file.GetName()] = file
+	}
+	files := &protoregistry.Files{}
+	var build func(path string, stack []string) error
+	build = func(path string, stack []string) error {
+		_, err := files.FindFileByPath(path)
+		if err == nil {
+			return nil
+		}
+		for _, item := range stack {
+			if item == path {
+				return fmt.Errorf("file '%s' depends on itself", path)
+			}
+		}
+		var desc protoreflect.FileDescriptor
+		desc, err = protoregistry.GlobalFiles.FindFileByPath(path)
+		if err != nil {
+			file, ok := protos[path]
+			if !ok {
+				return fmt.Errorf("file '%s' isn't available", path)
+			}
+			for _, dependency := range file.GetDependency() {
+				err = build(dependency, append(stack, path))
+				if err != nil {
+					return err
+				}
+			}
+			desc, err = protodesc.NewFile(file, files)
+			if err != nil {
+				return err
+			}
+		}
+		return files.RegisterFile(desc)
+	}
+	for _, file := range set.GetFile() {
+		err = build(file.GetName(), nil)
+		if err != nil {
+			return
+		}
+	}
+	result = files
+	return
+}
+
+// cacheFile returns the name of the file where the descriptors for the server are cached, or an empty string if there
+// is no cache directory.
+func (h *Helper) cacheFile() string {
+	if h.cacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(h.address))
+	return filepath.Join(h.cacheDir, cacheSubdir, hex.EncodeToString(sum[:])+".binpb")
+}
+
+// loadCache loads the descriptors from the cache. Returns nil if there is no cache or it has expired.
+func (h *Helper) loadCache() (result *descriptorpb.FileDescriptorSet, err error) {
+	file := h.cacheFile()
+	if file == "" {
+		return
+	}
+	info, err := os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	if time.Since(info.ModTime()) > h.cacheTtl {
+		h.logger.Debug(
+			"Cached descriptors have expired",
+			slog.String("file", file),
+			slog.Time("time", info.ModTime()),
+			slog.Duration("ttl", h.cacheTtl),
+		)
+		return
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	err = proto.Unmarshal(data, set)
+	if err != nil {
+		return
+	}
+	h.logger.Debug(
+		"Loaded descriptors from cache",
+		slog.String("file", file),
+	)
+	result = set
+	return
+}
+
+// saveCache saves the descriptors to the cache, if there is a cache directory.
+func (h *Helper) saveCache(set *descriptorpb.FileDescriptorSet) error {
+	file := h.cacheFile()
+	if file == "" {
+		return nil
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return files.WriteAtomic(file, data, 0600)
+}
+
+// removeCache removes the cache file, if there is a cache directory.
+func (h *Helper) removeCache(ctx context.Context) {
+	file := h.cacheFile()
+	if file == "" {
+		return
+	}
+	err := os.Remove(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		h.logger.WarnContext(
+			ctx,
+			"Failed to remove cached descriptors",
+			slog.String("file", file),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// Resolver returns the resolver that should be used to find the types of messages, for example when unmarshalling
+// protocol buffers any objects. It finds the types compiled into the binary and, when the Discover method has been
+// called, also the types obtained from the server.
+func (h *Helper) Resolver() Resolver {
+	if h.types == nil {
+		return protoregistry.GlobalTypes
+	}
+	return &typesResolver{
+		types: h.types,
+	}
+}
+
+// Resolver is the interface of the objects that find message and extension types, as needed by the protojson and
+// proto unmarshalling options.
+type Resolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// typesResolver looks up types first in the global registry and then in the types obtained from the server.
+type typesResolver struct {
+	types *dynamicpb.Types
+}
+
+func (r *typesResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	result, err := protoregistry.GlobalTypes.FindMessageByName(name)
+	if errors.Is(err, protoregistry.NotFound) {
+		result, err = r.types.FindMessageByName(name)
+	}
+	return result, err
+}
+
+func (r *typesResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	result, err := protoregistry.GlobalTypes.FindMessageByURL(url)
+	if errors.Is(err, protoregistry.NotFound) {
+		result, err = r.types.FindMessageByURL(url)
+	}
+	return result, err
+}
+
+func (r *typesResolver) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	result, err := protoregistry.GlobalTypes.FindExtensionByName(name)
+	if errors.Is(err, protoregistry.NotFound) {
+		result, err = r.types.FindExtensionByName(name)
+	}
+	return result, err
+}
+
+func (r *typesResolver) FindExtensionByNumber(message protoreflect.FullName,
+	number protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	result, err := protoregistry.GlobalTypes.FindExtensionByNumber(message, number)
+	if errors.Is(err, protoregistry.NotFound) {
+		result, err = r.types.FindExtensionByNumber(message, number)
+	}
+	return result, err
+}
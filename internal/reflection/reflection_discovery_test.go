@@ -0,0 +1,693 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package reflection
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcreflection "google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Discovery", func() {
+	var (
+		ctx        context.Context
+		server     *testing.Server
+		connection *grpc.ClientConn
+		widgetFile protoreflect.FileDescriptor
+		widgetDesc protoreflect.MessageDescriptor
+		gadgetFile protoreflect.FileDescriptor
+		gadgetDesc protoreflect.MessageDescriptor
+		cacheDir   string
+		fetches    *atomic.Int32
+	)
+
+	// makeObject creates an object of the given type with the given identifier, name and color:
+	makeObject := func(desc protoreflect.MessageDescriptor, id, name, color string) *dynamicpb.Message {
+		object := dynamicpb.NewMessage(desc)
+		err := protojson.Unmarshal(
+			[]byte(`{"id":"`+id+`","metadata":{"name":"`+name+`"},"color":"`+color+`"}`),
+			object,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		return object
+	}
+
+	// makeHandler creates a handler for a method of the service of the given file that decodes the request into a
+	// dynamic message of the input type of the method and then calls the given function to calculate the response.
+	makeHandler := func(file protoreflect.FileDescriptor, name string,
+		function func(request, response *dynamicpb.Message)) grpc.MethodDesc {
+		methodDesc := file.Services().Get(0).Methods().ByName(protoreflect.Name(name))
+		return grpc.MethodDesc{
+			MethodName: name,
+			Handler: func(srv any, ctx context.Context, dec func(any) error,
+				interceptor grpc.UnaryServerInterceptor) (any, error) {
+				request := dynamicpb.NewMessage(methodDesc.Input())
+				err := dec(request)
+				if err != nil {
+					return nil, err
+				}
+				response := dynamicpb.NewMessage(methodDesc.Output())
+				function(request, response)
+				return response, nil
+			},
+		}
+	}
+
+	// field returns the descriptor of the field with the given name:
+	field := func(message *dynamicpb.Message, name string) protoreflect.FieldDescriptor {
+		return message.Descriptor().Fields().ByName(protoreflect.Name(name))
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		// Create a context:
+		ctx = context.Background()
+
+		// Create a temporary directory for the cache:
+		cacheDir, err = os.MkdirTemp("", "*.cache")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			err := os.RemoveAll(cacheDir)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		// Create the descriptors of files containing object types that aren't compiled into the binary. The
+		// widgets service supports all the methods, and the gadgets service is a read only catalogue.
+		widgetFile, err = protodesc.NewFile(
+			makeObjectFile("Widget", "List", "Get", "Create", "Update", "Delete"),
+			protoregistry.GlobalFiles,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		widgetDesc = widgetFile.Messages().ByName("Widget")
+		gadgetFile, err = protodesc.NewFile(
+			makeObjectFile("Gadget", "List", "Get"),
+			protoregistry.GlobalFiles,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		gadgetDesc = gadgetFile.Messages().ByName("Gadget")
+
+		// Create the server, with an implementation of the widgets service that works with dynamic messages, and
+		// with a reflection service that knows the widget file in addition to the files compiled into the binary:
+		server = testing.NewServer()
+		DeferCleanup(server.Stop)
+		server.Registrar().RegisterService(
+			&grpc.ServiceDesc{
+				ServiceName: "fulfillment.v1.Widgets",
+				HandlerType: (*any)(nil),
+				Methods: []grpc.MethodDesc{
+					makeHandler(widgetFile, "List", func(request, response *dynamicpb.Message) {
+						items := response.Mutable(field(response, "items")).List()
+						items.Append(protoreflect.ValueOfMessage(makeObject(widgetDesc, "123", "my", "red")))
+						response.Set(field(response, "total"), protoreflect.ValueOfInt32(1))
+					}),
+					makeHandler(widgetFile, "Get", func(request, response *dynamicpb.Message) {
+						id := request.Get(field(request, "id")).String()
+						response.Set(
+							field(response, "object"),
+							protoreflect.ValueOfMessage(makeObject(widgetDesc, id, "my", "red")),
+						)
+					}),
+					makeHandler(widgetFile, "Create", func(request, response *dynamicpb.Message) {
+						object := request.Get(field(request, "object")).Message()
+						object.Set(widgetDesc.Fields().ByName("id"), protoreflect.ValueOfString("456"))
+						response.Set(field(response, "object"), protoreflect.ValueOfMessage(object))
+					}),
+					makeHandler(widgetFile, "Update", func(request, response *dynamicpb.Message) {
+						object := request.Get(field(request, "object")).Message()
+						response.Set(field(response, "object"), protoreflect.ValueOfMessage(object))
+					}),
+					makeHandler(widgetFile, "Delete", func(request, response *dynamicpb.Message) {
+					}),
+				},
+			},
+			nil,
+		)
+		server.Registrar().RegisterService(
+			&grpc.ServiceDesc{
+				ServiceName: "fulfillment.v1.Gadgets",
+				HandlerType: (*any)(nil),
+				Methods: []grpc.MethodDesc{
+					makeHandler(gadgetFile, "List", func(request, response *dynamicpb.Message) {
+						items := response.Mutable(field(response, "items")).List()
+						items.Append(protoreflect.ValueOfMessage(makeObject(gadgetDesc, "789", "my", "blue")))
+						response.Set(field(response, "total"), protoreflect.ValueOfInt32(1))
+					}),
+					makeHandler(gadgetFile, "Get", func(request, response *dynamicpb.Message) {
+						id := request.Get(field(request, "id")).String()
+						response.Set(
+							field(response, "object"),
+							protoreflect.ValueOfMessage(makeObject(gadgetDesc, id, "my", "blue")),
+						)
+					}),
+				},
+			},
+			nil,
+		)
+		fetches = &atomic.Int32{}
+		reflectionv1.RegisterServerReflectionServer(
+			server.Registrar(),
+			&countingReflectionServer{
+				ServerReflectionServer: grpcreflection.NewServerV1(grpcreflection.ServerOptions{
+					Services: server.Registrar().(grpcreflection.ServiceInfoProvider),
+					DescriptorResolver: &fileResolver{
+						files: []protoreflect.FileDescriptor{
+							widgetFile,
+							gadgetFile,
+						},
+					},
+				}),
+				count: fetches,
+			},
+		)
+		server.Start()
+
+		// Create the client connection:
+		connection, err = grpc.NewClient(
+			server.Address(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(connection.Close)
+	})
+
+	// makeHelper creates a helper that uses the cache directory and the given TTL:
+	makeHelper := func(ttl time.Duration) *Helper {
+		helper, err := NewHelper().
+			SetLogger(logger).
+			SetConnection(connection).
+			AddPackage("fulfillment.v1", 1).
+			SetAddress(server.Address()).
+			SetCacheDir(cacheDir).
+			SetCacheTtl(ttl).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		return helper
+	}
+
+	It("Can't be created with a negative cache TTL", func() {
+		helper, err := NewHelper().
+			SetLogger(logger).
+			SetConnection(connection).
+			AddPackage("fulfillment.v1", 1).
+			SetCacheTtl(-time.Minute).
+			Build()
+		Expect(err).To(MatchError("cache TTL should be positive, but it is -1m0s"))
+		Expect(helper).To(BeNil())
+	})
+
+	It("Doesn't know types that aren't compiled into the binary if discovery isn't used", func() {
+		helper := makeHelper(0)
+		Expect(helper.Lookup("widget")).To(BeNil())
+		Expect(helper.Singulars()).ToNot(ContainElement("widget"))
+	})
+
+	It("Finds types that aren't compiled into the binary", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(helper.Singulars()).To(ContainElements("cluster", "host", "widget"))
+		Expect(helper.Plurals()).To(ContainElements("clusters", "hosts", "widgets"))
+		Expect(helper.Names()).To(ContainElement("fulfillment.v1.Widget"))
+	})
+
+	It("Keeps using generated types for the types compiled into the binary", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		objectHelper := helper.Lookup("cluster")
+		Expect(objectHelper).ToNot(BeNil())
+		Expect(objectHelper.Instance()).ToNot(BeAssignableToTypeOf(&dynamicpb.Message{}))
+	})
+
+	It("Invokes the methods of types that aren't compiled into the binary", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		objectHelper := helper.Lookup("widgets")
+		Expect(objectHelper).ToNot(BeNil())
+		Expect(objectHelper.Singular()).To(Equal("widget"))
+
+		// List:
+		listResult, err := objectHelper.List(ctx, ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(listResult.Total).To(BeNumerically("==", 1))
+		Expect(listResult.Items).To(HaveLen(1))
+		Expect(objectHelper.GetId(listResult.Items[0])).To(Equal("123"))
+		Expect(objectHelper.GetName(listResult.Items[0])).To(Equal("my"))
+
+		// Get:
+		object, err := objectHelper.Get(ctx, "123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objectHelper.GetId(object)).To(Equal("123"))
+		Expect(objectHelper.GetName(object)).To(Equal("my"))
+
+		// Create:
+		object = objectHelper.Instance()
+		err = protojson.Unmarshal([]byte(`{"metadata":{"name":"your"},"color":"blue"}`), object)
+		Expect(err).ToNot(HaveOccurred())
+		object, err = objectHelper.Create(ctx, object)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objectHelper.GetId(object)).To(Equal("456"))
+		Expect(objectHelper.GetName(object)).To(Equal("your"))
+
+		// Update:
+		object, err = objectHelper.Update(ctx, object)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objectHelper.GetId(object)).To(Equal("456"))
+
+		// Delete:
+		err = objectHelper.Delete(ctx, "456")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Finds object types that support only some of the methods", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The gadgets service only supports listing and getting:
+		gadgetHelper := helper.Lookup("gadgets")
+		Expect(gadgetHelper).ToNot(BeNil())
+		Expect(gadgetHelper.CanList()).To(BeTrue())
+		Expect(gadgetHelper.CanGet()).To(BeTrue())
+		Expect(gadgetHelper.CanCreate()).To(BeFalse())
+		Expect(gadgetHelper.CanUpdate()).To(BeFalse())
+		Expect(gadgetHelper.CanDelete()).To(BeFalse())
+		Expect(gadgetHelper.Verbs()).To(Equal([]string{"get"}))
+
+		// The widgets service supports all the methods:
+		widgetHelper := helper.Lookup("widgets")
+		Expect(widgetHelper).ToNot(BeNil())
+		Expect(widgetHelper.CanList()).To(BeTrue())
+		Expect(widgetHelper.CanGet()).To(BeTrue())
+		Expect(widgetHelper.CanCreate()).To(BeTrue())
+		Expect(widgetHelper.CanUpdate()).To(BeTrue())
+		Expect(widgetHelper.CanDelete()).To(BeTrue())
+		Expect(widgetHelper.Verbs()).To(Equal([]string{"get", "create", "edit", "delete"}))
+	})
+
+	It("Ignores object types that don't have metadata", func() {
+		// Create a file with an object type that has all the fields except the metadata:
+		fileProto := makeObjectFile("Gizmo", "List", "Get")
+		objectProto := fileProto.MessageType[0]
+		objectProto.Field = slices.DeleteFunc(objectProto.Field, func(field *descriptorpb.FieldDescriptorProto) bool {
+			return field.GetName() == "metadata"
+		})
+		gizmoFile, err := protodesc.NewFile(fileProto, protoregistry.GlobalFiles)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Check that the type is ignored, instead of failing later when the metadata is used:
+		helper := makeHelper(0)
+		helper.scanFile(gizmoFile)
+		Expect(helper.Lookup("gizmo")).To(BeNil())
+		Expect(helper.Names()).ToNot(ContainElement("fulfillment.v1.Gizmo"))
+	})
+
+	It("Returns the object helpers in the same order than the names", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		objects := helper.Objects()
+		names := make([]string, len(objects))
+		for i, object := range objects {
+			names[i] = string(object.FullName())
+		}
+		Expect(names).To(Equal(helper.Names()))
+		Expect(names).To(ContainElements("fulfillment.v1.Gadget", "fulfillment.v1.Widget"))
+	})
+
+	It("Invokes the supported methods of types that support only some of the methods", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		objectHelper := helper.Lookup("gadget")
+		Expect(objectHelper).ToNot(BeNil())
+
+		// List:
+		listResult, err := objectHelper.List(ctx, ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(listResult.Total).To(BeNumerically("==", 1))
+		Expect(objectHelper.GetId(listResult.Items[0])).To(Equal("789"))
+
+		// Get:
+		object, err := objectHelper.Get(ctx, "789")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objectHelper.GetName(object)).To(Equal("my"))
+	})
+
+	It("Fails to invoke the methods that aren't supported", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		objectHelper := helper.Lookup("gadget")
+		Expect(objectHelper).ToNot(BeNil())
+		_, err = objectHelper.Create(ctx, objectHelper.Instance())
+		Expect(err).To(MatchError("object type 'fulfillment.v1.Gadget' doesn't support the 'Create' method"))
+		_, err = objectHelper.Update(ctx, objectHelper.Instance())
+		Expect(err).To(MatchError("object type 'fulfillment.v1.Gadget' doesn't support the 'Update' method"))
+		err = objectHelper.Delete(ctx, "789")
+		Expect(err).To(MatchError("object type 'fulfillment.v1.Gadget' doesn't support the 'Delete' method"))
+	})
+
+	It("Resolves types that aren't compiled into the binary", func() {
+		helper := makeHelper(0)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		value := &anypb.Any{}
+		resolver := helper.Resolver()
+		err = protojson.UnmarshalOptions{
+			Resolver: resolver,
+		}.Unmarshal(
+			[]byte(`{
+				"@type": "type.googleapis.com/fulfillment.v1.Widget",
+				"id": "123",
+				"color": "green"
+			}`),
+			value,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		object, err := anypb.UnmarshalNew(value, proto.UnmarshalOptions{
+			Resolver: resolver,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(object.ProtoReflect().Descriptor().FullName())).To(Equal("fulfillment.v1.Widget"))
+		Expect(helper.Lookup("widget").GetId(object)).To(Equal("123"))
+	})
+
+	It("Saves the descriptors to the cache and uses them", func() {
+		// The first time the descriptors should be fetched from the server:
+		helper := makeHelper(time.Hour)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 1))
+		files, err := filepath.Glob(filepath.Join(cacheDir, "descriptors", "*.binpb"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+
+		// The second time they should be loaded from the cache:
+		helper = makeHelper(time.Hour)
+		err = helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 1))
+		Expect(helper.Lookup("widget")).ToNot(BeNil())
+	})
+
+	It("Fetches the descriptors again when the cache has expired", func() {
+		helper := makeHelper(time.Hour)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 1))
+
+		// Make the cache file older than the TTL:
+		files, err := filepath.Glob(filepath.Join(cacheDir, "descriptors", "*.binpb"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		old := time.Now().Add(-2 * time.Hour)
+		err = os.Chtimes(files[0], old, old)
+		Expect(err).ToNot(HaveOccurred())
+
+		helper = makeHelper(time.Hour)
+		err = helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 2))
+		Expect(helper.Lookup("widget")).ToNot(BeNil())
+	})
+
+	It("Fetches the descriptors again when the cached ones can't be built", func() {
+		helper := makeHelper(time.Hour)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 1))
+
+		// Replace the cache file with a file that depends on a file that isn't available:
+		files, err := filepath.Glob(filepath.Join(cacheDir, "descriptors", "*.binpb"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		broken := makeObjectFile("Widget")
+		broken.Dependency = append(broken.Dependency, "missing.proto")
+		data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+			File: []*descriptorpb.FileDescriptorProto{broken},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		err = os.WriteFile(files[0], data, 0600)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The descriptors should be fetched again and the cache should be replaced:
+		helper = makeHelper(time.Hour)
+		err = helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 2))
+		Expect(helper.Lookup("widget")).ToNot(BeNil())
+		helper = makeHelper(time.Hour)
+		err = helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 2))
+		Expect(helper.Lookup("widget")).ToNot(BeNil())
+	})
+
+	It("Uses a different cache file for each server", func() {
+		helper := makeHelper(time.Hour)
+		err := helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		helper, err = NewHelper().
+			SetLogger(logger).
+			SetConnection(connection).
+			AddPackage("fulfillment.v1", 1).
+			SetAddress("other.example.com:443").
+			SetCacheDir(cacheDir).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetches.Load()).To(BeNumerically("==", 2))
+		files, err := filepath.Glob(filepath.Join(cacheDir, "descriptors", "*.binpb"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+	})
+
+	It("Uses only the types compiled into the binary if the server doesn't support reflection", func() {
+		// Create a server without the reflection service:
+		other := testing.NewServer()
+		DeferCleanup(other.Stop)
+		other.Start()
+		otherConnection, err := grpc.NewClient(
+			other.Address(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(otherConnection.Close)
+
+		helper, err := NewHelper().
+			SetLogger(logger).
+			SetConnection(otherConnection).
+			AddPackage("fulfillment.v1", 1).
+			SetAddress(other.Address()).
+			SetCacheDir(cacheDir).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(helper.Lookup("cluster")).ToNot(BeNil())
+		Expect(helper.Lookup("widget")).To(BeNil())
+	})
+
+	It("Uses only the types compiled into the binary if the reflection service fails", func() {
+		// Create a server with a reflection service that always fails:
+		other := testing.NewServer()
+		DeferCleanup(other.Stop)
+		reflectionv1.RegisterServerReflectionServer(other.Registrar(), &failingReflectionServer{})
+		other.Start()
+		otherConnection, err := grpc.NewClient(
+			other.Address(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(otherConnection.Close)
+
+		helper, err := NewHelper().
+			SetLogger(logger).
+			SetConnection(otherConnection).
+			AddPackage("fulfillment.v1", 1).
+			SetAddress(other.Address()).
+			SetCacheDir(cacheDir).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = helper.Discover(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(helper.Lookup("cluster")).ToNot(BeNil())
+		Expect(helper.Lookup("widget")).To(BeNil())
+	})
+})
+
+// fileResolver is a descriptor resolver that finds the given files and then the files compiled into the binary.
+type fileResolver struct {
+	files []protoreflect.FileDescriptor
+}
+
+func (r *fileResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	for _, file := range r.files {
+		if path == file.Path() {
+			return file, nil
+		}
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r *fileResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	for _, file := range r.files {
+		if name.Parent() != file.Package() {
+			continue
+		}
+		service := file.Services().ByName(name.Name())
+		if service != nil {
+			return service, nil
+		}
+		message := file.Messages().ByName(name.Name())
+		if message != nil {
+			return message, nil
+		}
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// countingReflectionServer wraps a reflection server and counts how many times the descriptors have been requested.
+type countingReflectionServer struct {
+	reflectionv1.ServerReflectionServer
+	count *atomic.Int32
+}
+
+func (s *countingReflectionServer) ServerReflectionInfo(
+	stream reflectionv1.ServerReflection_ServerReflectionInfoServer) error {
+	s.count.Add(1)
+	return s.ServerReflectionServer.ServerReflectionInfo(stream)
+}
+
+// failingReflectionServer is a reflection server that always fails with an internal error.
+type failingReflectionServer struct {
+	reflectionv1.UnimplementedServerReflectionServer
+}
+
+func (s *failingReflectionServer) ServerReflectionInfo(
+	stream reflectionv1.ServerReflection_ServerReflectionInfoServer) error {
+	return status.Error(codes.Internal, "internal error")
+}
+
+// makeObjectFile creates the descriptor of a file of the 'fulfillment.v1' package that contains an object type with the
+// given name, with 'id', 'metadata' and 'color' fields, and a service with the given methods.
+func makeObjectFile(name string, methods ...string) *descriptorpb.FileDescriptorProto {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	service := name + "s"
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type,
+		typeName string) *descriptorpb.FieldDescriptorProto {
+		result := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    optional.Enum(),
+			Type:     kind.Enum(),
+			JsonName: proto.String(name),
+		}
+		if typeName != "" {
+			result.TypeName = proto.String(typeName)
+		}
+		return result
+	}
+	message := func(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name:  proto.String(name),
+			Field: fields,
+		}
+	}
+	stringKind := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	int32Kind := descriptorpb.FieldDescriptorProto_TYPE_INT32
+	messageKind := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	objectType := ".fulfillment.v1." + name
+	result := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("fulfillment/v1/" + strings.ToLower(name) + "_type.proto"),
+		Package:    proto.String("fulfillment.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"shared/v1/metadata_type.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			message(
+				name,
+				field("id", 1, stringKind, ""),
+				field("metadata", 2, messageKind, ".shared.v1.Metadata"),
+				field("color", 3, stringKind, ""),
+			),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String(service),
+		}},
+	}
+	for _, method := range methods {
+		var request, response *descriptorpb.DescriptorProto
+		switch method {
+		case "List":
+			items := field("items", 1, messageKind, objectType)
+			items.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			request = message(
+				"",
+				field("filter", 1, stringKind, ""),
+				field("limit", 2, int32Kind, ""),
+			)
+			response = message(
+				"",
+				items,
+				field("total", 2, int32Kind, ""),
+			)
+		case "Get":
+			request = message("", field("id", 1, stringKind, ""))
+			response = message("", field("object", 1, messageKind, objectType))
+		case "Create", "Update":
+			request = message("", field("object", 1, messageKind, objectType))
+			response = message("", field("object", 1, messageKind, objectType))
+		case "Delete":
+			request = message("", field("id", 1, stringKind, ""))
+			response = message("")
+		}
+		request.Name = proto.String(service + method + "Request")
+		response.Name = proto.String(service + method + "Response")
+		result.MessageType = append(result.MessageType, request, response)
+		result.Service[0].Method = append(result.Service[0].Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(method),
+			InputType:  proto.String(".fulfillment.v1." + request.GetName()),
+			OutputType: proto.String(".fulfillment.v1." + response.GetName()),
+		})
+	}
+	return result
+}
@@ -21,6 +21,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gertd/go-pluralize"
 	"golang.org/x/exp/maps"
@@ -28,6 +29,7 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
 
 	// This is needed to ensure that the types and services are loaded into the protocol buffers registry, otherwise
 	// they will be visible only if they are explicitly used in some part of the code.
@@ -45,13 +47,18 @@ const (
 	updateMethodName = protoreflect.Name("Update")
 
 	// Fields:
-	filterFieldName   = protoreflect.Name("filter")
-	idFieldName       = protoreflect.Name("id")
-	itemsFieldName    = protoreflect.Name("items")
-	limitFieldName    = protoreflect.Name("limit")
-	metadataFieldName = protoreflect.Name("metadata")
-	objectFieldName   = protoreflect.Name("object")
-	totalFieldName    = protoreflect.Name("total")
+	filterFieldName     = protoreflect.Name("filter")
+	idFieldName         = protoreflect.Name("id")
+	itemsFieldName      = protoreflect.Name("items")
+	limitFieldName      = protoreflect.Name("limit")
+	metadataFieldName   = protoreflect.Name("metadata")
+	nameFieldName       = protoreflect.Name("name")
+	objectFieldName     = protoreflect.Name("object")
+	offsetFieldName     = protoreflect.Name("offset")
+	orderFieldName      = protoreflect.Name("order")
+	pathsFieldName      = protoreflect.Name("paths")
+	totalFieldName      = protoreflect.Name("total")
+	updateMaskFieldName = protoreflect.Name("update_mask")
 )
 
 // HelperBuilder contains the data and logic needed to create a reflection helper.
@@ -61,6 +68,9 @@ type HelperBuilder struct {
 	logger     *slog.Logger
 	connection *grpc.ClientConn
 	packages   map[string]int
+	address    string
+	cacheDir   string
+	cacheTtl   time.Duration
 }
 
 // Helper simplifies use of the protocol buffers reflection facility. It knows how to extract from the descriptors the
@@ -72,6 +82,11 @@ type Helper struct {
 	logger     *slog.Logger
 	connection *grpc.ClientConn
 	packages   map[protoreflect.FullName]int
+	address    string
+	cacheDir   string
+	cacheTtl   time.Duration
+	files      *protoregistry.Files
+	types      *dynamicpb.Types
 	scanOnce   *sync.Once
 	pluralizer *pluralize.Client
 	helpers    []ObjectHelper
@@ -116,6 +131,27 @@ func (b *HelperBuilder) AddPackages(values map[string]int) *HelperBuilder {
 	return b
 }
 
+// SetAddress sets the address of the server. This is optional, and it is only used as the key of the cache of the
+// descriptors obtained by the Discover method.
+func (b *HelperBuilder) SetAddress(value string) *HelperBuilder {
+	b.address = value
+	return b
+}
+
+// SetCacheDir sets the directory where the Discover method saves the descriptors obtained from the server. This is
+// optional, if not set the descriptors will not be cached.
+func (b *HelperBuilder) SetCacheDir(value string) *HelperBuilder {
+	b.cacheDir = value
+	return b
+}
+
+// SetCacheTtl sets how long the descriptors saved by the Discover method are used before obtaining them again from
+// the server. This is optional, the default is one hour.
+func (b *HelperBuilder) SetCacheTtl(value time.Duration) *HelperBuilder {
+	b.cacheTtl = value
+	return b
+}
+
 // Build uses the data stored in the builder to create a new reflection helper.
 func (b *HelperBuilder) Build() (result *Helper, err error) {
 	// Check the parameters:
@@ -131,6 +167,16 @@ func (b *HelperBuilder) Build() (result *Helper, err error) {
 		err = errors.New("at least one package is mandatory")
 		return
 	}
+	if b.cacheTtl < 0 {
+		err = fmt.Errorf("cache TTL should be positive, but it is %s", b.cacheTtl)
+		return
+	}
+
+	// Set defaults:
+	cacheTtl := b.cacheTtl
+	if cacheTtl == 0 {
+		cacheTtl = DefaultCacheTtl
+	}
 
 	// Create the pluralizer:
 	pluralizer := pluralize.NewClient()
@@ -146,6 +192,9 @@ func (b *HelperBuilder) Build() (result *Helper, err error) {
 		logger:     b.logger,
 		packages:   packages,
 		connection: b.connection,
+		address:    b.address,
+		cacheDir:   b.cacheDir,
+		cacheTtl:   cacheTtl,
 		pluralizer: pluralizer,
 		scanOnce:   &sync.Once{},
 		helpers:    []ObjectHelper{},
@@ -161,6 +210,9 @@ func (h *Helper) scanIfNeeded() {
 
 func (h *Helper) scan() {
 	protoregistry.GlobalFiles.RangeFiles(h.scanFile)
+	if h.files != nil {
+		h.files.RangeFiles(h.scanDiscoveredFile)
+	}
 	sort.Slice(
 		h.helpers,
 		func(i, j int) bool {
@@ -197,180 +249,249 @@ func (h *Helper) scanFile(fileDesc protoreflect.FileDescriptor) bool {
 	return true
 }
 
+// scanDiscoveredFile scans a file obtained from the server, ignoring it if it is also compiled into the binary, as
+// in that case it has already been scanned and the generated types should be preferred.
+func (h *Helper) scanDiscoveredFile(fileDesc protoreflect.FileDescriptor) bool {
+	_, err := protoregistry.GlobalFiles.FindFileByPath(fileDesc.Path())
+	if err == nil {
+		return true
+	}
+	serviceDescs := fileDesc.Services()
+	for i := range serviceDescs.Len() {
+		serviceDesc := serviceDescs.Get(i)
+		_, err = protoregistry.GlobalFiles.FindDescriptorByName(serviceDesc.FullName())
+		if err == nil {
+			h.logger.Debug(
+				"Ignoring discovered service because it is compiled into the binary",
+				slog.String("file", fileDesc.Path()),
+				slog.String("service", string(serviceDesc.FullName())),
+			)
+			return true
+		}
+	}
+	return h.scanFile(fileDesc)
+}
+
 func (h *Helper) scanService(serviceDesc protoreflect.ServiceDescriptor) {
-	// The service must have the get, list, update and delete method:
 	h.logger.Debug(
 		"Scanning service",
 		slog.String("service", string(serviceDesc.FullName())),
 	)
 	methodDescs := serviceDesc.Methods()
 	listDesc := methodDescs.ByName(listMethodName)
-	if listDesc == nil {
-		return
-	}
 	getDesc := methodDescs.ByName(getMethodName)
-	if getDesc == nil {
-		return
-	}
 	createDesc := methodDescs.ByName(createMethodName)
-	if createDesc == nil {
-		return
-	}
 	updateDesc := methodDescs.ByName(updateMethodName)
-	if updateDesc == nil {
-		return
-	}
 	deleteDesc := methodDescs.ByName(deleteMethodName)
-	if deleteDesc == nil {
-		return
-	}
 
-	// The request of the get method must have an `id` field:
-	getRequestIdFieldDesc := h.getIdField(getDesc.Input())
-	if getRequestIdFieldDesc == nil {
+	// Find the object type, which is the type of the `object` field of the response of the `Get` method, or of the
+	// `items` field of the response of the `List` method, or of the `object` field of the responses of the `Create`
+	// and `Update` methods, in that order. If none of those methods exist, or the type doesn't have the `id` and
+	// `metadata` fields, then this isn't a service that supports objects.
+	objectDesc := h.findObjectType(getDesc, listDesc, createDesc, updateDesc)
+	if objectDesc == nil {
+		h.logger.Debug(
+			"Ignoring service because it doesn't have a valid object type",
+			slog.String("service", string(serviceDesc.FullName())),
+		)
 		return
 	}
 
-	// The response of the get method must have an `object` field:
-	getResponseObjectFieldDesc := h.getObjectField(getDesc.Output())
-	objectDesc := getResponseObjectFieldDesc.Message()
+	// Calculate the singular and pluran names:
+	objectName := string(objectDesc.Name())
+	objectNameSingular := strings.ToLower(objectName)
+	objectNamePlural := strings.ToLower(h.pluralizer.Plural(objectName))
+
+	// Get the descriptors of the fields of the object:
+	idFieldDesc := h.getIdField(objectDesc)
+	metadataFieldDesc := h.getMetadataField(objectDesc)
 
-	// The request of the list method must have a `filter` field:
-	listRequestFilterFieldDesc := h.getFilterField(listDesc.Input())
-	if listRequestFilterFieldDesc == nil {
+	// Create the object helper, and then add the information of the methods that are supported:
+	helper := ObjectHelper{
+		parent:        h,
+		descriptor:    objectDesc,
+		idField:       idFieldDesc,
+		metadataField: metadataFieldDesc,
+		singular:      objectNameSingular,
+		plural:        objectNamePlural,
+		template:      h.makeTemplate(objectDesc),
+	}
+	helper.get = h.makeGetInfo(getDesc, objectDesc)
+	helper.list = h.makeListInfo(listDesc, objectDesc)
+	helper.create = h.makeCreateInfo(createDesc, objectDesc)
+	helper.update = h.makeUpdateInfo(updateDesc, objectDesc)
+	helper.delete = h.makeDeleteInfo(deleteDesc)
+	if helper.list == nil && helper.get == nil && helper.create == nil && helper.update == nil {
+		h.logger.Debug(
+			"Ignoring service because none of its methods is supported",
+			slog.String("service", string(serviceDesc.FullName())),
+		)
 		return
 	}
+	h.logger.Debug(
+		"Found object type",
+		slog.String("service", string(serviceDesc.FullName())),
+		slog.String("type", string(objectDesc.FullName())),
+		slog.Any("verbs", helper.Verbs()),
+	)
+	h.helpers = append(h.helpers, helper)
+}
 
-	// The request of the list method may have a `limit` field:
-	listRequestLimitFieldDesc := h.getLimitField(listDesc.Input())
-
-	// The response of the list method must have an `items` field:
-	listResponseItemsFieldDesc := h.getItemsField(listDesc.Output())
-	if listResponseItemsFieldDesc == nil {
-		return
+func (h *Helper) findObjectType(getDesc, listDesc, createDesc,
+	updateDesc protoreflect.MethodDescriptor) protoreflect.MessageDescriptor {
+	var objectDesc protoreflect.MessageDescriptor
+	if getDesc != nil {
+		fieldDesc := h.getObjectField(getDesc.Output())
+		if fieldDesc != nil {
+			objectDesc = fieldDesc.Message()
+		}
 	}
-	if listResponseItemsFieldDesc.Message() != objectDesc {
-		return
+	if objectDesc == nil && listDesc != nil {
+		fieldDesc := h.getItemsField(listDesc.Output())
+		if fieldDesc != nil {
+			objectDesc = fieldDesc.Message()
+		}
+	}
+	for _, methodDesc := range []protoreflect.MethodDescriptor{createDesc, updateDesc} {
+		if objectDesc != nil || methodDesc == nil {
+			continue
+		}
+		fieldDesc := h.getObjectField(methodDesc.Output())
+		if fieldDesc != nil {
+			objectDesc = fieldDesc.Message()
+		}
+	}
+	if objectDesc == nil {
+		return nil
 	}
 
-	// The response of the list method may have a `total` field:
-	listResponseTotalFieldDesc := h.getTotalField(listDesc.Output())
+	// The object type must have the `id` and `metadata` fields, as those are used to find and display objects:
+	if h.getIdField(objectDesc) == nil || h.getMetadataField(objectDesc) == nil {
+		return nil
+	}
+	return objectDesc
+}
 
-	// The request and response of the `Crate` method must have an `object` message field:
-	createRequestObjectFieldDesc := h.getObjectField(createDesc.Input())
-	if createRequestObjectFieldDesc == nil {
-		return
+func (h *Helper) makeGetInfo(methodDesc protoreflect.MethodDescriptor,
+	objectDesc protoreflect.MessageDescriptor) *getInfo {
+	if methodDesc == nil {
+		return nil
 	}
-	if createRequestObjectFieldDesc.Message() != objectDesc {
-		return
+
+	// The request must have an `id` field, and the response must have an `object` field:
+	idFieldDesc := h.getIdField(methodDesc.Input())
+	if idFieldDesc == nil {
+		return nil
 	}
-	createResponseObjectFieldDesc := h.getObjectField(createDesc.Output())
-	if createResponseObjectFieldDesc == nil {
-		return
+	objectFieldDesc := h.getObjectField(methodDesc.Output())
+	if objectFieldDesc == nil || objectFieldDesc.Message() != objectDesc {
+		return nil
 	}
-	if createResponseObjectFieldDesc.Message() != objectDesc {
-		return
+	return &getInfo{
+		methodInfo: h.makeMethodInfo(methodDesc),
+		id:         idFieldDesc,
+		object:     objectFieldDesc,
 	}
+}
 
-	// The request and response of the `Update` method must have an `object` message field:
-	updateRequestObjectFieldDesc := h.getObjectField(updateDesc.Input())
-	if updateRequestObjectFieldDesc == nil {
-		return
+func (h *Helper) makeListInfo(methodDesc protoreflect.MethodDescriptor,
+	objectDesc protoreflect.MessageDescriptor) *listInfo {
+	if methodDesc == nil {
+		return nil
 	}
-	if updateRequestObjectFieldDesc.Message() != objectDesc {
-		return
+
+	// The request must have a `filter` field, and may have `limit`, `offset` and `order` fields:
+	filterFieldDesc := h.getFilterField(methodDesc.Input())
+	if filterFieldDesc == nil {
+		return nil
 	}
-	updateResponseObjectFieldDesc := h.getObjectField(updateDesc.Output())
-	if updateResponseObjectFieldDesc == nil {
-		return
+	limitFieldDesc := h.getLimitField(methodDesc.Input())
+	offsetFieldDesc := h.getOffsetField(methodDesc.Input())
+	orderFieldDesc := h.getOrderField(methodDesc.Input())
+
+	// The response must have an `items` field, and may have a `total` field:
+	itemsFieldDesc := h.getItemsField(methodDesc.Output())
+	if itemsFieldDesc == nil || itemsFieldDesc.Message() != objectDesc {
+		return nil
 	}
-	if updateResponseObjectFieldDesc.Message() != objectDesc {
-		return
+	totalFieldDesc := h.getTotalField(methodDesc.Output())
+
+	return &listInfo{
+		methodInfo: h.makeMethodInfo(methodDesc),
+		filter:     filterFieldDesc,
+		limit:      limitFieldDesc,
+		offset:     offsetFieldDesc,
+		order:      orderFieldDesc,
+		items:      itemsFieldDesc,
+		total:      totalFieldDesc,
 	}
+}
 
-	// The request of the `Delete` method must have an `id` string field:
-	deleteRequestIdFieldDesc := h.getIdField(deleteDesc.Input())
-	if deleteRequestIdFieldDesc == nil {
-		return
+func (h *Helper) makeCreateInfo(methodDesc protoreflect.MethodDescriptor,
+	objectDesc protoreflect.MessageDescriptor) *createInfo {
+	in, out := h.getObjectFields(methodDesc, objectDesc)
+	if in == nil || out == nil {
+		return nil
+	}
+	return &createInfo{
+		methodInfo: h.makeMethodInfo(methodDesc),
+		in:         in,
+		out:        out,
 	}
+}
 
-	// Create the object template:
-	objectTemplate := h.makeTemplate(objectDesc)
+func (h *Helper) makeUpdateInfo(methodDesc protoreflect.MethodDescriptor,
+	objectDesc protoreflect.MessageDescriptor) *updateInfo {
+	in, out := h.getObjectFields(methodDesc, objectDesc)
+	if in == nil || out == nil {
+		return nil
+	}
 
-	// Create templates for the request and response messages:
-	getRequestTemplate, getResponseTemplate := h.makeMethodTemplates(getDesc)
-	listRequestTemplate, listResponseTemplate := h.makeMethodTemplates(listDesc)
-	createRequestTemplate, createResponseTemplate := h.makeMethodTemplates(createDesc)
-	updateRequestTemplate, updateResponseTemplate := h.makeMethodTemplates(updateDesc)
-	deleteRequestTemplate, deleteResponseTemplate := h.makeMethodTemplates(deleteDesc)
+	// The request may have an `update_mask` field:
+	maskFieldDesc := h.getUpdateMaskField(methodDesc.Input())
 
-	// Calculate the singular and pluran names:
-	objectName := string(objectDesc.Name())
-	objectNameSingular := strings.ToLower(objectName)
-	objectNamePlural := strings.ToLower(h.pluralizer.Plural(objectName))
+	return &updateInfo{
+		methodInfo: h.makeMethodInfo(methodDesc),
+		in:         in,
+		out:        out,
+		mask:       maskFieldDesc,
+	}
+}
 
-	// Get the descriptors of the fields of the object:
-	objectFields := objectDesc.Fields()
-	idFieldDesc := objectFields.ByName(idFieldName)
-	metadataFieldDesc := objectFields.ByName(metadataFieldName)
+func (h *Helper) makeDeleteInfo(methodDesc protoreflect.MethodDescriptor) *deleteInfo {
+	if methodDesc == nil {
+		return nil
+	}
 
-	// This is a supported object type:
-	helper := ObjectHelper{
-		parent:        h,
-		descriptor:    objectDesc,
-		idField:       idFieldDesc,
-		metadataField: metadataFieldDesc,
-		singular:      objectNameSingular,
-		plural:        objectNamePlural,
-		template:      objectTemplate,
-		get: getInfo{
-			methodInfo: methodInfo{
-				path:     h.makeMethodPath(getDesc),
-				request:  getRequestTemplate,
-				response: getResponseTemplate,
-			},
-			id:     getRequestIdFieldDesc,
-			object: getResponseObjectFieldDesc,
-		},
-		list: listInfo{
-			methodInfo: methodInfo{
-				path:     h.makeMethodPath(listDesc),
-				request:  listRequestTemplate,
-				response: listResponseTemplate,
-			},
-			filter: listRequestFilterFieldDesc,
-			limit:  listRequestLimitFieldDesc,
-			items:  listResponseItemsFieldDesc,
-			total:  listResponseTotalFieldDesc,
-		},
-		create: createInfo{
-			methodInfo: methodInfo{
-				path:     h.makeMethodPath(createDesc),
-				request:  createRequestTemplate,
-				response: createResponseTemplate,
-			},
-			in:  createRequestObjectFieldDesc,
-			out: createResponseObjectFieldDesc,
-		},
-		update: updateInfo{
-			methodInfo: methodInfo{
-				path:     h.makeMethodPath(updateDesc),
-				request:  updateRequestTemplate,
-				response: updateResponseTemplate,
-			},
-			in:  updateRequestObjectFieldDesc,
-			out: updateResponseObjectFieldDesc,
-		},
-		delete: deleteInfo{
-			methodInfo: methodInfo{
-				path:     h.makeMethodPath(deleteDesc),
-				request:  deleteRequestTemplate,
-				response: deleteResponseTemplate,
-			},
-			id: deleteRequestIdFieldDesc,
-		},
+	// The request must have an `id` string field:
+	idFieldDesc := h.getIdField(methodDesc.Input())
+	if idFieldDesc == nil {
+		return nil
 	}
-	h.helpers = append(h.helpers, helper)
+	return &deleteInfo{
+		methodInfo: h.makeMethodInfo(methodDesc),
+		id:         idFieldDesc,
+	}
+}
+
+// getObjectFields returns the `object` fields of the request and response of the given method, if they exist and have
+// the given object type.
+func (h *Helper) getObjectFields(methodDesc protoreflect.MethodDescriptor,
+	objectDesc protoreflect.MessageDescriptor) (in, out protoreflect.FieldDescriptor) {
+	if methodDesc == nil {
+		return
+	}
+	in = h.getObjectField(methodDesc.Input())
+	if in == nil || in.Message() != objectDesc {
+		in = nil
+		return
+	}
+	out = h.getObjectField(methodDesc.Output())
+	if out == nil || out.Message() != objectDesc {
+		in, out = nil, nil
+		return
+	}
+	return
 }
 
 func (h *Helper) getIdField(messageDesc protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
@@ -429,6 +550,62 @@ func (h *Helper) getLimitField(messageDesc protoreflect.MessageDescriptor) proto
 	return fieldDesc
 }
 
+func (h *Helper) getOffsetField(messageDesc protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
+	fieldDesc := messageDesc.Fields().ByName(offsetFieldName)
+	if fieldDesc == nil {
+		return nil
+	}
+	if fieldDesc.Cardinality() == protoreflect.Repeated {
+		return nil
+	}
+	if fieldDesc.Kind() != protoreflect.Int32Kind {
+		return nil
+	}
+	return fieldDesc
+}
+
+func (h *Helper) getOrderField(messageDesc protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
+	fieldDesc := messageDesc.Fields().ByName(orderFieldName)
+	if fieldDesc == nil {
+		return nil
+	}
+	if fieldDesc.Cardinality() == protoreflect.Repeated {
+		return nil
+	}
+	if fieldDesc.Kind() != protoreflect.StringKind {
+		return nil
+	}
+	return fieldDesc
+}
+
+func (h *Helper) getMetadataField(messageDesc protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
+	fieldDesc := messageDesc.Fields().ByName(metadataFieldName)
+	if fieldDesc == nil {
+		return nil
+	}
+	if fieldDesc.Cardinality() == protoreflect.Repeated {
+		return nil
+	}
+	if fieldDesc.Message() == nil {
+		return nil
+	}
+	return fieldDesc
+}
+
+func (h *Helper) getUpdateMaskField(messageDesc protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
+	fieldDesc := messageDesc.Fields().ByName(updateMaskFieldName)
+	if fieldDesc == nil {
+		return nil
+	}
+	if fieldDesc.Cardinality() == protoreflect.Repeated {
+		return nil
+	}
+	if fieldDesc.Message() == nil || fieldDesc.Message().FullName() != "google.protobuf.FieldMask" {
+		return nil
+	}
+	return fieldDesc
+}
+
 func (h *Helper) getItemsField(messageDesc protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
 	fieldDesc := messageDesc.Fields().ByName(itemsFieldName)
 	if fieldDesc == nil {
@@ -492,6 +669,16 @@ func (h *Helper) Plurals() []string {
 	return results
 }
 
+// Objects returns the helpers for all the object types, in the same order than the Names method.
+func (h *Helper) Objects() []*ObjectHelper {
+	h.scanIfNeeded()
+	results := make([]*ObjectHelper, len(h.helpers))
+	for i := range h.helpers {
+		results[i] = &h.helpers[i]
+	}
+	return results
+}
+
 // Lookup returns the helper for the given object type. Returns nil if there is no such object.
 func (h *Helper) Lookup(objectType string) *ObjectHelper {
 	h.scanIfNeeded()
@@ -513,19 +700,22 @@ func (h *Helper) makeMethodPath(methodDesc protoreflect.MethodDescriptor) string
 	return fmt.Sprintf("/%s/%s", methodDesc.FullName().Parent(), methodDesc.Name())
 }
 
-func (h *Helper) makeMethodTemplates(methodDesc protoreflect.MethodDescriptor) (requestTemplate,
-	responseTemplate proto.Message) {
-	requestTemplate = h.makeTemplate(methodDesc.Input())
-	responseTemplate = h.makeTemplate(methodDesc.Output())
-	return
+func (h *Helper) makeMethodInfo(methodDesc protoreflect.MethodDescriptor) methodInfo {
+	return methodInfo{
+		path:     h.makeMethodPath(methodDesc),
+		request:  h.makeTemplate(methodDesc.Input()),
+		response: h.makeTemplate(methodDesc.Output()),
+	}
 }
 
+// makeTemplate creates an empty message of the given type. It uses the generated type if the descriptor is compiled
+// into the binary, and a dynamic message if it has been obtained from the server.
 func (h *Helper) makeTemplate(messageDesc protoreflect.MessageDescriptor) proto.Message {
 	messageType, err := protoregistry.GlobalTypes.FindMessageByName(messageDesc.FullName())
-	if err != nil {
-		panic(err)
+	if err == nil && messageType.Descriptor() == messageDesc {
+		return messageType.New().Interface()
 	}
-	return messageType.New().Interface()
+	return dynamicpb.NewMessage(messageDesc)
 }
 
 // ObjectHelper contains information about a message type that satisfies the conditions to be considered an object.
@@ -535,11 +725,11 @@ type ObjectHelper struct {
 	singular      string
 	plural        string
 	template      proto.Message
-	list          listInfo
-	get           getInfo
-	create        createInfo
-	update        updateInfo
-	delete        deleteInfo
+	list          *listInfo
+	get           *getInfo
+	create        *createInfo
+	update        *updateInfo
+	delete        *deleteInfo
 	idField       protoreflect.FieldDescriptor
 	metadataField protoreflect.FieldDescriptor
 }
@@ -560,6 +750,8 @@ type listInfo struct {
 	methodInfo
 	filter protoreflect.FieldDescriptor
 	limit  protoreflect.FieldDescriptor
+	offset protoreflect.FieldDescriptor
+	order  protoreflect.FieldDescriptor
 	items  protoreflect.FieldDescriptor
 	total  protoreflect.FieldDescriptor
 }
@@ -572,8 +764,9 @@ type createInfo struct {
 
 type updateInfo struct {
 	methodInfo
-	in  protoreflect.FieldDescriptor
-	out protoreflect.FieldDescriptor
+	in   protoreflect.FieldDescriptor
+	out  protoreflect.FieldDescriptor
+	mask protoreflect.FieldDescriptor
 }
 
 type deleteInfo struct {
@@ -605,8 +798,64 @@ func (h *ObjectHelper) Plural() string {
 	return h.plural
 }
 
+// CanList returns true if the server supports listing objects of this type.
+func (h *ObjectHelper) CanList() bool {
+	return h.list != nil
+}
+
+// CanOrder returns true if the server supports sorting the results of the list method of this type.
+func (h *ObjectHelper) CanOrder() bool {
+	return h.list != nil && h.list.order != nil
+}
+
+// CanGet returns true if the server supports getting individual objects of this type.
+func (h *ObjectHelper) CanGet() bool {
+	return h.get != nil
+}
+
+// CanCreate returns true if the server supports creating objects of this type.
+func (h *ObjectHelper) CanCreate() bool {
+	return h.create != nil
+}
+
+// CanUpdate returns true if the server supports updating objects of this type.
+func (h *ObjectHelper) CanUpdate() bool {
+	return h.update != nil
+}
+
+// CanDelete returns true if the server supports deleting objects of this type.
+func (h *ObjectHelper) CanDelete() bool {
+	return h.delete != nil
+}
+
+// Verbs returns the commands of the tool that can be used with objects of this type. Note that the 'get', 'edit' and
+// 'delete' commands find the objects using the list method, so they are available only if it is supported.
+func (h *ObjectHelper) Verbs() []string {
+	var result []string
+	if h.CanList() {
+		result = append(result, "get")
+	}
+	if h.CanCreate() {
+		result = append(result, "create")
+	}
+	if h.CanList() && h.CanUpdate() {
+		result = append(result, "edit")
+	}
+	if h.CanList() && h.CanDelete() {
+		result = append(result, "delete")
+	}
+	return result
+}
+
+// unsupported returns the error used when a method isn't supported by the server for this object type.
+func (h *ObjectHelper) unsupported(method protoreflect.Name) error {
+	return fmt.Errorf("object type '%s' doesn't support the '%s' method", h, method)
+}
+
 type ListOptions struct {
 	Filter string
+	Order  string
+	Offset int32
 	Limit  int32
 }
 
@@ -616,10 +865,20 @@ type ListResult struct {
 }
 
 func (h *ObjectHelper) List(ctx context.Context, options ListOptions) (result ListResult, err error) {
+	if h.list == nil {
+		err = h.unsupported(listMethodName)
+		return
+	}
 	request := proto.Clone(h.list.request)
 	if options.Filter != "" {
 		request.ProtoReflect().Set(h.list.filter, protoreflect.ValueOfString(options.Filter))
 	}
+	if options.Order != "" && h.list.order != nil {
+		request.ProtoReflect().Set(h.list.order, protoreflect.ValueOfString(options.Order))
+	}
+	if options.Offset > 0 && h.list.offset != nil {
+		request.ProtoReflect().Set(h.list.offset, protoreflect.ValueOfInt32(options.Offset))
+	}
 	if options.Limit > 0 && h.list.limit != nil {
 		request.ProtoReflect().Set(h.list.limit, protoreflect.ValueOfInt32(options.Limit))
 	}
@@ -642,6 +901,10 @@ func (h *ObjectHelper) List(ctx context.Context, options ListOptions) (result Li
 }
 
 func (h *ObjectHelper) Get(ctx context.Context, id string) (result proto.Message, err error) {
+	if h.get == nil {
+		err = h.unsupported(getMethodName)
+		return
+	}
 	request := proto.Clone(h.get.request)
 	h.setId(request, h.get.id, id)
 	response := proto.Clone(h.get.response)
@@ -662,10 +925,21 @@ func (h *ObjectHelper) GetName(object proto.Message) string {
 }
 
 func (h *ObjectHelper) GetMetadata(object proto.Message) Metadata {
-	return object.ProtoReflect().Get(h.metadataField).Message().Interface().(Metadata)
+	message := object.ProtoReflect().Get(h.metadataField).Message()
+	metadata, ok := message.Interface().(Metadata)
+	if !ok {
+		metadata = &dynamicMetadata{
+			message: message,
+		}
+	}
+	return metadata
 }
 
 func (h *ObjectHelper) Create(ctx context.Context, object proto.Message) (result proto.Message, err error) {
+	if h.create == nil {
+		err = h.unsupported(createMethodName)
+		return
+	}
 	request := proto.Clone(h.create.request)
 	h.setObject(request, h.create.in, object)
 	response := proto.Clone(h.create.response)
@@ -677,9 +951,20 @@ func (h *ObjectHelper) Create(ctx context.Context, object proto.Message) (result
 	return
 }
 
-func (h *ObjectHelper) Update(ctx context.Context, object proto.Message) (result proto.Message, err error) {
+// Update sends the given object to the server. The optional paths are the fields that have been changed, as returned by
+// the Diff method. They are sent in the update mask of the request, if the request has one, so that the server only
+// updates those fields. If no paths are given the mask is left empty and the server updates the complete object.
+func (h *ObjectHelper) Update(ctx context.Context, object proto.Message,
+	paths ...string) (result proto.Message, err error) {
+	if h.update == nil {
+		err = h.unsupported(updateMethodName)
+		return
+	}
 	request := proto.Clone(h.update.request)
 	h.setObject(request, h.update.in, object)
+	if h.update.mask != nil && len(paths) > 0 {
+		h.setMask(request, h.update.mask, paths)
+	}
 	response := proto.Clone(h.update.response)
 	err = h.parent.connection.Invoke(ctx, h.update.path, request, response)
 	if err != nil {
@@ -690,6 +975,9 @@ func (h *ObjectHelper) Update(ctx context.Context, object proto.Message) (result
 }
 
 func (h *ObjectHelper) Delete(ctx context.Context, id string) error {
+	if h.delete == nil {
+		return h.unsupported(deleteMethodName)
+	}
 	request := proto.Clone(h.delete.request)
 	h.setId(request, h.delete.id, id)
 	response := proto.Clone(h.delete.response)
@@ -700,6 +988,16 @@ func (h *ObjectHelper) setId(message proto.Message, field protoreflect.FieldDesc
 	message.ProtoReflect().Set(field, protoreflect.ValueOfString(value))
 }
 
+func (h *ObjectHelper) setMask(message proto.Message, field protoreflect.FieldDescriptor, paths []string) {
+	maskReflect := message.ProtoReflect().NewField(field).Message()
+	pathsField := maskReflect.Descriptor().Fields().ByName(pathsFieldName)
+	pathsList := maskReflect.Mutable(pathsField).List()
+	for _, path := range paths {
+		pathsList.Append(protoreflect.ValueOfString(path))
+	}
+	message.ProtoReflect().Set(field, protoreflect.ValueOfMessage(maskReflect))
+}
+
 func (h *ObjectHelper) setObject(message proto.Message, field protoreflect.FieldDescriptor, value proto.Message) {
 	message.ProtoReflect().Set(field, protoreflect.ValueOfMessage(value.ProtoReflect()))
 }
@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package reflection
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultChunkSize is the number of objects that the iterator requests in each call to the list method when the chunk
+// size isn't explicitly specified.
+const DefaultChunkSize = 100
+
+// IteratorOptions contains the options used to create an iterator.
+type IteratorOptions struct {
+	// Filter is the filter that will be sent to the server.
+	Filter string
+
+	// Order is the sort criteria that will be sent to the server. It is ignored if the server doesn't support it.
+	Order string
+
+	// Offset is the index of the first object that will be returned.
+	Offset int32
+
+	// Limit is the maximum number of objects that will be returned. Zero means no limit.
+	Limit int32
+
+	// ChunkSize is the number of objects requested in each call to the list method. Zero means DefaultChunkSize.
+	ChunkSize int32
+}
+
+// ListIterator calls the list method repeatedly, using the offset and limit fields of the request, till all the
+// objects have been returned, or till the limit has been reached. If the server doesn't support the offset or limit
+// fields then all the objects are returned in one page, and the offset and limit are applied locally.
+//
+// Don't create instances of this type directly, use the Iterator method of the object helper.
+type ListIterator struct {
+	helper    *ObjectHelper
+	filter    string
+	order     string
+	limit     int32
+	chunkSize int32
+	offset    int32
+	count     int32
+	total     int32
+	done      bool
+}
+
+// Iterator creates an iterator that returns the objects of this type page by page.
+func (h *ObjectHelper) Iterator(options IteratorOptions) *ListIterator {
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &ListIterator{
+		helper:    h,
+		filter:    options.Filter,
+		order:     options.Order,
+		limit:     options.Limit,
+		chunkSize: chunkSize,
+		offset:    options.Offset,
+	}
+}
+
+// Next returns the next page of objects. When there are no more objects it returns an empty page.
+func (i *ListIterator) Next(ctx context.Context) (result []proto.Message, err error) {
+	if i.done {
+		return
+	}
+	list := i.helper.list
+	if list == nil {
+		err = i.helper.unsupported(listMethodName)
+		return
+	}
+
+	// Calculate how many objects to request, so that we don't exceed the limit:
+	size := i.chunkSize
+	if i.limit > 0 {
+		size = min(size, i.limit-i.count)
+	}
+
+	// Send the request. If the server doesn't support the offset and limit fields then it will return all the
+	// objects, so we need to skip the ones before the offset locally.
+	paged := list.offset != nil && list.limit != nil
+	options := ListOptions{
+		Filter: i.filter,
+		Order:  i.order,
+	}
+	if paged {
+		options.Offset = i.offset
+		options.Limit = size
+	}
+	response, err := i.helper.List(ctx, options)
+	if err != nil {
+		return
+	}
+	items := response.Items
+	if !paged {
+		items = items[min(int(i.offset), len(items)):]
+	}
+	if i.limit > 0 && int32(len(items)) > i.limit-i.count {
+		items = items[:i.limit-i.count]
+	}
+	i.offset += int32(len(items))
+	i.count += int32(len(items))
+
+	// Update the total, and decide if we are done. Note that the server may not report the total, even if the response
+	// has the field, and in that case we stop when it returns less objects than requested.
+	if !paged {
+		i.total = max(response.Total, int32(len(response.Items)))
+		i.done = true
+	} else {
+		hasTotal := list.total != nil && response.Total > 0
+		if hasTotal {
+			i.total = response.Total
+		} else {
+			i.total = i.offset
+		}
+		switch {
+		case len(items) == 0:
+			i.done = true
+		case i.limit > 0 && i.count >= i.limit:
+			i.done = true
+		case hasTotal:
+			i.done = i.offset >= i.total
+		default:
+			i.done = int32(len(items)) < size
+		}
+	}
+
+	result = items
+	return
+}
+
+// Done returns true if the iterator has already returned all the objects.
+func (i *ListIterator) Done() bool {
+	return i.done
+}
+
+// Count returns the number of objects returned so far.
+func (i *ListIterator) Count() int32 {
+	return i.count
+}
+
+// Total returns the total number of objects that match the filter, as reported by the server in the last page. If
+// the server doesn't report it, then it is the number of objects seen so far, including those before the offset.
+func (i *ListIterator) Total() int32 {
+	return i.total
+}
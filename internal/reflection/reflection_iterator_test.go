@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package reflection
+
+import (
+	"context"
+	"fmt"
+
+	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("List iterator", func() {
+	var (
+		ctx          context.Context
+		objectHelper *ObjectHelper
+		hosts        []*ffv1.Host
+		requests     []*ffv1.HostsListRequest
+		reportTotal  bool
+	)
+
+	BeforeEach(func() {
+		// Create a context:
+		ctx = context.Background()
+
+		// Prepare the hosts:
+		hosts = make([]*ffv1.Host, 25)
+		for i := range hosts {
+			hosts[i] = ffv1.Host_builder{
+				Id: fmt.Sprintf("host-%02d", i),
+			}.Build()
+		}
+		requests = nil
+		reportTotal = true
+
+		// Create a server that returns the requested page of hosts:
+		server := testing.NewServer()
+		DeferCleanup(server.Stop)
+		ffv1.RegisterHostsServer(server.Registrar(), &testing.HostsServerFuncs{
+			ListFunc: func(ctx context.Context,
+				request *ffv1.HostsListRequest) (*ffv1.HostsListResponse, error) {
+				requests = append(requests, request)
+				offset := min(int(request.GetOffset()), len(hosts))
+				end := len(hosts)
+				if request.HasLimit() {
+					end = min(offset+int(request.GetLimit()), len(hosts))
+				}
+				page := hosts[offset:end]
+				response := ffv1.HostsListResponse_builder{
+					Size:  proto.Int32(int32(len(page))),
+					Items: page,
+				}.Build()
+				if reportTotal {
+					response.SetTotal(int32(len(hosts)))
+				}
+				return response, nil
+			},
+		})
+		server.Start()
+
+		// Create the client connection:
+		connection, err := grpc.NewClient(
+			server.Address(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(connection.Close)
+
+		// Create the helper:
+		helper, err := NewHelper().
+			SetLogger(logger).
+			SetConnection(connection).
+			AddPackage("fulfillment.v1", 1).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		objectHelper = helper.Lookup("hosts")
+		Expect(objectHelper).ToNot(BeNil())
+	})
+
+	// collect gets all the pages from the iterator, and returns the identifiers of the objects and the sizes of the
+	// pages.
+	collect := func(iterator *ListIterator) (ids []string, sizes []int) {
+		for !iterator.Done() {
+			page, err := iterator.Next(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			sizes = append(sizes, len(page))
+			for _, object := range page {
+				ids = append(ids, objectHelper.GetId(object))
+			}
+		}
+		return
+	}
+
+	It("Returns all the objects in chunks", func() {
+		iterator := objectHelper.Iterator(IteratorOptions{
+			ChunkSize: 10,
+		})
+		ids, sizes := collect(iterator)
+		Expect(ids).To(HaveLen(25))
+		Expect(ids[0]).To(Equal("host-00"))
+		Expect(ids[24]).To(Equal("host-24"))
+		Expect(sizes).To(Equal([]int{10, 10, 5}))
+		Expect(iterator.Count()).To(BeNumerically("==", 25))
+		Expect(iterator.Total()).To(BeNumerically("==", 25))
+		Expect(requests).To(HaveLen(3))
+		Expect(requests[1].GetOffset()).To(BeNumerically("==", 10))
+		Expect(requests[1].GetLimit()).To(BeNumerically("==", 10))
+	})
+
+	It("Uses the default chunk size", func() {
+		iterator := objectHelper.Iterator(IteratorOptions{})
+		ids, sizes := collect(iterator)
+		Expect(ids).To(HaveLen(25))
+		Expect(sizes).To(Equal([]int{25}))
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].GetLimit()).To(BeNumerically("==", DefaultChunkSize))
+	})
+
+	It("Stops when the limit is reached", func() {
+		iterator := objectHelper.Iterator(IteratorOptions{
+			Limit:     15,
+			ChunkSize: 10,
+		})
+		ids, sizes := collect(iterator)
+		Expect(ids).To(HaveLen(15))
+		Expect(sizes).To(Equal([]int{10, 5}))
+		Expect(iterator.Total()).To(BeNumerically("==", 25))
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].GetLimit()).To(BeNumerically("==", 5))
+	})
+
+	It("Starts at the offset", func() {
+		iterator := objectHelper.Iterator(IteratorOptions{
+			Offset:    20,
+			ChunkSize: 10,
+		})
+		ids, _ := collect(iterator)
+		Expect(ids).To(Equal([]string{"host-20", "host-21", "host-22", "host-23", "host-24"}))
+		Expect(iterator.Count()).To(BeNumerically("==", 5))
+		Expect(iterator.Total()).To(BeNumerically("==", 25))
+	})
+
+	It("Returns an empty page when the offset is beyond the last object", func() {
+		iterator := objectHelper.Iterator(IteratorOptions{
+			Offset: 30,
+		})
+		ids, sizes := collect(iterator)
+		Expect(ids).To(BeEmpty())
+		Expect(sizes).To(Equal([]int{0}))
+		Expect(iterator.Done()).To(BeTrue())
+	})
+
+	It("Stops when a page is incomplete if the server doesn't report the total", func() {
+		reportTotal = false
+		iterator := objectHelper.Iterator(IteratorOptions{
+			ChunkSize: 10,
+		})
+		ids, sizes := collect(iterator)
+		Expect(ids).To(HaveLen(25))
+		Expect(sizes).To(Equal([]int{10, 10, 5}))
+		Expect(iterator.Total()).To(BeNumerically("==", 25))
+	})
+
+	It("Doesn't call the server again once done", func() {
+		iterator := objectHelper.Iterator(IteratorOptions{})
+		collect(iterator)
+		page, err := iterator.Next(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(page).To(BeEmpty())
+		Expect(requests).To(HaveLen(1))
+	})
+})
@@ -13,7 +13,25 @@ language governing permissions and limitations under the License.
 
 package reflection
 
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
 // Metadata is an interface that provides access to common metadata fields in protobuf messages.
 type Metadata interface {
 	GetName() string
 }
+
+// dynamicMetadata is the implementation of the Metadata interface for the messages that don't have a generated type,
+// like those created from descriptors obtained from the server.
+type dynamicMetadata struct {
+	message protoreflect.Message
+}
+
+func (m *dynamicMetadata) GetName() string {
+	fieldDesc := m.message.Descriptor().Fields().ByName(nameFieldName)
+	if fieldDesc == nil || fieldDesc.Kind() != protoreflect.StringKind || fieldDesc.IsList() {
+		return ""
+	}
+	return m.message.Get(fieldDesc).String()
+}
@@ -0,0 +1,269 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package rendering
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/innabox/fulfillment-cli/internal/reflection"
+)
+
+// SortKey is an expression used to sort objects, and the direction.
+type SortKey struct {
+	// Expression is the CEL expression that calculates the value used to sort the objects. The expression can
+	// access the object via the `this` built-in variable.
+	Expression string
+
+	// Descending indicates if the objects should be sorted in descending order.
+	Descending bool
+}
+
+// String returns the text of the key, with the same syntax used by the order field of list requests.
+func (k SortKey) String() string {
+	if k.Descending {
+		return k.Expression + " desc"
+	}
+	return k.Expression
+}
+
+// ParseSortKey parses a sort key. The text is a CEL expression, optionally prefixed with a `-` or followed by `asc` or
+// `desc` to indicate the direction. For example, all these are valid:
+//
+//	this.metadata.name
+//	-this.metadata.creation_timestamp
+//	this.metadata.creation_timestamp desc
+func ParseSortKey(text string) (result SortKey, err error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "-") {
+		result.Descending = true
+		text = strings.TrimSpace(text[1:])
+	} else if index := strings.LastIndexAny(text, " \t"); index != -1 {
+		switch strings.ToLower(text[index+1:]) {
+		case "asc":
+			text = strings.TrimSpace(text[:index])
+		case "desc":
+			result.Descending = true
+			text = strings.TrimSpace(text[:index])
+		}
+	}
+	if text == "" {
+		err = errors.New("sort expression is empty")
+		return
+	}
+	result.Expression = text
+	return
+}
+
+// ObjectSorterBuilder contains the data and logic needed to create an object sorter. Don't create instances of this
+// type directly, use the NewObjectSorter function instead.
+type ObjectSorterBuilder struct {
+	logger *slog.Logger
+	helper *reflection.ObjectHelper
+	keys   []SortKey
+}
+
+// ObjectSorter sorts objects using CEL expressions evaluated in the same environment used to render tables. Don't
+// create instances of this type directly, use the NewObjectSorter function instead.
+type ObjectSorter struct {
+	logger   *slog.Logger
+	keys     []SortKey
+	programs []cel.Program
+}
+
+// sortableKinds are the kinds of the results of expressions that can be sorted. Enum values are integers in CEL, so
+// they are sorted by number, which is the order in which the values are declared.
+var sortableKinds = []types.Kind{
+	types.BoolKind,
+	types.BytesKind,
+	types.DoubleKind,
+	types.DurationKind,
+	types.DynKind,
+	types.IntKind,
+	types.StringKind,
+	types.TimestampKind,
+	types.UintKind,
+}
+
+// NewObjectSorter creates a builder that can then be used to configure and create an object sorter.
+func NewObjectSorter() *ObjectSorterBuilder {
+	return &ObjectSorterBuilder{}
+}
+
+// SetLogger sets the logger that the sorter will use to write messages to the log. This is mandatory.
+func (b *ObjectSorterBuilder) SetLogger(value *slog.Logger) *ObjectSorterBuilder {
+	b.logger = value
+	return b
+}
+
+// SetHelper sets the helper for the type of objects that will be sorted. This is mandatory.
+func (b *ObjectSorterBuilder) SetHelper(value *reflection.ObjectHelper) *ObjectSorterBuilder {
+	b.helper = value
+	return b
+}
+
+// AddKey adds a sort key. Objects are sorted by the first key, then by the second key, and so on. At least one key is
+// mandatory.
+func (b *ObjectSorterBuilder) AddKey(value SortKey) *ObjectSorterBuilder {
+	b.keys = append(b.keys, value)
+	return b
+}
+
+// Build uses the data stored in the builder to create a new object sorter.
+func (b *ObjectSorterBuilder) Build() (result *ObjectSorter, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.helper == nil {
+		err = errors.New("helper is mandatory")
+		return
+	}
+	if len(b.keys) == 0 {
+		err = errors.New("at least one sort key is mandatory")
+		return
+	}
+
+	// Compile the expressions:
+	celEnv, err := newCelEnv(b.helper.Descriptor())
+	if err != nil {
+		return
+	}
+	programs := make([]cel.Program, len(b.keys))
+	for i, key := range b.keys {
+		ast, issues := celEnv.Compile(key.Expression)
+		err = issues.Err()
+		if err != nil {
+			err = fmt.Errorf("failed to compile sort expression %q: %w", key.Expression, err)
+			return
+		}
+		outputType := ast.OutputType()
+		if !slices.Contains(sortableKinds, outputType.Kind()) {
+			err = fmt.Errorf(
+				"sort expression %q returns values of type '%s', which can't be sorted",
+				key.Expression, outputType,
+			)
+			return
+		}
+		programs[i], err = celEnv.Program(ast)
+		if err != nil {
+			err = fmt.Errorf("failed to create CEL program from sort expression %q: %w", key.Expression, err)
+			return
+		}
+	}
+
+	// Create and populate the object:
+	result = &ObjectSorter{
+		logger:   b.logger,
+		keys:     slices.Clone(b.keys),
+		programs: programs,
+	}
+	return
+}
+
+// Sort sorts the given objects in place. The sort is stable, so objects that have the same values for all the keys
+// keep the order in which they were returned by the server.
+func (s *ObjectSorter) Sort(objects []proto.Message) error {
+	// Evaluate the expressions for all the objects before sorting, so that each expression is evaluated only once
+	// for each object:
+	type entry struct {
+		object proto.Message
+		values []ref.Val
+	}
+	entries := make([]entry, len(objects))
+	for i, object := range objects {
+		values, err := s.eval(object)
+		if err != nil {
+			return err
+		}
+		entries[i] = entry{
+			object: object,
+			values: values,
+		}
+	}
+
+	// Sort the entries and copy the result back to the slice of objects:
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		for i, key := range s.keys {
+			result := s.compare(a.values[i], b.values[i])
+			if result == 0 {
+				continue
+			}
+			if key.Descending {
+				result = -result
+			}
+			return result
+		}
+		return 0
+	})
+	for i, entry := range entries {
+		objects[i] = entry.object
+	}
+	return nil
+}
+
+// eval evaluates the expressions of the keys for the given object.
+func (s *ObjectSorter) eval(object proto.Message) (result []ref.Val, err error) {
+	celVars, err := cel.PartialVars(map[string]any{
+		"this": object,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to set variables for sort expressions: %w", err)
+		return
+	}
+	values := make([]ref.Val, len(s.programs))
+	for i, program := range s.programs {
+		values[i], _, err = program.Eval(celVars)
+		if err != nil {
+			err = fmt.Errorf("failed to evaluate sort expression %q: %w", s.keys[i].Expression, err)
+			return
+		}
+	}
+	result = values
+	return
+}
+
+// compare compares two values calculated by a sort expression. Null values go before any other value. Values that
+// can't be compared directly, for example because the expression returns different types for different objects, are
+// compared using their text representation.
+func (s *ObjectSorter) compare(a, b ref.Val) int {
+	aNull := a.Type() == types.NullType
+	bNull := b.Type() == types.NullType
+	switch {
+	case aNull && bNull:
+		return 0
+	case aNull:
+		return -1
+	case bNull:
+		return 1
+	}
+	comparer, ok := a.(traits.Comparer)
+	if ok {
+		result, ok := comparer.Compare(b).(types.Int)
+		if ok {
+			return int(result)
+		}
+	}
+	return strings.Compare(fmt.Sprintf("%v", a.Value()), fmt.Sprintf("%v", b.Value()))
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package rendering
+
+import (
+	"time"
+
+	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/innabox/fulfillment-cli/internal/reflection"
+)
+
+var _ = DescribeTable(
+	"Parse sort key",
+	func(text string, expected SortKey) {
+		actual, err := ParseSortKey(text)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(expected))
+	},
+	Entry(
+		"Ascending by default",
+		"this.id",
+		SortKey{Expression: "this.id"},
+	),
+	Entry(
+		"Minus prefix",
+		"-this.id",
+		SortKey{Expression: "this.id", Descending: true},
+	),
+	Entry(
+		"Descending suffix",
+		"this.id desc",
+		SortKey{Expression: "this.id", Descending: true},
+	),
+	Entry(
+		"Ascending suffix",
+		"this.id ASC",
+		SortKey{Expression: "this.id"},
+	),
+	Entry(
+		"Expression with spaces",
+		"size(this.metadata.name) desc",
+		SortKey{Expression: "size(this.metadata.name)", Descending: true},
+	),
+)
+
+var _ = Describe("Object sorter", func() {
+	var (
+		objectHelper *reflection.ObjectHelper
+		hosts        []proto.Message
+	)
+
+	BeforeEach(func() {
+		helper, err := makeHelper(logger, "127.0.0.1:1")
+		Expect(err).ToNot(HaveOccurred())
+		objectHelper = helper.Lookup("hosts")
+		Expect(objectHelper).ToNot(BeNil())
+
+		// Prepare hosts with different names, power states and creation times:
+		now := time.Now()
+		hosts = []proto.Message{
+			makeHost("1", "beta", ffv1.HostPowerState_HOST_POWER_STATE_OFF),
+			makeHost("2", "alpha", ffv1.HostPowerState_HOST_POWER_STATE_ON),
+			makeHost("3", "gamma", ffv1.HostPowerState_HOST_POWER_STATE_UNSPECIFIED),
+			makeHost("4", "alpha", ffv1.HostPowerState_HOST_POWER_STATE_OFF),
+		}
+		for i, host := range hosts {
+			host.(*ffv1.Host).GetMetadata().SetCreationTimestamp(
+				timestamppb.New(now.Add(time.Duration(i*i%3) * time.Hour)),
+			)
+		}
+	})
+
+	// sort sorts the hosts with the given keys and returns the resulting identifiers.
+	sort := func(keys ...string) []string {
+		builder := NewObjectSorter().
+			SetLogger(logger).
+			SetHelper(objectHelper)
+		for _, text := range keys {
+			key, err := ParseSortKey(text)
+			Expect(err).ToNot(HaveOccurred())
+			builder.AddKey(key)
+		}
+		sorter, err := builder.Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = sorter.Sort(hosts)
+		Expect(err).ToNot(HaveOccurred())
+		ids := make([]string, len(hosts))
+		for i, host := range hosts {
+			ids[i] = objectHelper.GetId(host)
+		}
+		return ids
+	}
+
+	It("Sorts by string keeping the original order of equal values", func() {
+		Expect(sort("this.metadata.name")).To(Equal([]string{"2", "4", "1", "3"}))
+	})
+
+	It("Sorts by string in descending order", func() {
+		Expect(sort("-this.metadata.name")).To(Equal([]string{"3", "1", "2", "4"}))
+	})
+
+	It("Sorts enums by number", func() {
+		Expect(sort("this.status.power_state")).To(Equal([]string{"3", "2", "1", "4"}))
+	})
+
+	It("Sorts by timestamp", func() {
+		// The offsets of the creation times are 0, 1, 1 and 0 hours:
+		Expect(sort("this.metadata.creation_timestamp desc", "this.id")).To(Equal([]string{"2", "3", "1", "4"}))
+	})
+
+	It("Sorts by multiple keys", func() {
+		Expect(sort("this.metadata.name", "this.id desc")).To(Equal([]string{"4", "2", "1", "3"}))
+	})
+
+	It("Rejects expressions that return values that can't be sorted", func() {
+		_, err := NewObjectSorter().
+			SetLogger(logger).
+			SetHelper(objectHelper).
+			AddKey(SortKey{Expression: "this.metadata"}).
+			Build()
+		Expect(err).To(MatchError(ContainSubstring("can't be sorted")))
+	})
+
+	It("Requires at least one key", func() {
+		_, err := NewObjectSorter().
+			SetLogger(logger).
+			SetHelper(objectHelper).
+			Build()
+		Expect(err).To(MatchError("at least one sort key is mandatory"))
+	})
+})
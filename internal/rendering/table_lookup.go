@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package rendering
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/innabox/fulfillment-cli/internal/reflection"
+)
+
+// DefaultLookupTtl is the time that the names obtained from the server are kept in the lookup cache when the TTL
+// isn't explicitly specified.
+const DefaultLookupTtl = time.Minute
+
+// lookupBatchSize is the maximum number of identifiers that are resolved with a single call to the list method.
+const lookupBatchSize = 100
+
+// LookupCacheBuilder is used to create lookup caches. Don't create instances of this type directly, use the
+// NewLookupCache function instead.
+type LookupCacheBuilder struct {
+	ttl time.Duration
+}
+
+// LookupCache stores the names of objects obtained from the server when rendering tables, so that they aren't
+// requested again. It is safe for concurrent use, and can be shared by multiple table renderers. Don't create instances
+// of this type directly, use the NewLookupCache function instead.
+type LookupCache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[protoreflect.FullName]map[string]lookupEntry
+}
+
+// lookupEntry is an entry of the lookup cache.
+type lookupEntry struct {
+	name   string
+	expiry time.Time
+}
+
+// NewLookupCache creates a new builder for lookup caches.
+func NewLookupCache() *LookupCacheBuilder {
+	return &LookupCacheBuilder{
+		ttl: DefaultLookupTtl,
+	}
+}
+
+// SetTtl sets the time that the names are kept in the cache. The default is one minute.
+func (b *LookupCacheBuilder) SetTtl(value time.Duration) *LookupCacheBuilder {
+	b.ttl = value
+	return b
+}
+
+// Build uses the data stored in the builder to create a new lookup cache.
+func (b *LookupCacheBuilder) Build() (result *LookupCache, err error) {
+	// Check parameters:
+	if b.ttl <= 0 {
+		err = fmt.Errorf("TTL should be positive, but it is %s", b.ttl)
+		return
+	}
+
+	// Create and populate the object:
+	result = &LookupCache{
+		ttl:     b.ttl,
+		entries: map[protoreflect.FullName]map[string]lookupEntry{},
+	}
+	return
+}
+
+// get returns the name that corresponds to the given key, and a flag indicating if it was in the cache and it hasn't
+// expired yet.
+func (c *LookupCache) get(objectType protoreflect.FullName, key string) (result string, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entries := c.entries[objectType]
+	entry, ok := entries[key]
+	if !ok {
+		return
+	}
+	if time.Now().After(entry.expiry) {
+		delete(entries, key)
+		ok = false
+		return
+	}
+	result = entry.name
+	return
+}
+
+// set saves the name that corresponds to the given key.
+func (c *LookupCache) set(objectType protoreflect.FullName, key string, name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entries, ok := c.entries[objectType]
+	if !ok {
+		entries = map[string]lookupEntry{}
+		c.entries[objectType] = entries
+	}
+	entries[key] = lookupEntry{
+		name:   name,
+		expiry: time.Now().Add(c.ttl),
+	}
+}
+
+// resolveLookups finds the keys used by the lookup columns of the given rows that aren't in the cache yet, and gets
+// the corresponding names from the server, with one call to the list method for each object type. The calls for
+// different object types are done concurrently.
+func (r *TableRenderer) resolveLookups(ctx context.Context, table *compiledTable, rows [][]ref.Val) {
+	// Collect the keys that aren't in the cache, grouped by object type:
+	pending := map[protoreflect.FullName][]string{}
+	seen := map[protoreflect.FullName]map[string]bool{}
+	for i, col := range table.columns {
+		if !col.Lookup || col.Type == "" {
+			continue
+		}
+		for _, row := range rows {
+			value, ok := row[i].(types.String)
+			if !ok || value == "" {
+				continue
+			}
+			key := string(value)
+			if seen[col.Type][key] {
+				continue
+			}
+			if seen[col.Type] == nil {
+				seen[col.Type] = map[string]bool{}
+			}
+			seen[col.Type][key] = true
+			_, ok = r.lookupCache.get(col.Type, key)
+			if ok {
+				continue
+			}
+			pending[col.Type] = append(pending[col.Type], key)
+		}
+	}
+
+	// Resolve the keys of each type concurrently:
+	var group sync.WaitGroup
+	for objectType, keys := range pending {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for start := 0; start < len(keys); start += lookupBatchSize {
+				end := min(start+lookupBatchSize, len(keys))
+				r.resolveNames(ctx, objectType, keys[start:end])
+			}
+		}()
+	}
+	group.Wait()
+}
+
+// resolveNames gets from the server the names of the objects of the given type whose identifiers or names match the
+// given keys, and saves them in the cache. Keys that don't match any object are saved as is, so that they aren't
+// requested again.
+func (r *TableRenderer) resolveNames(ctx context.Context, objectType protoreflect.FullName, keys []string) {
+	// Find the object helper:
+	helper := r.helper.Lookup(string(objectType))
+	if helper == nil {
+		r.logger.ErrorContext(
+			ctx,
+			"Failed to find object helper for type",
+			slog.String("type", string(objectType)),
+		)
+		return
+	}
+
+	// Find the objects whose identifier or name matches any of the keys:
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = strconv.Quote(key)
+	}
+	filter := fmt.Sprintf(
+		"this.id in [%[1]s] || this.metadata.name in [%[1]s]",
+		strings.Join(values, ", "),
+	)
+	names := map[string]string{}
+	iterator := helper.Iterator(reflection.IteratorOptions{
+		Filter: filter,
+	})
+	for !iterator.Done() {
+		page, err := iterator.Next(ctx)
+		if err != nil {
+			r.logger.ErrorContext(
+				ctx,
+				"Failed to list objects for lookup",
+				slog.String("type", string(objectType)),
+				slog.Any("keys", keys),
+				slog.Any("error", err),
+			)
+			return
+		}
+		for _, object := range page {
+			name := helper.GetMetadata(object).GetName()
+			if name == "" {
+				continue
+			}
+			id := helper.GetId(object)
+			if _, ok := names[id]; !ok {
+				names[id] = name
+			}
+			if _, ok := names[name]; !ok {
+				names[name] = name
+			}
+		}
+	}
+
+	// Save the results in the cache, including the keys that don't match any object:
+	for _, key := range keys {
+		name, ok := names[key]
+		if !ok {
+			name = key
+		}
+		r.lookupCache.set(objectType, key, name)
+	}
+}
@@ -24,7 +24,6 @@ import (
 	"reflect"
 	"slices"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common/types"
@@ -76,6 +75,18 @@ type columnLayout struct {
 	Lookup bool `yaml:"lookup,omitempty"`
 }
 
+// compiledTable is a table layout where the CEL expressions of the columns have already been compiled, so that they
+// can be evaluated repeatedly without compiling them again.
+type compiledTable struct {
+	helper   *reflection.ObjectHelper
+	columns  []*columnLayout
+	programs []cel.Program
+}
+
+// DefaultBufferSize is the number of rows that a table stream keeps in memory to calculate the widths of the columns
+// when the buffer size isn't explicitly specified.
+const DefaultBufferSize = 100
+
 // TableRendererBuilder is used to create table renderers. Don't create instances of this type directly, use the
 // NewTableRenderer function instead.
 type TableRendererBuilder struct {
@@ -83,6 +94,8 @@ type TableRendererBuilder struct {
 	helper         *reflection.Helper
 	writer         io.Writer
 	includeDeleted bool
+	bufferSize     int
+	lookupCache    *LookupCache
 }
 
 // TableRenderer is responsible for rendering protocol buffer messages as tables. Don't create instances of this type
@@ -90,14 +103,18 @@ type TableRendererBuilder struct {
 type TableRenderer struct {
 	logger         *slog.Logger
 	helper         *reflection.Helper
-	writer         *tabwriter.Writer
-	cache          map[protoreflect.FullName]map[string]string
+	writer         io.Writer
+	lookupCache    *LookupCache
+	tables         map[protoreflect.FullName]*compiledTable
 	includeDeleted bool
+	bufferSize     int
 }
 
 // NewTableRenderer creates a new builder for table renderers.
 func NewTableRenderer() *TableRendererBuilder {
-	return &TableRendererBuilder{}
+	return &TableRendererBuilder{
+		bufferSize: DefaultBufferSize,
+	}
 }
 
 // SetLogger sets the logger that the renderer will use to write messages to the log. This is mandatory.
@@ -124,6 +141,21 @@ func (b *TableRendererBuilder) SetIncludeDeleted(value bool) *TableRendererBuild
 	return b
 }
 
+// SetBufferSize sets the number of rows that streams created by the renderer keep in memory before writing them. The
+// widths of the columns are calculated from those rows, and don't change after that. The default is 100.
+func (b *TableRendererBuilder) SetBufferSize(value int) *TableRendererBuilder {
+	b.bufferSize = value
+	return b
+}
+
+// SetLookupCache sets the cache that will be used to store the names of the objects referenced by lookup columns. This
+// is optional. It is useful to share the same cache between multiple renderers, so that the names aren't requested
+// again. If not set a new cache will be created with the default TTL.
+func (b *TableRendererBuilder) SetLookupCache(value *LookupCache) *TableRendererBuilder {
+	b.lookupCache = value
+	return b
+}
+
 // Build uses the data stored in the builder to create a new table renderer.
 func (b *TableRendererBuilder) Build() (result *TableRenderer, err error) {
 	// Check parameters:
@@ -139,26 +171,40 @@ func (b *TableRendererBuilder) Build() (result *TableRenderer, err error) {
 		err = fmt.Errorf("writer is mandatory")
 		return
 	}
+	if b.bufferSize <= 0 {
+		err = fmt.Errorf("buffer size should be positive, but it is %d", b.bufferSize)
+		return
+	}
 
-	// Create a tab writer for proper column alignment of output:
-	writer := tabwriter.NewWriter(b.writer, 0, 0, 2, ' ', 0)
+	// Create the lookup cache if needed:
+	lookupCache := b.lookupCache
+	if lookupCache == nil {
+		lookupCache, err = NewLookupCache().Build()
+		if err != nil {
+			err = fmt.Errorf("failed to create lookup cache: %w", err)
+			return
+		}
+	}
 
-	// Create the cache:
-	cache := map[protoreflect.FullName]map[string]string{}
+	// Create the cache of compiled tables:
+	tables := map[protoreflect.FullName]*compiledTable{}
 
 	// Create and populate the object:
 	result = &TableRenderer{
 		logger:         b.logger,
 		helper:         b.helper,
-		writer:         writer,
-		cache:          cache,
+		writer:         b.writer,
+		lookupCache:    lookupCache,
+		tables:         tables,
 		includeDeleted: b.includeDeleted,
+		bufferSize:     b.bufferSize,
 	}
 	return
 }
 
 // Render renders the given objects as a table to stdout. The objects parameter must be a slice of objects that
-// implement the proto.Message interface.
+// implement the proto.Message interface. The widths of the columns are calculated from all the objects, so this
+// should be used only when all of them are already in memory. Use the Stream method otherwise.
 func (r *TableRenderer) Render(ctx context.Context, objects any) error {
 	// Check that the objects parameter is a slice of objects that implement the proto.Message interface:
 	list := reflect.ValueOf(objects)
@@ -178,17 +224,50 @@ func (r *TableRenderer) Render(ctx context.Context, objects any) error {
 		messages[i] = list.Index(i).Interface().(proto.Message)
 	}
 
-	// Get the object helper from the first object:
-	descriptor := messages[0].ProtoReflect().Descriptor()
+	// Render all the messages with a stream that keeps all of them in the buffer, so that the widths of the
+	// columns are calculated from all of them:
+	stream := &TableStream{
+		renderer:   r,
+		bufferSize: len(messages),
+	}
+	err := stream.Write(ctx, messages)
+	if err != nil {
+		return err
+	}
+	return stream.Flush()
+}
+
+// Stream creates a stream that renders objects as a table page by page, as they are received. The first rows are kept
+// in memory till the buffer size is reached, and then the widths of the columns are calculated from them. After that
+// rows are written as soon as they are received, and the widths of the columns don't change. All the objects sent to
+// the stream must be of the same type.
+func (r *TableRenderer) Stream() *TableStream {
+	return &TableStream{
+		renderer:   r,
+		bufferSize: r.bufferSize,
+	}
+}
+
+// compileTable returns the compiled layout of the table for the given object type. The result is cached, so the CEL
+// expressions are compiled only once for each type.
+func (r *TableRenderer) compileTable(descriptor protoreflect.MessageDescriptor) (result *compiledTable, err error) {
+	// Return the cached result if available:
+	result, ok := r.tables[descriptor.FullName()]
+	if ok {
+		return
+	}
+
+	// Get the object helper:
 	helper := r.helper.Lookup(string(descriptor.FullName()))
 	if helper == nil {
-		return fmt.Errorf("failed to find object helper for type %q", descriptor.FullName())
+		err = fmt.Errorf("failed to find object helper for type %q", descriptor.FullName())
+		return
 	}
 
 	// Try to load the table definition for this object type:
 	table, err := r.loadTable(helper)
 	if err != nil {
-		return err
+		return
 	}
 	if table == nil {
 		table = r.defaultTable()
@@ -207,13 +286,9 @@ func (r *TableRenderer) Render(ctx context.Context, objects any) error {
 	thisDesc := helper.Descriptor()
 
 	// Build CEL environment:
-	celEnv, err := cel.NewEnv(
-		cel.Types(dynamicpb.NewMessage(thisDesc)),
-		cel.Variable("this", cel.ObjectType(string(thisDesc.FullName()))),
-		ext.Strings(),
-	)
+	celEnv, err := newCelEnv(thisDesc)
 	if err != nil {
-		return fmt.Errorf("failed to create CEL environment: %w", err)
+		return
 	}
 
 	// Compile the CEL expressions for the columns:
@@ -222,35 +297,46 @@ func (r *TableRenderer) Render(ctx context.Context, objects any) error {
 		ast, issues := celEnv.Compile(col.Value)
 		err = issues.Err()
 		if err != nil {
-			return fmt.Errorf(
+			err = fmt.Errorf(
 				"failed to compile CEL expression %q for column %q of type %q: %w",
 				col.Value, col.Header, helper, err,
 			)
+			return
 		}
-		prg, err := celEnv.Program(ast)
+		var prg cel.Program
+		prg, err = celEnv.Program(ast)
 		if err != nil {
-			return fmt.Errorf(
+			err = fmt.Errorf(
 				"failed to create CEL program from expression %q for column %q of type %q: %w",
 				col.Value, col.Header, helper, err,
 			)
+			return
 		}
 		prgs[i] = prg
 	}
 
-	// Render the table and remember to flush the writer when done:
-	defer r.writer.Flush()
-	err = r.renderHeader(table.Columns)
-	if err != nil {
-		return err
-	}
-	for _, message := range messages {
-		err := r.renderRow(ctx, table.Columns, prgs, message, helper)
-		if err != nil {
-			return err
-		}
+	// Save the result in the cache:
+	result = &compiledTable{
+		helper:   helper,
+		columns:  table.Columns,
+		programs: prgs,
 	}
+	r.tables[descriptor.FullName()] = result
+	return
+}
 
-	return nil
+// newCelEnv creates the CEL environment used to evaluate expressions for objects of the given type. The object is
+// available in the `this` variable.
+func newCelEnv(thisDesc protoreflect.MessageDescriptor) (result *cel.Env, err error) {
+	result, err = cel.NewEnv(
+		cel.Types(dynamicpb.NewMessage(thisDesc)),
+		cel.Variable("this", cel.ObjectType(string(thisDesc.FullName()))),
+		ext.Strings(),
+	)
+	if err != nil {
+		err = fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	return
 }
 
 // loadTable loads the table definition for the given object type from the embedded filesystem.
@@ -293,66 +379,58 @@ func (r *TableRenderer) defaultTable() *tableLayout {
 	}
 }
 
-// renderHeader renders the table header with column names.
-func (r *TableRenderer) renderHeader(cols []*columnLayout) error {
-	for i, col := range cols {
-		if i > 0 {
-			fmt.Fprint(r.writer, "\t")
-		}
-		fmt.Fprintf(r.writer, "%s", col.Header)
+// renderHeader returns the texts of the headers of the columns.
+func (r *TableRenderer) renderHeader(table *compiledTable) []string {
+	result := make([]string, len(table.columns))
+	for i, col := range table.columns {
+		result[i] = col.Header
 	}
-	fmt.Fprintf(r.writer, "\n")
-	return nil
+	return result
 }
 
-// renderRow renders a single row of the table.
-func (r *TableRenderer) renderRow(ctx context.Context, cols []*columnLayout, prgs []cel.Program, object proto.Message,
-	helper *reflection.ObjectHelper) error {
+// evalRow evaluates the CEL expressions of the columns for a single row of the table.
+func (r *TableRenderer) evalRow(table *compiledTable, object proto.Message) (result []ref.Val, err error) {
 	// Wrap the object in a top-level "this" field to avoid conflicts with reserved words:
 	in := map[string]any{
 		"this": object,
 	}
 	celVars, err := cel.PartialVars(in)
 	if err != nil {
-		return fmt.Errorf(
+		err = fmt.Errorf(
 			"failed to set variables for CEL expression for type %q: %w",
-			helper, err,
+			table.helper, err,
 		)
+		return
 	}
 
-	// Render each column:
-	for i := range len(cols) {
-		if i > 0 {
-			fmt.Fprintf(r.writer, "\t")
-		}
-		col := cols[i]
-		prg := prgs[i]
-
-		// Evaluate the CEL expression:
-		var out ref.Val
-		out, _, err = prg.Eval(celVars)
+	// Evaluate the expression of each column:
+	values := make([]ref.Val, len(table.columns))
+	for i, col := range table.columns {
+		values[i], _, err = table.programs[i].Eval(celVars)
 		if err != nil {
-			return fmt.Errorf(
+			err = fmt.Errorf(
 				"failed to evaluate CEL expression %q for column %q of type %q: %w",
-				col.Value, col.Header, helper, err,
+				col.Value, col.Header, table.helper, err,
 			)
+			return
 		}
+	}
+	result = values
+	return
+}
 
-		// Render the cell value:
-		err = r.renderCell(ctx, col, out)
-		if err != nil {
-			return fmt.Errorf(
-				"failed to render value %q for column %q of type %q: %w",
-				out, col.Header, helper, err,
-			)
-		}
+// renderRow returns the texts of the cells of a single row of the table, from the values of the CEL expressions. The
+// names for the lookup columns should have been resolved before calling this.
+func (r *TableRenderer) renderRow(table *compiledTable, values []ref.Val) []string {
+	result := make([]string, len(table.columns))
+	for i, col := range table.columns {
+		result[i] = r.renderCell(col, values[i])
 	}
-	fmt.Fprintf(r.writer, "\n")
-	return nil
+	return result
 }
 
-// renderCell renders a single cell in the table.
-func (r *TableRenderer) renderCell(ctx context.Context, col *columnLayout, val ref.Val) error {
+// renderCell returns the text of a single cell in the table.
+func (r *TableRenderer) renderCell(col *columnLayout, val ref.Val) string {
 	switch val := val.(type) {
 	case types.Int:
 		if col.Type != "" {
@@ -369,7 +447,7 @@ func (r *TableRenderer) renderCell(ctx context.Context, col *columnLayout, val r
 		if col.Lookup && col.Type != "" {
 			messageType, _ := protoregistry.GlobalTypes.FindMessageByName(col.Type)
 			if messageType != nil {
-				return r.renderCellLookup(ctx, val, messageType.Descriptor())
+				return r.renderCellLookup(val, messageType.Descriptor())
 			}
 		}
 	}
@@ -377,15 +455,12 @@ func (r *TableRenderer) renderCell(ctx context.Context, col *columnLayout, val r
 }
 
 // renderCellEnum renders an enum value as a string.
-func (r *TableRenderer) renderCellEnum(val types.Int, enumDesc protoreflect.EnumDescriptor) error {
+func (r *TableRenderer) renderCellEnum(val types.Int, enumDesc protoreflect.EnumDescriptor) string {
 	// Get the text of the name of the enum value:
 	valueDescs := enumDesc.Values()
 	valueDesc := valueDescs.ByNumber(protoreflect.EnumNumber(val))
 	if valueDesc == nil {
-		_, err := fmt.Fprintf(r.writer, "UNKNOWN:%d", val)
-		if err != nil {
-			return err
-		}
+		return fmt.Sprintf("UNKNOWN:%d", val)
 	}
 	valueTxt := string(valueDesc.Name())
 
@@ -403,89 +478,24 @@ func (r *TableRenderer) renderCellEnum(val types.Int, enumDesc protoreflect.Enum
 		}
 	}
 
-	_, err := fmt.Fprintf(r.writer, "%s", valueTxt)
-	return err
+	return valueTxt
 }
 
-// renderCellLookup renders a lookup value (identifier to name translation).
-func (r *TableRenderer) renderCellLookup(ctx context.Context, val types.String,
-	messageDesc protoreflect.MessageDescriptor) error {
+// renderCellLookup renders a lookup value (identifier to name translation). The name is taken from the lookup cache,
+// and if it isn't there, because it couldn't be obtained from the server, the identifier is used instead.
+func (r *TableRenderer) renderCellLookup(val types.String, messageDesc protoreflect.MessageDescriptor) string {
 	key := string(val)
-	var text string
-	if key != "" {
-		text = r.lookupName(ctx, messageDesc.FullName(), key)
-	} else {
-		text = "-"
-	}
-	_, err := fmt.Fprintf(r.writer, "%s", text)
-	return err
-}
-
-// lookupName looks up a name from an identifier.
-func (r *TableRenderer) lookupName(ctx context.Context, messageFullName protoreflect.FullName,
-	key string) (result string) {
-	// Check if the result is already in the cache and return it immediately if so, otherwise
-	// remember to update the cache when done:
-	cache, ok := r.cache[messageFullName]
-	if !ok {
-		cache = map[string]string{}
-		r.cache[messageFullName] = cache
+	if key == "" {
+		return "-"
 	}
-	result, ok = cache[key]
-	if ok {
-		return result
-	}
-	defer func() {
-		cache[key] = result
-	}()
-
-	// Find the object helper:
-	helper := r.helper.Lookup(string(messageFullName))
-	if helper == nil {
-		r.logger.ErrorContext(
-			ctx,
-			"Failed to find object helper for type",
-			slog.String("type", string(messageFullName)),
-		)
-		result = key
-		return
-	}
-
-	// Find the objects whose identifier or name matches the key:
-	filter := fmt.Sprintf(
-		"this.id == %[1]q || this.metadata.name == %[1]q",
-		key,
-	)
-	listResult, err := helper.List(ctx, reflection.ListOptions{
-		Filter: filter,
-	})
-	if err != nil {
-		r.logger.ErrorContext(
-			ctx,
-			"Failed to list objects for lookup",
-			slog.String("type", string(messageFullName)),
-			slog.String("key", key),
-			slog.Any("error", err),
-		)
-		result = key
-		return
-	}
-
-	// If there is no match, or multiple matches, return the original key:
-	if len(listResult.Items) == 0 {
-		result = key
-		return
+	name, ok := r.lookupCache.get(messageDesc.FullName(), key)
+	if !ok {
+		return key
 	}
-
-	// Return the name of the first object:
-	object := listResult.Items[0]
-	metadata := helper.GetMetadata(object)
-	result = metadata.GetName()
-	return
+	return name
 }
 
 // renderCellAny renders any value type as a string.
-func (r *TableRenderer) renderCellAny(val ref.Val) error {
-	_, err := fmt.Fprintf(r.writer, "%s", val)
-	return err
+func (r *TableRenderer) renderCellAny(val ref.Val) string {
+	return fmt.Sprintf("%s", val)
 }
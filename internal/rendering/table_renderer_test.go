@@ -0,0 +1,426 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package rendering
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	sharedv1 "github.com/innabox/fulfillment-common/api/shared/v1"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/innabox/fulfillment-cli/internal/reflection"
+	. "github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+// makeHelper creates a reflection helper that connects to the given address. The connection is created lazily, so if
+// the tables rendered don't need to look up objects the address doesn't need to exist.
+func makeHelper(logger *slog.Logger, address string) (result *reflection.Helper, err error) {
+	connection, err := grpc.NewClient(
+		address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return
+	}
+	result, err = reflection.NewHelper().
+		SetLogger(logger).
+		SetConnection(connection).
+		AddPackage("fulfillment.v1", 1).
+		Build()
+	return
+}
+
+// makeHost creates a host with the given identifier, name and power state.
+func makeHost(id, name string, state ffv1.HostPowerState) *ffv1.Host {
+	return ffv1.Host_builder{
+		Id: id,
+		Metadata: sharedv1.Metadata_builder{
+			Name: name,
+		}.Build(),
+		Status: ffv1.HostStatus_builder{
+			PowerState: state,
+		}.Build(),
+	}.Build()
+}
+
+var _ = Describe("Table renderer", func() {
+	var (
+		ctx    context.Context
+		helper *reflection.Helper
+		output *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		var err error
+		ctx = context.Background()
+		helper, err = makeHelper(logger, "127.0.0.1:1")
+		Expect(err).ToNot(HaveOccurred())
+		output = &bytes.Buffer{}
+	})
+
+	// makeRenderer creates a renderer that writes to the output buffer, and with the given buffer size.
+	makeRenderer := func(bufferSize int) *TableRenderer {
+		renderer, err := NewTableRenderer().
+			SetLogger(logger).
+			SetHelper(helper).
+			SetWriter(output).
+			SetBufferSize(bufferSize).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		return renderer
+	}
+
+	It("Aligns the columns to the widest cell", func() {
+		renderer := makeRenderer(DefaultBufferSize)
+		err := renderer.Render(ctx, []*ffv1.Host{
+			makeHost("123", "my-host", ffv1.HostPowerState_HOST_POWER_STATE_ON),
+			makeHost("4567890", "a", ffv1.HostPowerState_HOST_POWER_STATE_OFF),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(Equal(
+			"ID       NAME     POWER STATE\n" +
+				"123      my-host  ON\n" +
+				"4567890  a        OFF\n",
+		))
+	})
+
+	It("Renders unknown enum values", func() {
+		renderer := makeRenderer(DefaultBufferSize)
+		err := renderer.Render(ctx, []*ffv1.Host{
+			makeHost("123", "my-host", ffv1.HostPowerState(42)),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(ContainSubstring("UNKNOWN:42"))
+	})
+
+	It("Compiles the layout only once for each type", func() {
+		renderer := makeRenderer(DefaultBufferSize)
+		objects := []*ffv1.Host{
+			makeHost("123", "my-host", ffv1.HostPowerState_HOST_POWER_STATE_ON),
+		}
+		err := renderer.Render(ctx, objects)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(renderer.tables).To(HaveLen(1))
+		table := renderer.tables["fulfillment.v1.Host"]
+		Expect(table).ToNot(BeNil())
+		err = renderer.Render(ctx, objects)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(renderer.tables["fulfillment.v1.Host"]).To(BeIdenticalTo(table))
+	})
+
+	It("Rejects a buffer size that isn't positive", func() {
+		_, err := NewTableRenderer().
+			SetLogger(logger).
+			SetHelper(helper).
+			SetWriter(output).
+			SetBufferSize(0).
+			Build()
+		Expect(err).To(MatchError("buffer size should be positive, but it is 0"))
+	})
+
+	Describe("Stream", func() {
+		It("Doesn't write anything till the buffer is full", func() {
+			renderer := makeRenderer(3)
+			stream := renderer.Stream()
+			err := stream.Write(ctx, []proto.Message{
+				makeHost("123", "my-host", ffv1.HostPowerState_HOST_POWER_STATE_ON),
+				makeHost("456", "your-host", ffv1.HostPowerState_HOST_POWER_STATE_OFF),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(BeEmpty())
+			err = stream.Flush()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(Equal(
+				"ID   NAME       POWER STATE\n" +
+					"123  my-host    ON\n" +
+					"456  your-host  OFF\n",
+			))
+			Expect(stream.Count()).To(Equal(2))
+		})
+
+		It("Writes the rows immediately once the buffer is full", func() {
+			renderer := makeRenderer(1)
+			stream := renderer.Stream()
+			err := stream.Write(ctx, []proto.Message{
+				makeHost("123", "my-host", ffv1.HostPowerState_HOST_POWER_STATE_ON),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(Equal(
+				"ID   NAME     POWER STATE\n" +
+					"123  my-host  ON\n",
+			))
+			err = stream.Write(ctx, []proto.Message{
+				makeHost("456", "other", ffv1.HostPowerState_HOST_POWER_STATE_OFF),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(HaveSuffix("456  other    OFF\n"))
+		})
+
+		It("Keeps the widths calculated from the buffer", func() {
+			renderer := makeRenderer(1)
+			stream := renderer.Stream()
+			err := stream.Write(ctx, []proto.Message{
+				makeHost("123", "my-host", ffv1.HostPowerState_HOST_POWER_STATE_ON),
+				makeHost("456", "a-much-longer-name", ffv1.HostPowerState_HOST_POWER_STATE_OFF),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			err = stream.Flush()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(Equal(
+				"ID   NAME     POWER STATE\n" +
+					"123  my-host  ON\n" +
+					"456  a-much-longer-name  OFF\n",
+			))
+		})
+
+		It("Doesn't write anything if there are no objects", func() {
+			renderer := makeRenderer(DefaultBufferSize)
+			stream := renderer.Stream()
+			err := stream.Write(ctx, nil)
+			Expect(err).ToNot(HaveOccurred())
+			err = stream.Flush()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.String()).To(BeEmpty())
+			Expect(stream.Count()).To(BeZero())
+		})
+
+		It("Rejects objects of different types", func() {
+			renderer := makeRenderer(DefaultBufferSize)
+			stream := renderer.Stream()
+			err := stream.Write(ctx, []proto.Message{
+				makeHost("123", "my-host", ffv1.HostPowerState_HOST_POWER_STATE_ON),
+				ffv1.Cluster_builder{
+					Id: "456",
+				}.Build(),
+			})
+			Expect(err).To(MatchError(
+				`object of type "fulfillment.v1.Cluster" can't be rendered in a table of type ` +
+					`"fulfillment.v1.Host"`,
+			))
+		})
+	})
+})
+
+var _ = Describe("Table renderer lookups", func() {
+	var (
+		ctx       context.Context
+		helper    *reflection.Helper
+		output    *bytes.Buffer
+		instances []*ffv1.ComputeInstance
+		filters   []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		output = &bytes.Buffer{}
+		filters = nil
+
+		// Start a server that returns the templates, ignoring the filter, but remembering it so that we can check
+		// how many requests were sent and what they contained:
+		templates := []*ffv1.ComputeInstanceTemplate{
+			ffv1.ComputeInstanceTemplate_builder{
+				Id: "tpl-1",
+				Metadata: sharedv1.Metadata_builder{
+					Name: "small",
+				}.Build(),
+			}.Build(),
+			ffv1.ComputeInstanceTemplate_builder{
+				Id: "tpl-2",
+				Metadata: sharedv1.Metadata_builder{
+					Name: "large",
+				}.Build(),
+			}.Build(),
+		}
+		var lock sync.Mutex
+		server := NewServer()
+		DeferCleanup(server.Stop)
+		ffv1.RegisterComputeInstanceTemplatesServer(server.Registrar(), &ComputeInstanceTemplatesServerFuncs{
+			ListFunc: func(ctx context.Context, request *ffv1.ComputeInstanceTemplatesListRequest) (
+				*ffv1.ComputeInstanceTemplatesListResponse, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				filters = append(filters, request.GetFilter())
+				return ffv1.ComputeInstanceTemplatesListResponse_builder{
+					Size:  proto.Int32(int32(len(templates))),
+					Total: proto.Int32(int32(len(templates))),
+					Items: templates,
+				}.Build(), nil
+			},
+		})
+		server.Start()
+
+		// Create the helper:
+		var err error
+		helper, err = makeHelper(logger, server.Address())
+		Expect(err).ToNot(HaveOccurred())
+
+		// Prepare instances that reference the templates, some of them more than once, and one template that
+		// doesn't exist:
+		for i, template := range []string{"tpl-1", "tpl-2", "tpl-1", "missing"} {
+			instances = append(instances, ffv1.ComputeInstance_builder{
+				Id: fmt.Sprintf("ci-%d", i),
+				Spec: ffv1.ComputeInstanceSpec_builder{
+					Template: template,
+				}.Build(),
+			}.Build())
+		}
+	})
+
+	AfterEach(func() {
+		instances = nil
+	})
+
+	// makeRenderer creates a renderer that writes to the output buffer, and uses the given lookup cache.
+	makeRenderer := func(cache *LookupCache) *TableRenderer {
+		renderer, err := NewTableRenderer().
+			SetLogger(logger).
+			SetHelper(helper).
+			SetWriter(output).
+			SetLookupCache(cache).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		return renderer
+	}
+
+	It("Resolves all the names with a single request", func() {
+		renderer := makeRenderer(nil)
+		err := renderer.Render(ctx, instances)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filters).To(HaveLen(1))
+		Expect(filters[0]).To(Equal(
+			`this.id in ["tpl-1", "tpl-2", "missing"] || ` +
+				`this.metadata.name in ["tpl-1", "tpl-2", "missing"]`,
+		))
+		lines := strings.Split(output.String(), "\n")
+		Expect(lines[1]).To(MatchRegexp(`^ci-0 .* small `))
+		Expect(lines[2]).To(MatchRegexp(`^ci-1 .* large `))
+		Expect(lines[3]).To(MatchRegexp(`^ci-2 .* small `))
+		Expect(lines[4]).To(MatchRegexp(`^ci-3 .* missing `))
+	})
+
+	It("Doesn't request again names that are in the cache", func() {
+		renderer := makeRenderer(nil)
+		stream := renderer.Stream()
+		err := stream.Write(ctx, []proto.Message{instances[0]})
+		Expect(err).ToNot(HaveOccurred())
+		err = stream.Write(ctx, []proto.Message{instances[1], instances[2]})
+		Expect(err).ToNot(HaveOccurred())
+		err = stream.Flush()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filters).To(HaveLen(2))
+		Expect(filters[1]).To(HavePrefix(`this.id in ["tpl-2"]`))
+	})
+
+	It("Shares the cache between renderers", func() {
+		cache, err := NewLookupCache().Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = makeRenderer(cache).Render(ctx, instances)
+		Expect(err).ToNot(HaveOccurred())
+		err = makeRenderer(cache).Render(ctx, instances)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filters).To(HaveLen(1))
+	})
+
+	It("Requests the names again when they expire", func() {
+		cache, err := NewLookupCache().
+			SetTtl(time.Nanosecond).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		err = makeRenderer(cache).Render(ctx, instances)
+		Expect(err).ToNot(HaveOccurred())
+		time.Sleep(time.Millisecond)
+		err = makeRenderer(cache).Render(ctx, instances)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filters).To(HaveLen(2))
+	})
+
+	It("Rejects a TTL that isn't positive", func() {
+		_, err := NewLookupCache().
+			SetTtl(0).
+			Build()
+		Expect(err).To(MatchError("TTL should be positive, but it is 0s"))
+	})
+})
+
+// BenchmarkTableRenderer compares rendering a large number of objects all at once with rendering them page by page
+// with a stream.
+func BenchmarkTableRenderer(b *testing.B) {
+	const (
+		objectCount = 50000
+		pageSize    = DefaultBufferSize
+	)
+	logger := slog.New(slog.DiscardHandler)
+	helper, err := makeHelper(logger, "127.0.0.1:1")
+	if err != nil {
+		b.Fatal(err)
+	}
+	objects := make([]proto.Message, objectCount)
+	for i := range objects {
+		objects[i] = makeHost(
+			fmt.Sprintf("%08d", i),
+			fmt.Sprintf("host-%d", i),
+			ffv1.HostPowerState(i%3),
+		)
+	}
+	makeRenderer := func() *TableRenderer {
+		renderer, err := NewTableRenderer().
+			SetLogger(logger).
+			SetHelper(helper).
+			SetWriter(&bytes.Buffer{}).
+			Build()
+		if err != nil {
+			b.Fatal(err)
+		}
+		return renderer
+	}
+
+	b.Run("Render", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			err := makeRenderer().Render(context.Background(), objects)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			stream := makeRenderer().Stream()
+			for i := 0; i < len(objects); i += pageSize {
+				err := stream.Write(context.Background(), objects[i:min(i+pageSize, len(objects))])
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			err := stream.Flush()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
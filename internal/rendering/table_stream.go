@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package rendering
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/cel-go/common/types/ref"
+	"google.golang.org/protobuf/proto"
+)
+
+// columnPadding is the number of spaces added after the text of each column, except the last one.
+const columnPadding = 2
+
+// TableStream renders objects as a table page by page. Don't create instances of this type directly, use the Stream
+// method of the table renderer instead.
+type TableStream struct {
+	renderer   *TableRenderer
+	bufferSize int
+	table      *compiledTable
+	rows       [][]string
+	widths     []int
+	count      int
+}
+
+// Write renders the given objects. The rows are kept in memory till the buffer is full, and written immediately after
+// that. The names needed by the lookup columns are obtained from the server for all the objects at once.
+func (s *TableStream) Write(ctx context.Context, objects []proto.Message) error {
+	// Evaluate the expressions of the columns for all the objects:
+	values := make([][]ref.Val, len(objects))
+	for i, object := range objects {
+		// Compile the table when we receive the first object, as that is when we know the type:
+		descriptor := object.ProtoReflect().Descriptor()
+		if s.table == nil {
+			table, err := s.renderer.compileTable(descriptor)
+			if err != nil {
+				return err
+			}
+			s.table = table
+			s.rows = append(s.rows, s.renderer.renderHeader(table))
+		}
+		if descriptor.FullName() != s.table.helper.FullName() {
+			return fmt.Errorf(
+				"object of type %q can't be rendered in a table of type %q",
+				descriptor.FullName(), s.table.helper.FullName(),
+			)
+		}
+		var err error
+		values[i], err = s.renderer.evalRow(s.table, object)
+		if err != nil {
+			return err
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	// Get the names needed by the lookup columns:
+	s.renderer.resolveLookups(ctx, s.table, values)
+
+	// Render the rows:
+	for _, value := range values {
+		row := s.renderer.renderRow(s.table, value)
+		s.count++
+
+		// If the widths have already been calculated then write the row immediately, otherwise add it to the
+		// buffer and write the buffer when it is full:
+		if s.widths != nil {
+			err := s.writeRow(row)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		s.rows = append(s.rows, row)
+		if s.count >= s.bufferSize {
+			err := s.Flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush writes the rows that are in the buffer, calculating the widths of the columns from them if that hasn't been
+// done yet. It must be called after writing the last page.
+func (s *TableStream) Flush() error {
+	if len(s.rows) == 0 {
+		return nil
+	}
+	if s.widths == nil {
+		s.widths = make([]int, len(s.table.columns))
+		for _, row := range s.rows {
+			for i, cell := range row {
+				s.widths[i] = max(s.widths[i], utf8.RuneCountInString(cell))
+			}
+		}
+	}
+	for _, row := range s.rows {
+		err := s.writeRow(row)
+		if err != nil {
+			return err
+		}
+	}
+	s.rows = nil
+	return nil
+}
+
+// Count returns the number of objects that have been rendered, not including the header.
+func (s *TableStream) Count() int {
+	return s.count
+}
+
+// writeRow writes a row, padding each cell to the width of its column. Cells that are wider than the column aren't
+// truncated, they just shift the rest of the row.
+func (s *TableStream) writeRow(row []string) error {
+	var buffer strings.Builder
+	last := len(row) - 1
+	for i, cell := range row {
+		buffer.WriteString(cell)
+		if i < last {
+			padding := max(s.widths[i]-utf8.RuneCountInString(cell), 0) + columnPadding
+			buffer.WriteString(strings.Repeat(" ", padding))
+		}
+	}
+	buffer.WriteString("\n")
+	_, err := s.renderer.writer.Write([]byte(buffer.String()))
+	return err
+}
@@ -0,0 +1,446 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+// Package retry contains a gRPC client interceptor that retries calls that fail with transient errors.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Default values of the settings of the interceptor:
+const (
+	DefaultMaxAttempts    = 4
+	DefaultInitialBackoff = 250 * time.Millisecond
+	DefaultMaxBackoff     = 10 * time.Second
+)
+
+// InterceptorBuilder contains the data and logic needed to build an interceptor that retries calls that fail with
+// transient errors. Don't create instances of this type directly, use the NewInterceptor function instead.
+type InterceptorBuilder struct {
+	logger         *slog.Logger
+	maxAttempts    int
+	attemptTimeout time.Duration
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	methods        []string
+}
+
+// Interceptor contains the data needed by the interceptor.
+type Interceptor struct {
+	logger         *slog.Logger
+	maxAttempts    int
+	attemptTimeout time.Duration
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	methods        []string
+}
+
+// NewInterceptor creates a builder that can then be used to configure and create a interceptor.
+func NewInterceptor() *InterceptorBuilder {
+	return &InterceptorBuilder{}
+}
+
+// SetLogger sets the logger that will be used by the intercetor. This is mandatory.
+func (b *InterceptorBuilder) SetLogger(value *slog.Logger) *InterceptorBuilder {
+	b.logger = value
+	return b
+}
+
+// SetMaxAttempts sets the maximum number of attempts for each call, including the first one. A value of one disables
+// retries. This is optional, the default is given by the DefaultMaxAttempts constant.
+func (b *InterceptorBuilder) SetMaxAttempts(value int) *InterceptorBuilder {
+	b.maxAttempts = value
+	return b
+}
+
+// SetAttemptTimeout sets the maximum time that each attempt of a unary call can take. Note that this doesn't apply to
+// streams, as those can last as long as the caller wants. This is optional, by default attempts are only limited by
+// the deadline of the context of the call.
+func (b *InterceptorBuilder) SetAttemptTimeout(value time.Duration) *InterceptorBuilder {
+	b.attemptTimeout = value
+	return b
+}
+
+// SetInitialBackoff sets the time to wait before the first retry. The time is doubled for each subsequent retry, and
+// a random jitter of up to 20% is applied. This is optional, the default is given by the DefaultInitialBackoff
+// constant.
+func (b *InterceptorBuilder) SetInitialBackoff(value time.Duration) *InterceptorBuilder {
+	b.initialBackoff = value
+	return b
+}
+
+// SetMaxBackoff sets the maximum time to wait between retries. This is optional, the default is given by the
+// DefaultMaxBackoff constant.
+func (b *InterceptorBuilder) SetMaxBackoff(value time.Duration) *InterceptorBuilder {
+	b.maxBackoff = value
+	return b
+}
+
+// AddMethods adds methods that will be retried in addition to the idempotent ones. The values can be complete method
+// names like '/fulfillment.v1.Clusters/Create' or just the name of the method like 'Create', and then it applies to
+// all the services. This is optional, by default only methods whose names start with 'Get', 'List' or 'Watch' are
+// retried.
+func (b *InterceptorBuilder) AddMethods(values ...string) *InterceptorBuilder {
+	b.methods = append(b.methods, values...)
+	return b
+}
+
+// Build uses the data stored in the builder to create and configure a new interceptor.
+func (b *InterceptorBuilder) Build() (result *Interceptor, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.maxAttempts < 0 {
+		err = errors.New("maximum number of attempts can't be negative")
+		return
+	}
+	if b.attemptTimeout < 0 {
+		err = errors.New("attempt timeout can't be negative")
+		return
+	}
+
+	// Set defaults:
+	maxAttempts := b.maxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	initialBackoff := b.initialBackoff
+	if initialBackoff <= 0 {
+		initialBackoff = DefaultInitialBackoff
+	}
+	maxBackoff := b.maxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	// Create and populate the object:
+	result = &Interceptor{
+		logger:         b.logger,
+		maxAttempts:    maxAttempts,
+		attemptTimeout: b.attemptTimeout,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		methods:        slices.Clone(b.methods),
+	}
+	return
+}
+
+// UnaryClient is the unary client interceptor function that retries calls.
+func (i *Interceptor) UnaryClient(ctx context.Context, method string, request, response any,
+	conn *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !i.retriable(method) {
+		return invoker(ctx, method, request, response, conn, opts...)
+	}
+	for attempt := 1; ; attempt++ {
+		var trailer metadata.MD
+		err := i.invokeAttempt(ctx, method, request, response, conn, invoker, &trailer, opts)
+		if err == nil {
+			return nil
+		}
+		wait, ok := i.shouldRetry(ctx, attempt, err, trailer)
+		if !ok {
+			return err
+		}
+		err = i.sleep(ctx, method, attempt, wait, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (i *Interceptor) invokeAttempt(ctx context.Context, method string, request, response any,
+	conn *grpc.ClientConn, invoker grpc.UnaryInvoker, trailer *metadata.MD, opts []grpc.CallOption) error {
+	if i.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.attemptTimeout)
+		defer cancel()
+	}
+	opts = append(slices.Clip(opts), grpc.Trailer(trailer))
+	return invoker(ctx, method, request, response, conn, opts...)
+}
+
+// StreamClient is the stream client interceptor function that retries the creation of streams. It also retries when
+// the first message fails with a transient error, for example when the server accepts the stream and then fails
+// immediately. Once a message has been delivered errors are returned to the caller, as the interceptor can't know if
+// the messages already received have been processed.
+func (i *Interceptor) StreamClient(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if !i.retriable(method) {
+		return streamer(ctx, desc, conn, method, opts...)
+	}
+	result := &retryStream{
+		interceptor: i,
+		ctx:         ctx,
+		desc:        desc,
+		conn:        conn,
+		method:      method,
+		streamer:    streamer,
+		opts:        opts,
+	}
+	err := result.open()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// retryStream is the stream returned by the interceptor. It remembers the messages sent till the first message is
+// received, so that it can replay them in a new stream if the first receive fails with a transient error.
+type retryStream struct {
+	interceptor *Interceptor
+	ctx         context.Context
+	desc        *grpc.StreamDesc
+	conn        *grpc.ClientConn
+	method      string
+	streamer    grpc.Streamer
+	opts        []grpc.CallOption
+	lock        sync.Mutex
+	stream      grpc.ClientStream
+	attempt     int
+	sent        []any
+	closed      bool
+	received    bool
+}
+
+// open creates the underlying stream, retrying if that fails with a transient error.
+func (s *retryStream) open() error {
+	for {
+		s.attempt++
+		stream, err := s.streamer(s.ctx, s.desc, s.conn, s.method, s.opts...)
+		if err == nil {
+			s.stream = stream
+			return nil
+		}
+		wait, ok := s.interceptor.shouldRetry(s.ctx, s.attempt, err, nil)
+		if !ok {
+			return err
+		}
+		err = s.interceptor.sleep(s.ctx, s.method, s.attempt, wait, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// reopen creates a new underlying stream and sends to it the messages that were sent to the previous one.
+func (s *retryStream) reopen() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	err := s.open()
+	if err != nil {
+		return err
+	}
+	for _, message := range s.sent {
+		err = s.stream.SendMsg(message)
+		if err != nil {
+			// The stream returns io.EOF when it has been aborted, and the actual error is then returned by the
+			// next receive.
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+	if s.closed {
+		return s.stream.CloseSend()
+	}
+	return nil
+}
+
+func (s *retryStream) current() grpc.ClientStream {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stream
+}
+
+func (s *retryStream) Header() (metadata.MD, error) {
+	return s.current().Header()
+}
+
+func (s *retryStream) Trailer() metadata.MD {
+	return s.current().Trailer()
+}
+
+func (s *retryStream) Context() context.Context {
+	return s.current().Context()
+}
+
+func (s *retryStream) CloseSend() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	return s.stream.CloseSend()
+}
+
+func (s *retryStream) SendMsg(message any) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.received {
+		saved := message
+		protoMessage, ok := message.(proto.Message)
+		if ok {
+			saved = proto.Clone(protoMessage)
+		}
+		s.sent = append(s.sent, saved)
+	}
+	return s.stream.SendMsg(message)
+}
+
+func (s *retryStream) RecvMsg(message any) error {
+	for {
+		stream := s.current()
+		err := stream.RecvMsg(message)
+		if err == nil {
+			s.lock.Lock()
+			s.received = true
+			s.sent = nil
+			s.lock.Unlock()
+			return nil
+		}
+		s.lock.Lock()
+		received := s.received
+		s.lock.Unlock()
+		if received || errors.Is(err, io.EOF) {
+			return err
+		}
+		wait, ok := s.interceptor.shouldRetry(s.ctx, s.attempt, err, stream.Trailer())
+		if !ok {
+			return err
+		}
+		err = s.interceptor.sleep(s.ctx, s.method, s.attempt, wait, err)
+		if err != nil {
+			return err
+		}
+		err = s.reopen()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// retriable checks if the given method can be retried. That is true for the methods that are idempotent, or that
+// have been explicitly added.
+func (i *Interceptor) retriable(method string) bool {
+	if i.maxAttempts <= 1 {
+		return false
+	}
+	name := method[strings.LastIndex(method, "/")+1:]
+	for _, allowed := range i.methods {
+		if allowed == method || allowed == name {
+			return true
+		}
+	}
+	for _, prefix := range idempotentPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldRetry checks if a failed attempt should be retried, and returns the time to wait before doing it.
+func (i *Interceptor) shouldRetry(ctx context.Context, attempt int, err error,
+	trailer metadata.MD) (wait time.Duration, ok bool) {
+	if attempt >= i.maxAttempts || ctx.Err() != nil {
+		return
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+	case codes.DeadlineExceeded:
+		// This can only be the timeout of the attempt, as we already checked that the context of the call is
+		// still alive.
+		if i.attemptTimeout == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	// If the server sent a pushback then we need to honour it. As described in the gRPC retry design a value
+	// that isn't a non negative integer means that we should not retry.
+	values := trailer.Get(pushbackKey)
+	if len(values) > 0 {
+		millis, parseErr := strconv.ParseInt(values[0], 10, 64)
+		if parseErr != nil || millis < 0 {
+			return
+		}
+		wait = time.Duration(millis) * time.Millisecond
+		ok = true
+		return
+	}
+
+	// Calculate the exponential backoff, with jitter:
+	wait = i.initialBackoff << (attempt - 1)
+	if wait <= 0 || wait > i.maxBackoff {
+		wait = i.maxBackoff
+	}
+	jitter := 1 + jitterFactor*(2*rand.Float64()-1)
+	wait = time.Duration(float64(wait) * jitter)
+	ok = true
+	return
+}
+
+// sleep waits before the next attempt, or till the context is cancelled. In that case it returns the error of the
+// last attempt.
+func (i *Interceptor) sleep(ctx context.Context, method string, attempt int, wait time.Duration,
+	err error) error {
+	i.logger.InfoContext(
+		ctx,
+		"Retrying call",
+		slog.String("method", method),
+		slog.Int("attempt", attempt),
+		slog.Int("max_attempts", i.maxAttempts),
+		slog.Duration("wait", wait),
+		slog.Any("error", err),
+	)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return err
+	}
+}
+
+// idempotentPrefixes are the prefixes of the names of the methods that can be retried safely.
+var idempotentPrefixes = []string{
+	"Get",
+	"List",
+	"Watch",
+}
+
+// pushbackKey is the name of the trailer that the server uses to tell the client how long to wait before retrying, in
+// milliseconds.
+const pushbackKey = "grpc-retry-pushback-ms"
+
+// jitterFactor is the maximum fraction of the backoff that is randomly added or removed.
+const jitterFactor = 0.2
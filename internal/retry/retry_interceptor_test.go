@@ -0,0 +1,323 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package retry
+
+import (
+	"context"
+	"time"
+
+	eventsv1 "github.com/innabox/fulfillment-common/api/events/v1"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Interceptor", func() {
+	Describe("Creation", func() {
+		It("Can be created with all the mandatory parameters", func() {
+			interceptor, err := NewInterceptor().
+				SetLogger(logger).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(interceptor).ToNot(BeNil())
+			Expect(interceptor.maxAttempts).To(Equal(DefaultMaxAttempts))
+		})
+
+		It("Can't be created without a logger", func() {
+			interceptor, err := NewInterceptor().
+				Build()
+			Expect(err).To(MatchError("logger is mandatory"))
+			Expect(interceptor).To(BeNil())
+		})
+
+		It("Can't be created with a negative number of attempts", func() {
+			_, err := NewInterceptor().
+				SetLogger(logger).
+				SetMaxAttempts(-1).
+				Build()
+			Expect(err).To(MatchError("maximum number of attempts can't be negative"))
+		})
+	})
+
+	Describe("Behaviour", func() {
+		var (
+			ctx         context.Context
+			interceptor *Interceptor
+			calls       int
+			errs        []error
+			trailers    []metadata.MD
+		)
+
+		// invoker simulates a server that returns the configured errors and trailers, one for each call.
+		invoker := func(ctx context.Context, method string, request, response any, conn *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			calls++
+			index := calls - 1
+			if index < len(trailers) && trailers[index] != nil {
+				for _, opt := range opts {
+					trailerOpt, ok := opt.(grpc.TrailerCallOption)
+					if ok {
+						*trailerOpt.TrailerAddr = trailers[index]
+					}
+				}
+			}
+			if index < len(errs) {
+				return errs[index]
+			}
+			return nil
+		}
+
+		// streamer simulates a server that fails to create streams with the configured errors.
+		streamer := func(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			err := invoker(ctx, method, nil, nil, conn, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+
+		unavailable := status.Error(codes.Unavailable, "unavailable")
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			calls = 0
+			errs = nil
+			trailers = nil
+			var err error
+			interceptor, err = NewInterceptor().
+				SetLogger(logger).
+				SetMaxAttempts(3).
+				SetInitialBackoff(time.Millisecond).
+				SetMaxBackoff(10 * time.Millisecond).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		DescribeTable(
+			"Retries idempotent methods",
+			func(method string) {
+				errs = []error{unavailable, status.Error(codes.ResourceExhausted, "exhausted")}
+				err := interceptor.UnaryClient(ctx, method, nil, nil, nil, invoker)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(calls).To(Equal(3))
+			},
+			Entry("Get", "/fulfillment.v1.Clusters/Get"),
+			Entry("Get kubeconfig", "/fulfillment.v1.Clusters/GetKubeconfig"),
+			Entry("List", "/fulfillment.v1.Clusters/List"),
+		)
+
+		DescribeTable(
+			"Doesn't retry non idempotent methods",
+			func(method string) {
+				errs = []error{unavailable}
+				err := interceptor.UnaryClient(ctx, method, nil, nil, nil, invoker)
+				Expect(err).To(Equal(unavailable))
+				Expect(calls).To(Equal(1))
+			},
+			Entry("Create", "/fulfillment.v1.Clusters/Create"),
+			Entry("Delete", "/fulfillment.v1.Clusters/Delete"),
+			Entry("Update", "/fulfillment.v1.Clusters/Update"),
+		)
+
+		It("Retries methods that have been explicitly allowed", func() {
+			var err error
+			interceptor, err = NewInterceptor().
+				SetLogger(logger).
+				SetInitialBackoff(time.Millisecond).
+				AddMethods("Create", "/fulfillment.v1.Hosts/Delete").
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			errs = []error{unavailable}
+			err = interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Create", nil, nil, nil, invoker)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+			calls = 0
+			err = interceptor.UnaryClient(ctx, "/fulfillment.v1.Hosts/Delete", nil, nil, nil, invoker)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+			calls = 0
+			err = interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Delete", nil, nil, nil, invoker)
+			Expect(err).To(Equal(unavailable))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("Doesn't retry other errors", func() {
+			notFound := status.Error(codes.NotFound, "not found")
+			errs = []error{notFound}
+			err := interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, invoker)
+			Expect(err).To(Equal(notFound))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("Gives up after the maximum number of attempts", func() {
+			errs = []error{unavailable, unavailable, unavailable, unavailable}
+			err := interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, invoker)
+			Expect(err).To(Equal(unavailable))
+			Expect(calls).To(Equal(3))
+		})
+
+		It("Doesn't retry when there is only one attempt", func() {
+			var err error
+			interceptor, err = NewInterceptor().
+				SetLogger(logger).
+				SetMaxAttempts(1).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			errs = []error{unavailable}
+			err = interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, invoker)
+			Expect(err).To(Equal(unavailable))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("Honours the pushback sent by the server", func() {
+			errs = []error{unavailable}
+			trailers = []metadata.MD{
+				metadata.Pairs("grpc-retry-pushback-ms", "100"),
+			}
+			start := time.Now()
+			err := interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, invoker)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+			Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+		})
+
+		It("Doesn't retry if the server pushback is negative", func() {
+			errs = []error{unavailable}
+			trailers = []metadata.MD{
+				metadata.Pairs("grpc-retry-pushback-ms", "-1"),
+			}
+			err := interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, invoker)
+			Expect(err).To(Equal(unavailable))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("Stops retrying when the context is cancelled", func() {
+			var err error
+			interceptor, err = NewInterceptor().
+				SetLogger(logger).
+				SetInitialBackoff(time.Hour).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			errs = []error{unavailable, unavailable}
+			err = interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, invoker)
+			Expect(err).To(Equal(unavailable))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("Retries attempts that exceed the attempt timeout", func() {
+			var err error
+			interceptor, err = NewInterceptor().
+				SetLogger(logger).
+				SetInitialBackoff(time.Millisecond).
+				SetAttemptTimeout(10 * time.Millisecond).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			slow := func(ctx context.Context, method string, request, response any, conn *grpc.ClientConn,
+				opts ...grpc.CallOption) error {
+				calls++
+				if calls == 1 {
+					<-ctx.Done()
+					return status.FromContextError(ctx.Err()).Err()
+				}
+				return nil
+			}
+			err = interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, slow)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+		})
+
+		It("Retries the creation of watch streams", func() {
+			errs = []error{unavailable}
+			_, err := interceptor.StreamClient(ctx, nil, nil, "/events.v1.Events/Watch", streamer)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+		})
+	})
+
+	Describe("Streams", func() {
+		var (
+			ctx      context.Context
+			attempts int
+			filters  []string
+			client   eventsv1.EventsClient
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			attempts = 0
+			filters = nil
+
+			// Create a server that fails the first attempt after opening the stream, and then sends one event:
+			server := testing.NewServer()
+			DeferCleanup(server.Stop)
+			eventsv1.RegisterEventsServer(server.Registrar(), &testing.EventsServerFuncs{
+				WatchFunc: func(request *eventsv1.EventsWatchRequest, stream eventsv1.Events_WatchServer) error {
+					attempts++
+					filters = append(filters, request.GetFilter())
+					err := stream.SendHeader(metadata.MD{})
+					if err != nil {
+						return err
+					}
+					if attempts == 1 {
+						return status.Error(codes.Unavailable, "unavailable")
+					}
+					return stream.Send(eventsv1.EventsWatchResponse_builder{
+						Event: eventsv1.Event_builder{
+							Id: "my-event",
+						}.Build(),
+					}.Build())
+				},
+			})
+			server.Start()
+
+			// Create the client with the interceptor:
+			interceptor, err := NewInterceptor().
+				SetLogger(logger).
+				SetInitialBackoff(time.Millisecond).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			connection, err := grpc.NewClient(
+				server.Address(),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithStreamInterceptor(interceptor.StreamClient),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(connection.Close)
+			client = eventsv1.NewEventsClient(connection)
+		})
+
+		It("Retries watch streams that fail before delivering the first message", func() {
+			stream, err := client.Watch(ctx, eventsv1.EventsWatchRequest_builder{
+				Filter: proto.String("my-filter"),
+			}.Build())
+			Expect(err).ToNot(HaveOccurred())
+			response, err := stream.Recv()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.GetEvent().GetId()).To(Equal("my-event"))
+			Expect(attempts).To(Equal(2))
+			Expect(filters).To(Equal([]string{"my-filter", "my-filter"}))
+		})
+	})
+})
@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+// Package serviceaccount contains the support for authenticating with the token of the Kubernetes service account of
+// the pod where the tool runs.
+package serviceaccount
+
+import (
+	"os"
+)
+
+// HostEnvVar is the environment variable that Kubernetes sets in all the containers of a pod. We use it to detect that
+// the tool is running inside a cluster.
+const HostEnvVar = "KUBERNETES_SERVICE_HOST"
+
+// DefaultTokenFile is the location where Kubernetes mounts the projected token of the service account of the pod.
+const DefaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// Detect checks if the tool is running inside a Kubernetes pod, and if the given service account token file exists.
+// The file is usually DefaultTokenFile.
+func Detect(file string) bool {
+	if os.Getenv(HostEnvVar) == "" {
+		return false
+	}
+	info, err := os.Stat(file)
+	return err == nil && info.Mode().IsRegular()
+}
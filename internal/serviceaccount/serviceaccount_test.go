@@ -0,0 +1,47 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package serviceaccount
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Detection", func() {
+	var file string
+
+	BeforeEach(func() {
+		file = filepath.Join(GinkgoT().TempDir(), "token")
+		err := os.WriteFile(file, []byte("my_token"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Detects the token when running in a cluster", func() {
+		GinkgoT().Setenv(HostEnvVar, "10.0.0.1")
+		Expect(Detect(file)).To(BeTrue())
+	})
+
+	It("Doesn't detect the token when not running in a cluster", func() {
+		GinkgoT().Setenv(HostEnvVar, "")
+		Expect(Detect(file)).To(BeFalse())
+	})
+
+	It("Doesn't detect the token if the file doesn't exist", func() {
+		GinkgoT().Setenv(HostEnvVar, "10.0.0.1")
+		Expect(Detect(filepath.Join(GinkgoT().TempDir(), "missing"))).To(BeFalse())
+	})
+})
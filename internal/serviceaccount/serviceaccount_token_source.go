@@ -0,0 +1,247 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package serviceaccount
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/innabox/fulfillment-common/auth"
+
+	"github.com/innabox/fulfillment-cli/internal/oidc"
+)
+
+// TokenSourceBuilder contains the data and logic needed to create a service account token source. Don't create
+// instances of this type directly, use the NewTokenSource function instead.
+type TokenSourceBuilder struct {
+	logger       *slog.Logger
+	file         string
+	issuer       string
+	httpClient   *http.Client
+	clientId     string
+	clientSecret string
+	scopes       []string
+}
+
+// TokenSource is a token source that reads the projected token of a Kubernetes service account from a file. The file
+// is read again for each request, so that tokens rotated by the kubelet are picked up. Optionally the token can be
+// exchanged for a token of an OAuth server, using the token exchange grant described in RFC 8693. Don't create
+// instances of this type directly, use the NewTokenSource function instead.
+type TokenSource struct {
+	logger       *slog.Logger
+	file         string
+	issuer       string
+	httpClient   *http.Client
+	clientId     string
+	clientSecret string
+	scopes       []string
+	lock         *sync.Mutex
+	exchanger    *oidc.Exchanger
+	subject      string
+	exchanged    *auth.Token
+}
+
+// NewTokenSource creates a builder that can then be used to configure and create a service account token source.
+func NewTokenSource() *TokenSourceBuilder {
+	return &TokenSourceBuilder{}
+}
+
+// SetLogger sets the logger that the token source will use to write to the log. This is mandatory.
+func (b *TokenSourceBuilder) SetLogger(value *slog.Logger) *TokenSourceBuilder {
+	b.logger = value
+	return b
+}
+
+// SetFile sets the location of the file that contains the service account token. This is optional, the default is to
+// use the location where Kubernetes mounts the token.
+func (b *TokenSourceBuilder) SetFile(value string) *TokenSourceBuilder {
+	b.file = value
+	return b
+}
+
+// SetIssuer sets the URL of the OAuth server that will be used to exchange the service account token. This is
+// optional, when not set the service account token is used directly.
+func (b *TokenSourceBuilder) SetIssuer(value string) *TokenSourceBuilder {
+	b.issuer = value
+	return b
+}
+
+// SetHttpClient sets the HTTP client that will be used to talk to the OAuth server. This is optional, by default the
+// default HTTP client of the Go library is used.
+func (b *TokenSourceBuilder) SetHttpClient(value *http.Client) *TokenSourceBuilder {
+	b.httpClient = value
+	return b
+}
+
+// SetClientId sets the identifier of the client used for the token exchange. This is optional.
+func (b *TokenSourceBuilder) SetClientId(value string) *TokenSourceBuilder {
+	b.clientId = value
+	return b
+}
+
+// SetClientSecret sets the secret of the client used for the token exchange. This is optional.
+func (b *TokenSourceBuilder) SetClientSecret(value string) *TokenSourceBuilder {
+	b.clientSecret = value
+	return b
+}
+
+// SetScopes sets the scopes requested in the token exchange. This is optional.
+func (b *TokenSourceBuilder) SetScopes(values ...string) *TokenSourceBuilder {
+	b.scopes = values
+	return b
+}
+
+// Build uses the data stored in the builder to create a new service account token source.
+func (b *TokenSourceBuilder) Build() (result *TokenSource, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+
+	// Set defaults:
+	file := b.file
+	if file == "" {
+		file = DefaultTokenFile
+	}
+	httpClient := b.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// Create and populate the object:
+	result = &TokenSource{
+		logger:       b.logger,
+		file:         file,
+		issuer:       b.issuer,
+		httpClient:   httpClient,
+		clientId:     b.clientId,
+		clientSecret: b.clientSecret,
+		scopes:       b.scopes,
+		lock:         &sync.Mutex{},
+	}
+	return
+}
+
+// Token is the implementation of the auth.TokenSource interface.
+func (s *TokenSource) Token(ctx context.Context) (result *auth.Token, err error) {
+	// Read the token every time, as the kubelet replaces the file when the token is rotated:
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		err = fmt.Errorf("failed to read service account token file '%s': %w", s.file, err)
+		return
+	}
+	subject := string(bytes.TrimSpace(data))
+	if subject == "" {
+		err = fmt.Errorf("service account token file '%s' is empty", s.file)
+		return
+	}
+
+	// If there is no issuer then the service account token is used directly:
+	if s.issuer == "" {
+		result = &auth.Token{
+			Access: subject,
+			Expiry: s.expiry(ctx, subject),
+		}
+		return
+	}
+
+	// Reuse the result of the previous exchange if the service account token hasn't changed and the exchanged token
+	// is still fresh:
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.exchanged != nil && s.subject == subject {
+		expiry := s.exchanged.Expiry
+		if expiry.IsZero() || time.Until(expiry) > exchangeMargin {
+			result = s.exchanged
+			return
+		}
+	}
+	if s.exchanger == nil {
+		s.exchanger, err = s.createExchanger(ctx)
+		if err != nil {
+			return
+		}
+	}
+	exchanged, err := s.exchanger.Exchange(ctx, subject, oidc.JwtTokenType)
+	if err != nil {
+		err = fmt.Errorf("failed to exchange service account token: %w", err)
+		return
+	}
+	s.subject = subject
+	s.exchanged = exchanged
+	result = exchanged
+	return
+}
+
+// createExchanger finds the token endpoint of the issuer and creates the object that exchanges tokens with it.
+func (s *TokenSource) createExchanger(ctx context.Context) (result *oidc.Exchanger, err error) {
+	discoveryClient, err := oidc.NewDiscoveryClient().
+		SetLogger(s.logger).
+		SetHttpClient(s.httpClient).
+		Build()
+	if err != nil {
+		return
+	}
+	metadata, err := discoveryClient.Discover(ctx, s.issuer)
+	if err != nil {
+		return
+	}
+	if !metadata.SupportsTokenExchange() {
+		err = fmt.Errorf("issuer '%s' doesn't support token exchange", s.issuer)
+		return
+	}
+	result, err = oidc.NewExchanger().
+		SetLogger(s.logger).
+		SetHttpClient(s.httpClient).
+		SetEndpoint(metadata.TokenEndpoint).
+		SetClientId(s.clientId).
+		SetClientSecret(s.clientSecret).
+		SetScopes(s.scopes...).
+		Build()
+	return
+}
+
+// expiry extracts the expiration time from the service account token. It returns the zero time if the token isn't a
+// JSON web token or if it doesn't have an expiration time.
+func (s *TokenSource) expiry(ctx context.Context, token string) time.Time {
+	parser := jwt.NewParser()
+	claims := jwt.MapClaims{}
+	_, _, err := parser.ParseUnverified(token, claims)
+	if err != nil {
+		s.logger.DebugContext(
+			ctx,
+			"Failed to parse service account token",
+			slog.String("file", s.file),
+			slog.Any("error", err),
+		)
+		return time.Time{}
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}
+	}
+	return exp.Time
+}
+
+// exchangeMargin is the minimum remaining life of an exchanged token for it to be reused.
+const exchangeMargin = time.Minute
@@ -0,0 +1,209 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package serviceaccount
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Token source", func() {
+	var (
+		ctx  context.Context
+		file string
+	)
+
+	// writeToken replaces the token file in the same way that the kubelet does, writing a new file and then
+	// renaming it.
+	writeToken := func(token string) {
+		tmp := file + ".tmp"
+		err := os.WriteFile(tmp, []byte(token+"\n"), 0600)
+		Expect(err).ToNot(HaveOccurred())
+		err = os.Rename(tmp, file)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		file = filepath.Join(GinkgoT().TempDir(), "token")
+	})
+
+	It("Can't be created without a logger", func() {
+		_, err := NewTokenSource().
+			SetFile(file).
+			Build()
+		Expect(err).To(MatchError("logger is mandatory"))
+	})
+
+	It("Returns the content of the file", func() {
+		writeToken("my_token")
+		source, err := NewTokenSource().
+			SetLogger(logger).
+			SetFile(file).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		token, err := source.Token(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Access).To(Equal("my_token"))
+		Expect(token.Expiry.IsZero()).To(BeTrue())
+	})
+
+	It("Picks up rotated tokens", func() {
+		writeToken("first_token")
+		source, err := NewTokenSource().
+			SetLogger(logger).
+			SetFile(file).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		token, err := source.Token(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Access).To(Equal("first_token"))
+		writeToken("second_token")
+		token, err = source.Token(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Access).To(Equal("second_token"))
+	})
+
+	It("Takes the expiry from the token", func() {
+		expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+		text, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "system:serviceaccount:my-namespace:my-account",
+			"exp": expiry.Unix(),
+		}).SignedString([]byte("my_key"))
+		Expect(err).ToNot(HaveOccurred())
+		writeToken(text)
+		source, err := NewTokenSource().
+			SetLogger(logger).
+			SetFile(file).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		token, err := source.Token(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.Expiry).To(BeTemporally("==", expiry))
+	})
+
+	It("Fails if the file doesn't exist", func() {
+		source, err := NewTokenSource().
+			SetLogger(logger).
+			SetFile(file).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = source.Token(ctx)
+		Expect(err).To(MatchError(ContainSubstring("failed to read service account token file")))
+	})
+
+	It("Fails if the file is empty", func() {
+		writeToken("")
+		source, err := NewTokenSource().
+			SetLogger(logger).
+			SetFile(file).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = source.Token(ctx)
+		Expect(err).To(MatchError(ContainSubstring("is empty")))
+	})
+
+	Describe("Exchange", func() {
+		var (
+			issuer    *httptest.Server
+			subjects  []string
+			grantType string
+		)
+
+		BeforeEach(func() {
+			subjects = nil
+			grantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+			issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/.well-known/openid-configuration":
+					json.NewEncoder(w).Encode(map[string]any{
+						"issuer":                issuer.URL,
+						"token_endpoint":        issuer.URL + "/token",
+						"grant_types_supported": []string{grantType},
+					})
+				case "/token":
+					subject := r.PostFormValue("subject_token")
+					subjects = append(subjects, subject)
+					json.NewEncoder(w).Encode(map[string]any{
+						"access_token": fmt.Sprintf("exchanged_%s_%d", subject, len(subjects)),
+						"token_type":   "Bearer",
+						"expires_in":   300,
+					})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			DeferCleanup(issuer.Close)
+		})
+
+		It("Exchanges the token and reuses the result", func() {
+			writeToken("my_token")
+			source, err := NewTokenSource().
+				SetLogger(logger).
+				SetFile(file).
+				SetIssuer(issuer.URL).
+				SetClientId("my_client").
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			token, err := source.Token(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token.Access).To(Equal("exchanged_my_token_1"))
+			token, err = source.Token(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token.Access).To(Equal("exchanged_my_token_1"))
+			Expect(subjects).To(Equal([]string{"my_token"}))
+		})
+
+		It("Exchanges the token again when it is rotated", func() {
+			writeToken("first_token")
+			source, err := NewTokenSource().
+				SetLogger(logger).
+				SetFile(file).
+				SetIssuer(issuer.URL).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			token, err := source.Token(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token.Access).To(Equal("exchanged_first_token_1"))
+			writeToken("second_token")
+			token, err = source.Token(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token.Access).To(Equal("exchanged_second_token_2"))
+		})
+
+		It("Fails if the issuer doesn't support token exchange", func() {
+			grantType = "client_credentials"
+			writeToken("my_token")
+			source, err := NewTokenSource().
+				SetLogger(logger).
+				SetFile(file).
+				SetIssuer(issuer.URL).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = source.Token(ctx)
+			Expect(err).To(MatchError(ContainSubstring("doesn't support token exchange")))
+		})
+	})
+})
@@ -0,0 +1,331 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+// Package telemetry contains the support for exporting OpenTelemetry traces of the invocations of the tool.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/innabox/fulfillment-cli/internal/version"
+)
+
+// ProviderBuilder contains the data and logic needed to build a telemetry provider. Don't create instances of this
+// type directly, use the NewProvider function instead.
+type ProviderBuilder struct {
+	logger   *slog.Logger
+	exporter sdktrace.SpanExporter
+}
+
+// Provider creates the spans of the invocations of the tool and sends them to the configured exporter. When no
+// exporter has been configured it does nothing.
+type Provider struct {
+	logger     *slog.Logger
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	closer     io.Closer
+}
+
+// NewProvider creates a builder that can then be used to configure and create a telemetry provider.
+func NewProvider() *ProviderBuilder {
+	return &ProviderBuilder{}
+}
+
+// SetLogger sets the logger that the provider will use to write to the log. This is mandatory.
+func (b *ProviderBuilder) SetLogger(value *slog.Logger) *ProviderBuilder {
+	b.logger = value
+	return b
+}
+
+// SetExporter sets the exporter that will receive the spans. This is optional, by default the exporter is selected
+// using the standard OpenTelemetry environment variables, and if those aren't set no spans are exported.
+func (b *ProviderBuilder) SetExporter(value sdktrace.SpanExporter) *ProviderBuilder {
+	b.exporter = value
+	return b
+}
+
+// Build uses the data stored in the builder to create and configure a new telemetry provider.
+func (b *ProviderBuilder) Build() (result *Provider, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+
+	// Select the exporter:
+	exporter := b.exporter
+	var closer io.Closer
+	if exporter == nil {
+		exporter, closer, err = b.createExporter()
+		if err != nil {
+			return
+		}
+	}
+
+	// Create and populate the object. If there is no exporter then the provider is disabled, and we don't need
+	// to create the tracer provider.
+	result = &Provider{
+		logger:     b.logger,
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		closer:     closer,
+	}
+	if exporter == nil {
+		return
+	}
+	res, err := b.createResource()
+	if err != nil {
+		return
+	}
+	result.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	result.tracer = result.provider.Tracer(tracerName)
+	return
+}
+
+// createExporter creates the exporter selected by the 'OTEL_TRACES_EXPORTER' environment variable. It returns nil
+// if no exporter has been selected. If that variable isn't set but an OTLP endpoint has been configured then the OTLP
+// exporter is used. This is different to the default of the OpenTelemetry specification because we don't want to
+// try to connect to a collector in the local host every time that the tool is used.
+func (b *ProviderBuilder) createExporter() (result sdktrace.SpanExporter, closer io.Closer, err error) {
+	ctx := context.Background()
+	disabled, _ := strconv.ParseBool(os.Getenv(sdkDisabledEnvVar))
+	if disabled {
+		return
+	}
+	name := strings.TrimSpace(os.Getenv(tracesExporterEnvVar))
+	if name == "" {
+		if os.Getenv(otlpEndpointEnvVar) == "" && os.Getenv(otlpTracesEndpointEnvVar) == "" {
+			return
+		}
+		name = otlpExporter
+	}
+	switch name {
+	case noneExporter:
+		return
+	case otlpExporter:
+		protocol := os.Getenv(otlpTracesProtocolEnvVar)
+		if protocol == "" {
+			protocol = os.Getenv(otlpProtocolEnvVar)
+		}
+		switch protocol {
+		case "", "http/protobuf":
+			result, err = otlptracehttp.New(ctx)
+		case "grpc":
+			result, err = otlptracegrpc.New(ctx)
+		default:
+			err = fmt.Errorf(
+				"OTLP protocol '%s' isn't supported, valid values are 'http/protobuf' and 'grpc'",
+				protocol,
+			)
+			return
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		return
+	case consoleExporter:
+		var writer io.Writer = os.Stderr
+		file := os.Getenv(FileEnvVar)
+		if file != "" {
+			var fd *os.File
+			fd, err = os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				err = fmt.Errorf("failed to open traces file '%s': %w", file, err)
+				return
+			}
+			writer = fd
+			closer = fd
+		}
+		result, err = stdouttrace.New(stdouttrace.WithWriter(writer))
+		if err != nil {
+			err = fmt.Errorf("failed to create console exporter: %w", err)
+		}
+		return
+	default:
+		err = fmt.Errorf(
+			"traces exporter '%s' isn't supported, valid values are '%s', '%s' and '%s'",
+			name, otlpExporter, consoleExporter, noneExporter,
+		)
+		return
+	}
+}
+
+// createResource creates the resource that describes the tool. The attributes can be changed with the standard
+// 'OTEL_SERVICE_NAME' and 'OTEL_RESOURCE_ATTRIBUTES' environment variables.
+func (b *ProviderBuilder) createResource() (result *resource.Resource, err error) {
+	result, err = resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+			attribute.String("service.version", version.Get()),
+		),
+	)
+	if err != nil {
+		err = fmt.Errorf("failed to create resource: %w", err)
+		return
+	}
+	result, err = resource.Merge(result, resource.Environment())
+	if err != nil {
+		err = fmt.Errorf("failed to merge resource from environment: %w", err)
+	}
+	return
+}
+
+// Enabled returns true if spans are being exported.
+func (p *Provider) Enabled() bool {
+	return p.provider != nil
+}
+
+// Register makes this provider and the W3C trace context propagator the global ones, so that they are used by the
+// instrumentation of the gRPC and HTTP clients.
+func (p *Provider) Register() {
+	if p.provider != nil {
+		otel.SetTracerProvider(p.provider)
+	}
+	otel.SetTextMapPropagator(p.propagator)
+}
+
+// Start starts the root span of the invocation of the tool, and returns a context that contains it and the provider.
+// If the 'TRACEPARENT' environment variable is set, for example when the tool runs inside a pipeline that is already
+// traced, the span is created as a child of the span described by that variable.
+func (p *Provider) Start(ctx context.Context, name string) context.Context {
+	ctx = IntoContext(ctx, p)
+	if p.tracer == nil {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{}
+	for key, envVar := range parentEnvVars {
+		value := os.Getenv(envVar)
+		if value != "" {
+			carrier[key] = value
+		}
+	}
+	ctx = p.propagator.Extract(ctx, carrier)
+	ctx, _ = p.tracer.Start(ctx, name)
+	return ctx
+}
+
+// Shutdown sends the pending spans to the exporter and releases the resources used by the provider.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	var errs []error
+	if p.provider != nil {
+		errs = append(errs, p.provider.Shutdown(ctx))
+	}
+	if p.closer != nil {
+		errs = append(errs, p.closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Finish ends the root span stored in the context, recording the given error if it isn't nil, and then shuts down
+// the provider stored in the context. This is intended to be called once the command has finished.
+func Finish(ctx context.Context, err error) {
+	if ctx == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	provider := FromContext(ctx)
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	shutdownErr := provider.Shutdown(ctx)
+	if shutdownErr != nil {
+		provider.logger.ErrorContext(
+			ctx,
+			"Failed to shutdown telemetry provider",
+			slog.Any("error", shutdownErr),
+		)
+	}
+}
+
+// contextKey is the type used to store the provider in the context.
+type contextKey int
+
+const (
+	providerKey contextKey = iota
+)
+
+// IntoContext creates a new context that contains the given provider.
+func IntoContext(ctx context.Context, provider *Provider) context.Context {
+	return context.WithValue(ctx, providerKey, provider)
+}
+
+// FromContext returns the provider stored in the context, or nil if there is no provider.
+func FromContext(ctx context.Context) *Provider {
+	provider, _ := ctx.Value(providerKey).(*Provider)
+	return provider
+}
+
+// FileEnvVar is the name of the environment variable that contains the file where the console exporter writes the
+// spans, as JSON. If it isn't set the spans are written to the standard error. It uses the same prefix as the rest of
+// the environment variables that configure how the tool talks to the server.
+const FileEnvVar = "FULFILLMENT_SERVICE_TRACES_FILE"
+
+// Names of the standard OpenTelemetry environment variables:
+const (
+	sdkDisabledEnvVar        = "OTEL_SDK_DISABLED"
+	tracesExporterEnvVar     = "OTEL_TRACES_EXPORTER"
+	otlpEndpointEnvVar       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpTracesEndpointEnvVar = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	otlpProtocolEnvVar       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	otlpTracesProtocolEnvVar = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+)
+
+// parentEnvVars are the environment variables that may contain the trace context of the parent of the root span,
+// indexed by the name of the corresponding header.
+var parentEnvVars = map[string]string{
+	"traceparent": "TRACEPARENT",
+	"tracestate":  "TRACESTATE",
+}
+
+// Names of the supported exporters:
+const (
+	otlpExporter    = "otlp"
+	consoleExporter = "console"
+	noneExporter    = "none"
+)
+
+const (
+	serviceName     = "fulfillment-cli"
+	tracerName      = "github.com/innabox/fulfillment-cli"
+	shutdownTimeout = 5 * time.Second
+)
@@ -0,0 +1,233 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+
+	internalnetwork "github.com/innabox/fulfillment-cli/internal/network"
+	"github.com/innabox/fulfillment-cli/internal/testing"
+)
+
+var _ = Describe("Provider", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		// Make sure that the global provider and propagator are restored after each test:
+		tracerProvider := otel.GetTracerProvider()
+		propagator := otel.GetTextMapPropagator()
+		DeferCleanup(func() {
+			otel.SetTracerProvider(tracerProvider)
+			otel.SetTextMapPropagator(propagator)
+		})
+
+		// Make sure that the environment doesn't select an exporter:
+		for _, name := range []string{
+			tracesExporterEnvVar, otlpEndpointEnvVar, otlpTracesEndpointEnvVar, "TRACEPARENT",
+		} {
+			GinkgoT().Setenv(name, "")
+		}
+	})
+
+	It("Can't be created without a logger", func() {
+		_, err := NewProvider().
+			Build()
+		Expect(err).To(MatchError("logger is mandatory"))
+	})
+
+	It("Is disabled if no exporter has been configured", func() {
+		provider, err := NewProvider().
+			SetLogger(logger).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider.Enabled()).To(BeFalse())
+		ctx = provider.Start(ctx, "fulfillment-cli get")
+		Expect(trace.SpanContextFromContext(ctx).IsValid()).To(BeFalse())
+		Finish(ctx, nil)
+	})
+
+	It("Rejects unsupported exporters", func() {
+		GinkgoT().Setenv(tracesExporterEnvVar, "junk")
+		_, err := NewProvider().
+			SetLogger(logger).
+			Build()
+		Expect(err).To(MatchError(ContainSubstring("traces exporter 'junk' isn't supported")))
+	})
+
+	It("Writes spans to the file selected with the environment", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "traces.json")
+		GinkgoT().Setenv(tracesExporterEnvVar, "console")
+		GinkgoT().Setenv(FileEnvVar, file)
+		provider, err := NewProvider().
+			SetLogger(logger).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider.Enabled()).To(BeTrue())
+		ctx = provider.Start(ctx, "fulfillment-cli get")
+		Finish(ctx, nil)
+		data, err := os.ReadFile(file)
+		Expect(err).ToNot(HaveOccurred())
+		var span map[string]any
+		err = json.Unmarshal(data, &span)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(span).To(HaveKeyWithValue("Name", "fulfillment-cli get"))
+	})
+
+	Describe("Spans", func() {
+		var (
+			exporter *tracetest.InMemoryExporter
+			provider *Provider
+		)
+
+		BeforeEach(func() {
+			var err error
+			exporter = tracetest.NewInMemoryExporter()
+			provider, err = NewProvider().
+				SetLogger(logger).
+				SetExporter(keepingExporter{exporter}).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			provider.Register()
+		})
+
+		It("Creates a root span named after the command", func() {
+			ctx = provider.Start(ctx, "fulfillment-cli get")
+			Finish(ctx, nil)
+			spans := exporter.GetSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Name).To(Equal("fulfillment-cli get"))
+			Expect(spans[0].Parent.IsValid()).To(BeFalse())
+			Expect(spans[0].Status.Code).To(Equal(codes.Unset))
+		})
+
+		It("Records the error of the command", func() {
+			ctx = provider.Start(ctx, "fulfillment-cli get")
+			Finish(ctx, errors.New("my error"))
+			spans := exporter.GetSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Status.Code).To(Equal(codes.Error))
+			Expect(spans[0].Status.Description).To(Equal("my error"))
+		})
+
+		It("Uses the parent from the environment", func() {
+			GinkgoT().Setenv("TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+			ctx = provider.Start(ctx, "fulfillment-cli get")
+			Finish(ctx, nil)
+			spans := exporter.GetSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].SpanContext.TraceID().String()).To(Equal("0af7651916cd43dd8448eb211c80319c"))
+			Expect(spans[0].Parent.SpanID().String()).To(Equal("b7ad6b7169203331"))
+		})
+
+		It("Creates spans for gRPC calls and propagates the trace context", func() {
+			// Start a server that saves the metadata that it receives:
+			var received metadata.MD
+			server := testing.NewServer()
+			DeferCleanup(server.Stop)
+			ffv1.RegisterClustersServer(server.Registrar(), &testing.ClustersServerFuncs{
+				GetFunc: func(ctx context.Context,
+					request *ffv1.ClustersGetRequest) (*ffv1.ClustersGetResponse, error) {
+					received, _ = metadata.FromIncomingContext(ctx)
+					return ffv1.ClustersGetResponse_builder{
+						Object: ffv1.Cluster_builder{
+							Id: request.GetId(),
+						}.Build(),
+					}.Build(), nil
+				},
+			})
+			server.Start()
+
+			// Send the call from inside the root span:
+			conn, err := internalnetwork.NewGrpcClient().
+				SetLogger(logger).
+				SetPlaintext(true).
+				SetAddress(server.Address()).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(conn.Close)
+			ctx = provider.Start(ctx, "fulfillment-cli get")
+			client := ffv1.NewClustersClient(conn)
+			_, err = client.Get(ctx, ffv1.ClustersGetRequest_builder{
+				Id: "123",
+			}.Build())
+			Expect(err).ToNot(HaveOccurred())
+			Finish(ctx, nil)
+
+			// Check that the call span is a child of the root span:
+			spans := exporter.GetSpans()
+			Expect(spans).To(HaveLen(2))
+			call := spans[0]
+			root := spans[1]
+			Expect(root.Name).To(Equal("fulfillment-cli get"))
+			Expect(call.Name).To(Equal("fulfillment.v1.Clusters/Get"))
+			Expect(call.SpanKind).To(Equal(trace.SpanKindClient))
+			Expect(call.SpanContext.TraceID()).To(Equal(root.SpanContext.TraceID()))
+			Expect(call.Parent.SpanID()).To(Equal(root.SpanContext.SpanID()))
+
+			// Check that the server received the W3C trace context of the call span:
+			Expect(received.Get("traceparent")).To(ConsistOf(
+				"00-" + call.SpanContext.TraceID().String() + "-" + call.SpanContext.SpanID().String() + "-01",
+			))
+		})
+
+		It("Creates spans for HTTP requests", func() {
+			var received http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+			}))
+			DeferCleanup(server.Close)
+			ctx = provider.Start(ctx, "fulfillment-cli login")
+			client := &http.Client{
+				Transport: internalnetwork.NewHttpTransport(nil, false, nil),
+			}
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			Expect(err).ToNot(HaveOccurred())
+			response, err := client.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+			response.Body.Close()
+			Finish(ctx, nil)
+			spans := exporter.GetSpans()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].SpanKind).To(Equal(trace.SpanKindClient))
+			Expect(spans[0].Parent.SpanID()).To(Equal(spans[1].SpanContext.SpanID()))
+			Expect(received.Get("traceparent")).ToNot(BeEmpty())
+		})
+	})
+})
+
+// keepingExporter wraps the in-memory exporter so that the spans aren't discarded when the provider is shut down.
+type keepingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (e keepingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
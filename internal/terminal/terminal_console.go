@@ -14,6 +14,7 @@ language governing permissions and limitations under the License.
 package terminal
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -23,6 +24,7 @@ import (
 	iofs "io/fs"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/alecthomas/chroma/v2/formatters"
@@ -40,18 +42,23 @@ import (
 // ConsoleBuilder contains the data and logic needed to create a console. Don't create objects of this type directly,
 // use the NewConsole function instead.
 type ConsoleBuilder struct {
-	logger *slog.Logger
-	writer io.Writer
-	helper *reflection.Helper
+	logger      *slog.Logger
+	writer      io.Writer
+	reader      io.Reader
+	interactive *bool
+	helper      *reflection.Helper
 }
 
 // Console is helps writing messages to the console. Don't create objects of this type directly, use the NewConsole
 // function instead.
 type Console struct {
-	logger *slog.Logger
-	writer io.Writer
-	engine *templating.Engine
-	helper *reflection.Helper
+	logger      *slog.Logger
+	writer      io.Writer
+	reader      *bufio.Reader
+	interactive bool
+	engine      *templating.Engine
+	helper      *reflection.Helper
+	lookupCache *rendering.LookupCache
 }
 
 // NewConsole creates a builder that can the be used to create a template engine.
@@ -72,6 +79,20 @@ func (b *ConsoleBuilder) SetWriter(value io.Writer) *ConsoleBuilder {
 	return b
 }
 
+// SetReader sets the reader that the console will use to read the answers of the user. This is optional, the default
+// is to use os.Stdin and there is usually no need to change it; it is intended for unit tests.
+func (b *ConsoleBuilder) SetReader(value io.Reader) *ConsoleBuilder {
+	b.reader = value
+	return b
+}
+
+// SetInteractive sets whether the console can ask questions to the user. This is optional, by default the console is
+// interactive when both the reader and the writer are terminals.
+func (b *ConsoleBuilder) SetInteractive(value bool) *ConsoleBuilder {
+	b.interactive = &value
+	return b
+}
+
 // SetHelper sets the reflection helper that will be used to introspect objects. This is optional. If not set then
 // functions like 'table' that need reflection will not be available.
 func (b *ConsoleBuilder) SetHelper(value *reflection.Helper) *ConsoleBuilder {
@@ -87,17 +108,40 @@ func (b *ConsoleBuilder) Build() (result *Console, err error) {
 		return
 	}
 
-	// Set the default writer if needed:
+	// Set the default writer and reader if needed:
 	writer := b.writer
 	if writer == nil {
 		writer = os.Stdout
 	}
+	reader := b.reader
+	if reader == nil {
+		reader = os.Stdin
+	}
+
+	// Check if the console is interactive:
+	var interactive bool
+	if b.interactive != nil {
+		interactive = *b.interactive
+	} else {
+		interactive = isTerminal(reader) && isTerminal(writer)
+	}
+
+	// Create the cache for the names of the objects displayed by the 'table' function, so that they aren't requested
+	// again each time that the function is used:
+	lookupCache, err := rendering.NewLookupCache().Build()
+	if err != nil {
+		err = fmt.Errorf("failed to create lookup cache: %w", err)
+		return
+	}
 
 	// Create the console object first so we can reference its methods when building the template engine:
 	console := &Console{
-		logger: b.logger,
-		writer: writer,
-		helper: b.helper,
+		logger:      b.logger,
+		writer:      writer,
+		reader:      bufio.NewReader(reader),
+		interactive: interactive,
+		helper:      b.helper,
+		lookupCache: lookupCache,
 	}
 
 	// Create the template engine:
@@ -277,12 +321,63 @@ func (c *Console) renderColored(ctx context.Context, text string, format string)
 	return formatter.Format(colorable.NewColorable(file), style, iterator)
 }
 
+// Interactive returns true if the console can be used to ask questions to the user.
+func (c *Console) Interactive() bool {
+	return c.interactive
+}
+
+// Choose asks the user to choose one of the given options, and returns the index of the selected option. The options
+// are presented as a numbered list, and the user is asked again till the answer is one of those numbers. It returns
+// an error if the console isn't interactive or if there is no more input.
+func (c *Console) Choose(ctx context.Context, prompt string, options []string) (result int, err error) {
+	if !c.interactive {
+		err = errors.New("console isn't interactive")
+		return
+	}
+	if len(options) == 0 {
+		err = errors.New("there are no options to choose from")
+		return
+	}
+	c.Printf(ctx, "%s\n\n", prompt)
+	for i, option := range options {
+		c.Printf(ctx, "  %d. %s\n", i+1, option)
+	}
+	c.Printf(ctx, "\n")
+	for {
+		c.Printf(ctx, "Enter a number between 1 and %d: ", len(options))
+		var line string
+		line, err = c.reader.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			err = fmt.Errorf("failed to read answer: %w", err)
+			return
+		}
+		answer := strings.TrimSpace(line)
+		number, parseErr := strconv.Atoi(answer)
+		if parseErr == nil && number >= 1 && number <= len(options) {
+			result = number - 1
+			err = nil
+			return
+		}
+		c.Printf(ctx, "The answer '%s' isn't valid.\n", answer)
+		if err != nil {
+			err = fmt.Errorf("failed to read answer: %w", err)
+			return
+		}
+	}
+}
+
 // Write is an implementation of the io.Write interface that allows the console to be used as a writer if needed.
 func (c *Console) Write(p []byte) (n int, err error) {
 	n, err = c.writer.Write(p)
 	return
 }
 
+// isTerminal checks if the given reader or writer is a terminal.
+func isTerminal(value any) bool {
+	file, ok := value.(*os.File)
+	return ok && isatty.IsTerminal(file.Fd())
+}
+
 // tableFunc is a template function that renders a list of objects as a table. The objects parameter must be a slice
 // of objects that implement the proto.Message interface. This will not work and return an error if the reflection
 // helper is not set.
@@ -296,6 +391,7 @@ func (c *Console) tableFunc(objects any) (result string, err error) {
 		SetLogger(c.logger).
 		SetHelper(c.helper).
 		SetWriter(&buffer).
+		SetLookupCache(c.lookupCache).
 		Build()
 	if err != nil {
 		err = fmt.Errorf("failed to create table renderer: %w", err)
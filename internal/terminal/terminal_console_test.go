@@ -14,7 +14,9 @@ language governing permissions and limitations under the License.
 package terminal
 
 import (
+	"bytes"
 	"os"
+	"strings"
 
 	"github.com/innabox/fulfillment-common/text"
 	. "github.com/onsi/ginkgo/v2/dsl/core"
@@ -190,4 +192,64 @@ var _ = Describe("Console", func() {
 			]`))
 		})
 	})
+
+	Describe("Choose", func() {
+		// makeConsole creates an interactive console that reads the given input and writes to the given buffer.
+		makeConsole := func(input string, output *bytes.Buffer) *Console {
+			console, err := NewConsole().
+				SetLogger(logger).
+				SetReader(strings.NewReader(input)).
+				SetWriter(output).
+				SetInteractive(true).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			return console
+		}
+
+		It("Isn't interactive if the reader and writer aren't terminals", func() {
+			console, err := NewConsole().
+				SetLogger(logger).
+				SetReader(strings.NewReader("")).
+				SetWriter(&bytes.Buffer{}).
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(console.Interactive()).To(BeFalse())
+			_, err = console.Choose(ctx, "Select one:", []string{"a", "b"})
+			Expect(err).To(MatchError("console isn't interactive"))
+		})
+
+		It("Returns the selected option", func() {
+			output := &bytes.Buffer{}
+			console := makeConsole("2\n", output)
+			result, err := console.Choose(ctx, "Select one:", []string{"a", "b"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(1))
+			Expect(output.String()).To(Equal(
+				"Select one:\n\n  1. a\n  2. b\n\nEnter a number between 1 and 2: ",
+			))
+		})
+
+		It("Asks again if the answer isn't valid", func() {
+			output := &bytes.Buffer{}
+			console := makeConsole("junk\n3\n1\n", output)
+			result, err := console.Choose(ctx, "Select one:", []string{"a", "b"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(0))
+			Expect(output.String()).To(ContainSubstring("The answer 'junk' isn't valid."))
+			Expect(output.String()).To(ContainSubstring("The answer '3' isn't valid."))
+		})
+
+		It("Accepts last answer without new line", func() {
+			console := makeConsole("2", &bytes.Buffer{})
+			result, err := console.Choose(ctx, "Select one:", []string{"a", "b"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(1))
+		})
+
+		It("Fails if there is no more input", func() {
+			console := makeConsole("junk\n", &bytes.Buffer{})
+			_, err := console.Choose(ctx, "Select one:", []string{"a", "b"})
+			Expect(err).To(MatchError(ContainSubstring("failed to read answer")))
+		})
+	})
 })
@@ -0,0 +1,154 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// EditorBuilder contains the data and logic needed to create an editor. Don't create objects of this type directly,
+// use the NewEditor function instead.
+type EditorBuilder struct {
+	logger *slog.Logger
+}
+
+// Editor knows how to run the editor selected by the user to modify a piece of text. Don't create objects of this
+// type directly, use the NewEditor function instead.
+type Editor struct {
+	logger *slog.Logger
+}
+
+// NewEditor creates a builder that can then be used to create an editor.
+func NewEditor() *EditorBuilder {
+	return &EditorBuilder{}
+}
+
+// SetLogger sets the logger that the editor will use to write messages to the log. This is mandatory.
+func (b *EditorBuilder) SetLogger(value *slog.Logger) *EditorBuilder {
+	b.logger = value
+	return b
+}
+
+// Build uses the configuration stored in the builder to create a new editor.
+func (b *EditorBuilder) Build() (result *Editor, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+
+	// Create and populate the object:
+	result = &Editor{
+		logger: b.logger,
+	}
+	return
+}
+
+// Edit writes the given data to a temporary file with the given name, runs the editor on that file and returns the
+// potentially modified content. The name is used only to help the user and the editor, for example to select the
+// syntax highlighting according to the extension.
+func (e *Editor) Edit(ctx context.Context, name string, data []byte) (result []byte, err error) {
+	// Write the data to a temporary file:
+	tmpDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return
+	}
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		if err != nil {
+			e.logger.ErrorContext(
+				ctx,
+				"Failed to remove temporary directory",
+				slog.String("dir", tmpDir),
+				slog.Any("error", err),
+			)
+		}
+	}()
+	tmpFile := filepath.Join(tmpDir, name)
+	err = os.WriteFile(tmpFile, data, 0600)
+	if err != nil {
+		err = fmt.Errorf("failed to create temporary file '%s': %w", tmpFile, err)
+		return
+	}
+
+	// Run the editor:
+	editorName := e.findEditor(ctx)
+	editorPath, err := exec.LookPath(editorName)
+	if err != nil {
+		err = fmt.Errorf("failed to find editor command '%s': %w", editorName, err)
+		return
+	}
+	editorCmd := &exec.Cmd{
+		Path: editorPath,
+		Args: []string{
+			editorName,
+			tmpFile,
+		},
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	err = editorCmd.Run()
+	if err != nil {
+		err = fmt.Errorf("failed to edit: %w", err)
+		return
+	}
+
+	// Load the potentially modified file:
+	result, err = os.ReadFile(tmpFile)
+	if err != nil {
+		err = fmt.Errorf("failed to read back temporary file '%s': %w", tmpFile, err)
+		return
+	}
+	return
+}
+
+// findEditor tries to find the name of the editor command. It will first try with the content of the `EDITOR` and
+// `VISUAL` environment variables, and if those are empty it defaults to `vi`.
+func (e *Editor) findEditor(ctx context.Context) string {
+	for _, editorEnvVar := range editorEnvVars {
+		value, ok := os.LookupEnv(editorEnvVar)
+		if ok && value != "" {
+			e.logger.DebugContext(
+				ctx,
+				"Found editor using environment variable",
+				slog.String("var", editorEnvVar),
+				slog.String("value", value),
+			)
+			return value
+		}
+	}
+	e.logger.InfoContext(
+		ctx,
+		"Didn't find a editor in the environment, will use the default",
+		slog.Any("vars", editorEnvVars),
+		slog.String("default", defaultEditor),
+	)
+	return defaultEditor
+}
+
+// editorEnvVars is the list of environment variables that will be used to obtain the name of the editor command.
+var editorEnvVars = []string{
+	"EDITOR",
+	"VISUAL",
+}
+
+// defualtEditor is the editor used when the environment variables don't indicate any other editor.
+const defaultEditor = "vi"
@@ -0,0 +1,155 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package testing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// Certificates contains a certificate authority and server and client certificates signed by it, intended for tests
+// that need TLS connections with client authentication.
+type Certificates struct {
+	// CaFile is the path of the PEM file containing the certificate of the certificate authority.
+	CaFile string
+
+	// CaPool is a pool containing only the certificate of the certificate authority.
+	CaPool *x509.CertPool
+
+	// ServerCertFile and ServerKeyFile are the paths of the PEM files containing the server certificate, valid
+	// for 'localhost' and '127.0.0.1', and its key.
+	ServerCertFile string
+	ServerKeyFile  string
+
+	// ClientCertFile and ClientKeyFile are the paths of the PEM files containing the client certificate and its
+	// key.
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// ClientKey is the key of the client certificate, useful for tests that need to encode it differently.
+	ClientKey *ecdsa.PrivateKey
+}
+
+// MakeCertificates generates a new certificate authority and server and client certificates, and writes them to
+// PEM files inside the given directory.
+func MakeCertificates(dir string) *Certificates {
+	result := &Certificates{}
+
+	// Create the certificate authority:
+	caKey := makeKey()
+	caTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName: "Test CA",
+		},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	Expect(err).ToNot(HaveOccurred())
+	caCert, err := x509.ParseCertificate(caDer)
+	Expect(err).ToNot(HaveOccurred())
+	result.CaFile = writePem(dir, "ca.pem", "CERTIFICATE", caDer)
+	result.CaPool = x509.NewCertPool()
+	result.CaPool.AddCert(caCert)
+
+	// Create the server certificate:
+	serverKey := makeKey()
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName: "localhost",
+		},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:   time.Now().Add(-time.Minute),
+		NotAfter:    time.Now().Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverDer, err := x509.CreateCertificate(rand.Reader, serverTemplate, caCert, serverKey.Public(), caKey)
+	Expect(err).ToNot(HaveOccurred())
+	result.ServerCertFile = writePem(dir, "server.crt", "CERTIFICATE", serverDer)
+	result.ServerKeyFile = writePem(dir, "server.key", "PRIVATE KEY", marshalKey(serverKey))
+
+	// Create the client certificate:
+	clientKey := makeKey()
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject: pkix.Name{
+			CommonName: "client",
+		},
+		NotBefore:   time.Now().Add(-time.Minute),
+		NotAfter:    time.Now().Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDer, err := x509.CreateCertificate(rand.Reader, clientTemplate, caCert, clientKey.Public(), caKey)
+	Expect(err).ToNot(HaveOccurred())
+	result.ClientCertFile = writePem(dir, "client.crt", "CERTIFICATE", clientDer)
+	result.ClientKeyFile = writePem(dir, "client.key", "PRIVATE KEY", marshalKey(clientKey))
+	result.ClientKey = clientKey
+
+	return result
+}
+
+// ServerConfig returns a TLS configuration for a server that uses the server certificate and requires clients to
+// present a certificate signed by the certificate authority.
+func (c *Certificates) ServerConfig() *tls.Config {
+	certificate, err := tls.LoadX509KeyPair(c.ServerCertFile, c.ServerKeyFile)
+	Expect(err).ToNot(HaveOccurred())
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    c.CaPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+func makeKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	return key
+}
+
+func marshalKey(key *ecdsa.PrivateKey) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	return der
+}
+
+func writePem(dir, name, kind string, der []byte) string {
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:  kind,
+		Bytes: der,
+	})
+	err := os.WriteFile(path, data, 0600)
+	Expect(err).ToNot(HaveOccurred())
+	return path
+}
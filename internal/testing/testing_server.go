@@ -15,15 +15,19 @@ package testing
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"net"
 	"strings"
 
 	eventsv1 "github.com/innabox/fulfillment-common/api/events/v1"
 	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	metadatav1 "github.com/innabox/fulfillment-common/api/metadata/v1"
 	. "github.com/onsi/ginkgo/v2/dsl/core"
 	. "github.com/onsi/gomega"
 	"google.golang.org/genproto/googleapis/api/httpbody"
 	"google.golang.org/grpc"
+	experimentalcredentials "google.golang.org/grpc/experimental/credentials"
 )
 
 // Server is a gRPC server used only for tests.
@@ -43,6 +47,34 @@ func NewServer() *Server {
 	}
 }
 
+// NewTLSServer creates a new gRPC server that listens in a randomly selected port in the local host and uses TLS with
+// the given configuration.
+func NewTLSServer(config *tls.Config) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	// We need to disable ALPN because the clients created by the CLI don't support it.
+	server := grpc.NewServer(
+		grpc.Creds(experimentalcredentials.NewTLSWithALPNDisabled(config)),
+	)
+	return &Server{
+		listener: listener,
+		server:   server,
+	}
+}
+
+// NewUnixServer creates a new gRPC server that listens in the given Unix socket. Names starting with '@' are abstract
+// sockets, which are only supported in Linux.
+func NewUnixServer(path string) *Server {
+	listener, err := net.Listen("unix", path)
+	Expect(err).ToNot(HaveOccurred())
+	server := grpc.NewServer()
+	return &Server{
+		listener: listener,
+		server:   server,
+	}
+}
+
 // Adress returns the address where the server is listening.
 func (s *Server) Address() string {
 	return s.listener.Addr().String()
@@ -59,6 +91,10 @@ func (s *Server) Start() {
 	go func() {
 		defer GinkgoRecover()
 		err := s.server.Serve(s.listener)
+		if errors.Is(err, grpc.ErrServerStopped) {
+			// This happens when the test finishes and stops the server before it actually started to serve.
+			return
+		}
 		Expect(err).ToNot(HaveOccurred())
 	}()
 }
@@ -322,6 +358,23 @@ func (s *EventsServerFuncs) Watch(request *eventsv1.EventsWatchRequest, stream e
 	return s.WatchFunc(request, stream)
 }
 
+// Make sure that we implement the interface.
+var _ metadatav1.MetadataServer = (*MetadataServerFuncs)(nil)
+
+// MetadataServerFuncs is an implementation of the metadata server that uses configurable functions to implement the
+// methods.
+type MetadataServerFuncs struct {
+	metadatav1.UnimplementedMetadataServer
+
+	GetFunc func(context.Context, *metadatav1.MetadataGetRequest) (*metadatav1.MetadataGetResponse, error)
+}
+
+func (s *MetadataServerFuncs) Get(ctx context.Context,
+	request *metadatav1.MetadataGetRequest) (response *metadatav1.MetadataGetResponse, err error) {
+	response, err = s.GetFunc(ctx, request)
+	return
+}
+
 // Helper function to extract object ID from event
 func GetEventObjectID(event *eventsv1.Event) string {
 	switch payload := event.Payload.(type) {
@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+// Package timeout contains the support for the global '--timeout' flag: the default timeouts of the commands, the
+// functions to store the timeout in the context and the error returned when a call to the server times out.
+package timeout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+)
+
+// annotation is the name of the command annotation that contains the default timeout of the command.
+const annotation = "timeout"
+
+// SetDefault sets the timeout that will be used for the given command and its sub-commands when the '--timeout' flag
+// isn't used. A zero value means that there is no timeout.
+func SetDefault(cmd *cobra.Command, value time.Duration) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[annotation] = value.String()
+}
+
+// ForCommand returns the timeout that should be used for the given command when the '--timeout' flag isn't used. This
+// is the value set with the SetDefault function for the command or for the nearest of its parents. Commands that watch
+// for changes run till the user interrupts them, so when the '--watch' flag is used there is no default timeout.
+func ForCommand(cmd *cobra.Command) time.Duration {
+	watch := cmd.Flags().Lookup("watch")
+	if watch != nil && watch.Value.String() == "true" {
+		return 0
+	}
+	for current := cmd; current != nil; current = current.Parent() {
+		text, ok := current.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		value, err := time.ParseDuration(text)
+		if err != nil {
+			return 0
+		}
+		return value
+	}
+	return 0
+}
+
+// contextKey is the type used to store the timeout in the context.
+type contextKey int
+
+const (
+	timeoutKey contextKey = iota
+)
+
+// IntoContext creates a new context that contains the given timeout. Note that this only stores the value so that it
+// can later be used in error messages, it doesn't set the deadline of the context.
+func IntoContext(ctx context.Context, value time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey, value)
+}
+
+// FromContext returns the timeout stored in the context, or zero if there is no timeout.
+func FromContext(ctx context.Context) time.Duration {
+	value, _ := ctx.Value(timeoutKey).(time.Duration)
+	return value
+}
+
+// Error is the error returned when a call to the server doesn't complete before the deadline of the context.
+type Error struct {
+	method  string
+	timeout time.Duration
+	cause   error
+}
+
+// Method returns the full name of the gRPC method that timed out, for example '/fulfillment.v1.Clusters/Get'.
+func (e *Error) Method() string {
+	return e.method
+}
+
+// Timeout returns the timeout that was exceeded, or zero if it isn't known.
+func (e *Error) Timeout() time.Duration {
+	return e.timeout
+}
+
+// Error is the implementation of the error interface.
+func (e *Error) Error() string {
+	if e.timeout > 0 {
+		return fmt.Sprintf(
+			"call to '%s' didn't complete within %s, use the '--timeout' flag to change the limit",
+			e.method, e.timeout,
+		)
+	}
+	return fmt.Sprintf("call to '%s' didn't complete before the deadline", e.method)
+}
+
+// Unwrap returns the original error returned by the gRPC library.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// GRPCStatus returns the status of the original error, so that functions like status.Code still work when the
+// error has been replaced.
+func (e *Error) GRPCStatus() *status.Status {
+	return status.Convert(e.cause)
+}
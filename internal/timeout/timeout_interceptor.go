@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package timeout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorBuilder contains the data and logic needed to build an interceptor that replaces the errors caused by
+// the deadline of the context with errors that contain the name of the method that timed out. Don't create instances
+// of this type directly, use the NewInterceptor function instead.
+type InterceptorBuilder struct {
+	logger *slog.Logger
+}
+
+// Interceptor contains the data needed by the interceptor.
+type Interceptor struct {
+	logger *slog.Logger
+}
+
+// NewInterceptor creates a builder that can then be used to configure and create a interceptor.
+func NewInterceptor() *InterceptorBuilder {
+	return &InterceptorBuilder{}
+}
+
+// SetLogger sets the logger that will be used by the intercetor. This is mandatory.
+func (b *InterceptorBuilder) SetLogger(value *slog.Logger) *InterceptorBuilder {
+	b.logger = value
+	return b
+}
+
+// Build uses the data stored in the builder to create and configure a new interceptor.
+func (b *InterceptorBuilder) Build() (result *Interceptor, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+
+	// Create and populate the object:
+	result = &Interceptor{
+		logger: b.logger,
+	}
+	return
+}
+
+// UnaryClient is the unary client interceptor function that replaces deadline errors.
+func (i *Interceptor) UnaryClient(ctx context.Context, method string, request, response any,
+	conn *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, request, response, conn, opts...)
+	return i.replaceError(ctx, method, err)
+}
+
+// StreamClient is the stream client interceptor function that replaces deadline errors, both when creating the stream
+// and when receiving messages.
+func (i *Interceptor) StreamClient(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, conn, method, opts...)
+	if err != nil {
+		return nil, i.replaceError(ctx, method, err)
+	}
+	return &interceptorStream{
+		ClientStream: stream,
+		interceptor:  i,
+		ctx:          ctx,
+		method:       method,
+	}, nil
+}
+
+// replaceError checks if the given error was caused by the deadline of the context, and in that case returns a new
+// error that contains the name of the method and the timeout. Other errors are returned unchanged.
+func (i *Interceptor) replaceError(ctx context.Context, method string, err error) error {
+	if err == nil || status.Code(err) != codes.DeadlineExceeded {
+		return err
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	result := &Error{
+		method:  method,
+		timeout: FromContext(ctx),
+		cause:   err,
+	}
+	i.logger.DebugContext(
+		ctx,
+		"Call timed out",
+		slog.String("method", method),
+		slog.Duration("timeout", result.timeout),
+		slog.Any("error", err),
+	)
+	return result
+}
+
+// interceptorStream wraps a client stream so that the errors returned when receiving messages can be replaced.
+type interceptorStream struct {
+	grpc.ClientStream
+	interceptor *Interceptor
+	ctx         context.Context
+	method      string
+}
+
+// RecvMsg is part of the implementation of the grpc.ClientStream interface.
+func (s *interceptorStream) RecvMsg(message any) error {
+	err := s.ClientStream.RecvMsg(message)
+	return s.interceptor.replaceError(s.ctx, s.method, err)
+}
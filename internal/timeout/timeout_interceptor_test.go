@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package timeout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Interceptor", func() {
+	var interceptor *Interceptor
+
+	// invoker simulates a server that doesn't respond till the context is done.
+	invoker := func(ctx context.Context, method string, request, response any, conn *grpc.ClientConn,
+		opts ...grpc.CallOption) error {
+		<-ctx.Done()
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+
+	BeforeEach(func() {
+		var err error
+		interceptor, err = NewInterceptor().
+			SetLogger(logger).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Can't be created without a logger", func() {
+		_, err := NewInterceptor().
+			Build()
+		Expect(err).To(MatchError("logger is mandatory"))
+	})
+
+	It("Replaces the error when the deadline of the context is exceeded", func() {
+		ctx := IntoContext(context.Background(), 10*time.Millisecond)
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		err := interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil, invoker)
+		var timeoutErr *Error
+		Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+		Expect(timeoutErr.Method()).To(Equal("/fulfillment.v1.Clusters/Get"))
+		Expect(timeoutErr.Timeout()).To(Equal(10 * time.Millisecond))
+		Expect(err).To(MatchError(ContainSubstring("'/fulfillment.v1.Clusters/Get' didn't complete within 10ms")))
+		Expect(status.Code(err)).To(Equal(codes.DeadlineExceeded))
+	})
+
+	It("Preserves deadline errors that aren't caused by the context", func() {
+		original := status.Error(codes.DeadlineExceeded, "server deadline")
+		err := interceptor.UnaryClient(
+			context.Background(), "/fulfillment.v1.Clusters/Get", nil, nil, nil,
+			func(ctx context.Context, method string, request, response any, conn *grpc.ClientConn,
+				opts ...grpc.CallOption) error {
+				return original
+			},
+		)
+		Expect(err).To(BeIdenticalTo(original))
+	})
+
+	It("Preserves other errors", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		original := status.Error(codes.Unavailable, "unavailable")
+		err := interceptor.UnaryClient(
+			ctx, "/fulfillment.v1.Clusters/Get", nil, nil, nil,
+			func(ctx context.Context, method string, request, response any, conn *grpc.ClientConn,
+				opts ...grpc.CallOption) error {
+				return original
+			},
+		)
+		Expect(err).To(BeIdenticalTo(original))
+	})
+
+	It("Replaces the error when creating a stream", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := interceptor.StreamClient(
+			ctx, &grpc.StreamDesc{}, nil, "/events.v1.Events/Watch",
+			func(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string,
+				opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return nil, invoker(ctx, method, nil, nil, conn, opts...)
+			},
+		)
+		var timeoutErr *Error
+		Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+		Expect(timeoutErr.Method()).To(Equal("/events.v1.Events/Watch"))
+		Expect(err).To(MatchError(ContainSubstring("didn't complete before the deadline")))
+	})
+})
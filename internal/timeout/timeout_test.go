@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package timeout
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+var _ = Describe("For command", func() {
+	var (
+		parent *cobra.Command
+		child  *cobra.Command
+	)
+
+	BeforeEach(func() {
+		parent = &cobra.Command{
+			Use: "parent",
+		}
+		child = &cobra.Command{
+			Use: "child",
+		}
+		child.Flags().Bool("watch", false, "Watch")
+		parent.AddCommand(child)
+	})
+
+	It("Returns zero if no default has been set", func() {
+		Expect(ForCommand(child)).To(BeZero())
+	})
+
+	It("Returns the default of the command", func() {
+		SetDefault(child, time.Minute)
+		Expect(ForCommand(child)).To(Equal(time.Minute))
+	})
+
+	It("Returns the default of the parent", func() {
+		SetDefault(parent, 10*time.Minute)
+		Expect(ForCommand(child)).To(Equal(10 * time.Minute))
+	})
+
+	It("Prefers the default of the command to the default of the parent", func() {
+		SetDefault(parent, 10*time.Minute)
+		SetDefault(child, time.Minute)
+		Expect(ForCommand(child)).To(Equal(time.Minute))
+	})
+
+	It("Returns zero when watching", func() {
+		SetDefault(child, time.Minute)
+		err := child.Flags().Parse([]string{"--watch"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ForCommand(child)).To(BeZero())
+	})
+})
+
+var _ = Describe("Context", func() {
+	It("Returns zero if there is no timeout in the context", func() {
+		Expect(FromContext(context.Background())).To(BeZero())
+	})
+
+	It("Returns the timeout stored in the context", func() {
+		ctx := IntoContext(context.Background(), 30*time.Second)
+		Expect(FromContext(ctx)).To(Equal(30 * time.Second))
+	})
+})
@@ -0,0 +1,327 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+// Package trace contains a gRPC client interceptor that writes the details of the calls sent to the server, so that
+// users can see what the tool actually sends and receives.
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/innabox/fulfillment-cli/internal/credentials"
+)
+
+// InterceptorBuilder contains the data and logic needed to build an interceptor that writes the details of the calls.
+// Don't create instances of this type directly, use the NewInterceptor function instead.
+type InterceptorBuilder struct {
+	logger *slog.Logger
+	writer io.Writer
+}
+
+// Interceptor contains the data needed by the interceptor.
+type Interceptor struct {
+	logger *slog.Logger
+	writer io.Writer
+	lock   *sync.Mutex
+}
+
+// NewInterceptor creates a builder that can then be used to configure and create a interceptor.
+func NewInterceptor() *InterceptorBuilder {
+	return &InterceptorBuilder{}
+}
+
+// SetLogger sets the logger that will be used by the intercetor. This is mandatory.
+func (b *InterceptorBuilder) SetLogger(value *slog.Logger) *InterceptorBuilder {
+	b.logger = value
+	return b
+}
+
+// SetWriter sets the writer where the details of the calls will be written, usually the standard error of the
+// process. This is mandatory.
+func (b *InterceptorBuilder) SetWriter(value io.Writer) *InterceptorBuilder {
+	b.writer = value
+	return b
+}
+
+// Build uses the data stored in the builder to create and configure a new interceptor.
+func (b *InterceptorBuilder) Build() (result *Interceptor, err error) {
+	// Check parameters:
+	if b.logger == nil {
+		err = errors.New("logger is mandatory")
+		return
+	}
+	if b.writer == nil {
+		err = errors.New("writer is mandatory")
+		return
+	}
+
+	// Create and populate the object:
+	result = &Interceptor{
+		logger: b.logger,
+		writer: b.writer,
+		lock:   &sync.Mutex{},
+	}
+	return
+}
+
+// UnaryClient is the unary client interceptor function that writes the details of each call once it has finished.
+func (i *Interceptor) UnaryClient(ctx context.Context, method string, request, response any,
+	conn *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var header, trailer metadata.MD
+	opts = append(slices.Clip(opts), grpc.Header(&header), grpc.Trailer(&trailer))
+	start := time.Now()
+	err := invoker(ctx, method, request, response, conn, opts...)
+	record := &traceRecord{
+		Method:          method,
+		RequestHeaders:  i.outgoingHeaders(ctx),
+		Request:         i.marshalMessage(ctx, request),
+		ResponseHeaders: redactHeaders(header),
+		Status:          i.marshalStatus(ctx, err),
+		Trailers:        redactHeaders(trailer),
+		Duration:        time.Since(start).String(),
+	}
+	if err == nil {
+		record.Response = i.marshalMessage(ctx, response)
+	}
+	i.write(ctx, record)
+	return err
+}
+
+// StreamClient is the stream client interceptor function that writes the details of the creation of streams and of
+// each message sent and received.
+func (i *Interceptor) StreamClient(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	stream, err := streamer(ctx, desc, conn, method, opts...)
+	record := &traceRecord{
+		Method:         method,
+		RequestHeaders: i.outgoingHeaders(ctx),
+	}
+	if err != nil {
+		record.Status = i.marshalStatus(ctx, err)
+		record.Duration = time.Since(start).String()
+		i.write(ctx, record)
+		return nil, err
+	}
+	i.write(ctx, record)
+	return &interceptorStream{
+		ClientStream: stream,
+		interceptor:  i,
+		ctx:          ctx,
+		method:       method,
+		start:        start,
+	}, nil
+}
+
+// outgoingHeaders returns the redacted metadata that will be sent with the call. Note that this doesn't include the
+// headers added by the credentials of the connection, as those are added later by the gRPC library.
+func (i *Interceptor) outgoingHeaders(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return redactHeaders(md)
+}
+
+// marshalMessage converts the given protocol buffers message to JSON, redacting the sensitive fields.
+func (i *Interceptor) marshalMessage(ctx context.Context, message any) json.RawMessage {
+	value, ok := message.(proto.Message)
+	if !ok || value == nil {
+		return nil
+	}
+	data, err := protojson.Marshal(value)
+	if err != nil {
+		i.logger.DebugContext(
+			ctx,
+			"Failed to marshal message for trace",
+			slog.Any("error", err),
+		)
+		return nil
+	}
+	return redactJson(data)
+}
+
+// marshalStatus converts the status of the given error to JSON.
+func (i *Interceptor) marshalStatus(ctx context.Context, err error) json.RawMessage {
+	data, marshalErr := protojson.Marshal(status.Convert(err).Proto())
+	if marshalErr != nil {
+		i.logger.DebugContext(
+			ctx,
+			"Failed to marshal status for trace",
+			slog.Any("error", marshalErr),
+		)
+		return nil
+	}
+	return data
+}
+
+// write writes the given record to the output, as indented JSON. Errors are only logged, as tracing should never
+// make calls fail.
+func (i *Interceptor) write(ctx context.Context, record *traceRecord) {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		i.logger.DebugContext(
+			ctx,
+			"Failed to marshal trace record",
+			slog.Any("error", err),
+		)
+		return
+	}
+	data = append(data, '\n')
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	_, err = i.writer.Write(data)
+	if err != nil {
+		i.logger.DebugContext(
+			ctx,
+			"Failed to write trace record",
+			slog.Any("error", err),
+		)
+	}
+}
+
+// interceptorStream wraps a client stream so that the messages sent and received can be written.
+type interceptorStream struct {
+	grpc.ClientStream
+	interceptor *Interceptor
+	ctx         context.Context
+	method      string
+	start       time.Time
+}
+
+// SendMsg is part of the implementation of the grpc.ClientStream interface.
+func (s *interceptorStream) SendMsg(message any) error {
+	err := s.ClientStream.SendMsg(message)
+	s.interceptor.write(s.ctx, &traceRecord{
+		Method: s.method,
+		Sent:   s.interceptor.marshalMessage(s.ctx, message),
+	})
+	return err
+}
+
+// RecvMsg is part of the implementation of the grpc.ClientStream interface. When the stream ends it also writes the
+// status, the trailers and the total duration of the stream.
+func (s *interceptorStream) RecvMsg(message any) error {
+	err := s.ClientStream.RecvMsg(message)
+	if err == nil {
+		s.interceptor.write(s.ctx, &traceRecord{
+			Method:   s.method,
+			Received: s.interceptor.marshalMessage(s.ctx, message),
+		})
+		return nil
+	}
+	statusErr := err
+	if errors.Is(err, io.EOF) {
+		statusErr = nil
+	}
+	s.interceptor.write(s.ctx, &traceRecord{
+		Method:   s.method,
+		Status:   s.interceptor.marshalStatus(s.ctx, statusErr),
+		Trailers: redactHeaders(s.ClientStream.Trailer()),
+		Duration: time.Since(s.start).String(),
+	})
+	return err
+}
+
+// traceRecord is the data written for each call, or for each event of a stream.
+type traceRecord struct {
+	Method          string          `json:"method"`
+	RequestHeaders  metadata.MD     `json:"request_headers,omitempty"`
+	Request         json.RawMessage `json:"request,omitempty"`
+	Sent            json.RawMessage `json:"sent,omitempty"`
+	Received        json.RawMessage `json:"received,omitempty"`
+	ResponseHeaders metadata.MD     `json:"response_headers,omitempty"`
+	Response        json.RawMessage `json:"response,omitempty"`
+	Status          json.RawMessage `json:"status,omitempty"`
+	Trailers        metadata.MD     `json:"trailers,omitempty"`
+	Duration        string          `json:"duration,omitempty"`
+}
+
+// redactHeaders returns a copy of the given metadata where the values of the sensitive headers have been replaced.
+func redactHeaders(md metadata.MD) metadata.MD {
+	if len(md) == 0 {
+		return nil
+	}
+	result := md.Copy()
+	for name, values := range result {
+		if !sensitiveHeaders[strings.ToLower(name)] {
+			continue
+		}
+		for j := range values {
+			values[j] = credentials.RedactedText
+		}
+	}
+	return result
+}
+
+// redactJson returns a copy of the given JSON document where the values of the sensitive fields have been replaced.
+// The fields are searched at any depth, so this also works for messages nested inside other messages or inside
+// 'Any' fields.
+func redactJson(data []byte) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	err := decoder.Decode(&value)
+	if err != nil {
+		return data
+	}
+	redactValue(value)
+	result, err := json.Marshal(value)
+	if err != nil {
+		return data
+	}
+	return result
+}
+
+func redactValue(value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		for name, field := range value {
+			if sensitiveFields[name] {
+				value[name] = credentials.RedactedText
+				continue
+			}
+			redactValue(field)
+		}
+	case []any:
+		for _, item := range value {
+			redactValue(item)
+		}
+	}
+}
+
+// sensitiveFields are the JSON names of the fields of messages whose values are replaced. This includes the
+// kubeconfig of hubs and the kubeconfigs and passwords returned for clusters.
+var sensitiveFields = map[string]bool{
+	"kubeconfig": true,
+	"password":   true,
+}
+
+// sensitiveHeaders are the names of the headers whose values are replaced.
+var sensitiveHeaders = map[string]bool{
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
@@ -0,0 +1,215 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	ffv1 "github.com/innabox/fulfillment-common/api/fulfillment/v1"
+	privatev1 "github.com/innabox/fulfillment-common/api/private/v1"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var _ = Describe("Interceptor", func() {
+	var (
+		ctx         context.Context
+		output      *bytes.Buffer
+		interceptor *Interceptor
+	)
+
+	// readRecords parses the records written by the interceptor.
+	readRecords := func() []map[string]any {
+		var result []map[string]any
+		decoder := json.NewDecoder(output)
+		for {
+			var record map[string]any
+			err := decoder.Decode(&record)
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			result = append(result, record)
+		}
+		return result
+	}
+
+	// respond returns an invoker that copies the given message to the response, and sets the given trailer.
+	respond := func(message proto.Message, trailer metadata.MD, err error) grpc.UnaryInvoker {
+		return func(ctx context.Context, method string, request, response any, conn *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			for _, opt := range opts {
+				trailerOpt, ok := opt.(grpc.TrailerCallOption)
+				if ok {
+					*trailerOpt.TrailerAddr = trailer
+				}
+			}
+			if message != nil {
+				proto.Merge(response.(proto.Message), message)
+			}
+			return err
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		output = &bytes.Buffer{}
+		var err error
+		interceptor, err = NewInterceptor().
+			SetLogger(logger).
+			SetWriter(output).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Can't be created without a writer", func() {
+		_, err := NewInterceptor().
+			SetLogger(logger).
+			Build()
+		Expect(err).To(MatchError("writer is mandatory"))
+	})
+
+	It("Writes the details of the call", func() {
+		request := ffv1.ClustersGetRequest_builder{
+			Id: "123",
+		}.Build()
+		response := &ffv1.ClustersGetResponse{}
+		invoker := respond(
+			ffv1.ClustersGetResponse_builder{
+				Object: ffv1.Cluster_builder{
+					Id: "123",
+				}.Build(),
+			}.Build(),
+			metadata.Pairs("my-trailer", "my-value"),
+			nil,
+		)
+		err := interceptor.UnaryClient(ctx, "/fulfillment.v1.Clusters/Get", request, response, nil, invoker)
+		Expect(err).ToNot(HaveOccurred())
+		records := readRecords()
+		Expect(records).To(HaveLen(1))
+		record := records[0]
+		Expect(record).To(HaveKeyWithValue("method", "/fulfillment.v1.Clusters/Get"))
+		Expect(record).To(HaveKeyWithValue("request", map[string]any{"id": "123"}))
+		Expect(record).To(HaveKeyWithValue("response", map[string]any{
+			"object": map[string]any{"id": "123"},
+		}))
+		Expect(record).To(HaveKeyWithValue("status", BeEmpty()))
+		Expect(record).To(HaveKeyWithValue("trailers", map[string]any{"my-trailer": []any{"my-value"}}))
+		Expect(record).To(HaveKey("duration"))
+	})
+
+	It("Writes the status of failed calls", func() {
+		invoker := respond(nil, nil, status.Error(codes.NotFound, "cluster not found"))
+		err := interceptor.UnaryClient(
+			ctx, "/fulfillment.v1.Clusters/Get", &ffv1.ClustersGetRequest{}, &ffv1.ClustersGetResponse{}, nil,
+			invoker,
+		)
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+		records := readRecords()
+		Expect(records).To(HaveLen(1))
+		Expect(records[0]).To(HaveKeyWithValue("status", map[string]any{
+			"code":    float64(codes.NotFound),
+			"message": "cluster not found",
+		}))
+		Expect(records[0]).ToNot(HaveKey("response"))
+	})
+
+	It("Redacts passwords", func() {
+		invoker := respond(
+			ffv1.ClustersGetPasswordResponse_builder{
+				Password: "my-password",
+			}.Build(),
+			nil,
+			nil,
+		)
+		err := interceptor.UnaryClient(
+			ctx, "/fulfillment.v1.Clusters/GetPassword", &ffv1.ClustersGetPasswordRequest{},
+			&ffv1.ClustersGetPasswordResponse{}, nil, invoker,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).ToNot(ContainSubstring("my-password"))
+		records := readRecords()
+		Expect(records[0]).To(HaveKeyWithValue("response", map[string]any{"password": "REDACTED"}))
+	})
+
+	It("Redacts kubeconfigs inside 'Any' fields", func() {
+		hub, err := anypb.New(privatev1.Hub_builder{
+			Id:         "my-hub",
+			Kubeconfig: []byte("my-kubeconfig"),
+		}.Build())
+		Expect(err).ToNot(HaveOccurred())
+		invoker := respond(hub, nil, nil)
+		err = interceptor.UnaryClient(ctx, "/private.v1.Hubs/Get", &anypb.Any{}, &anypb.Any{}, nil, invoker)
+		Expect(err).ToNot(HaveOccurred())
+		records := readRecords()
+		Expect(records[0]).To(HaveKeyWithValue("response", SatisfyAll(
+			HaveKeyWithValue("id", "my-hub"),
+			HaveKeyWithValue("kubeconfig", "REDACTED"),
+		)))
+	})
+
+	It("Redacts authorization headers", func() {
+		ctx = metadata.AppendToOutgoingContext(ctx, "Authorization", "Bearer my-token")
+		err := interceptor.UnaryClient(
+			ctx, "/fulfillment.v1.Clusters/Get", &ffv1.ClustersGetRequest{}, &ffv1.ClustersGetResponse{}, nil,
+			respond(nil, nil, nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).ToNot(ContainSubstring("my-token"))
+		records := readRecords()
+		Expect(records[0]).To(HaveKeyWithValue("request_headers", map[string]any{
+			"authorization": []any{"REDACTED"},
+		}))
+	})
+
+	It("Redacts cookie headers", func() {
+		ctx = metadata.AppendToOutgoingContext(ctx, "Cookie", "session=my-session", "my-header", "my-value")
+		err := interceptor.UnaryClient(
+			ctx, "/fulfillment.v1.Clusters/Get", &ffv1.ClustersGetRequest{}, &ffv1.ClustersGetResponse{}, nil,
+			respond(nil, nil, nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).ToNot(ContainSubstring("my-session"))
+		records := readRecords()
+		Expect(records[0]).To(HaveKeyWithValue("request_headers", map[string]any{
+			"cookie":    []any{"REDACTED"},
+			"my-header": []any{"my-value"},
+		}))
+	})
+
+	It("Doesn't modify the messages", func() {
+		response := &ffv1.ClustersGetPasswordResponse{}
+		err := interceptor.UnaryClient(
+			ctx, "/fulfillment.v1.Clusters/GetPassword", &ffv1.ClustersGetPasswordRequest{}, response, nil,
+			respond(
+				ffv1.ClustersGetPasswordResponse_builder{
+					Password: "my-password",
+				}.Build(),
+				nil,
+				nil,
+			),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.GetPassword()).To(Equal("my-password"))
+	})
+})
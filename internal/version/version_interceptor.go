@@ -17,10 +17,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -29,16 +34,22 @@ import (
 // InterceptorBuilder contains the data and logic needed to build an interceptor that adds version information to the
 // gRPC calls. Don't create instances of this type directly, use the NewInterceptor function instead.
 type InterceptorBuilder struct {
-	logger  *slog.Logger
-	product string
-	version string
+	logger   *slog.Logger
+	product  string
+	version  string
+	cacheDir string
+	writer   io.Writer
 }
 
 // Interceptor contains the data needed by the interceptor.
 type Interceptor struct {
-	logger  *slog.Logger
-	product string
-	version string
+	logger   *slog.Logger
+	product  string
+	version  string
+	cacheDir string
+	writer   io.Writer
+	lock     *sync.Mutex
+	checked  bool
 }
 
 // NewInterceptor creates a builder that can then be used to configure and create a interceptor.
@@ -64,6 +75,22 @@ func (b *InterceptorBuilder) SetVersion(value string) *InterceptorBuilder {
 	return b
 }
 
+// SetCacheDir sets the directory where the interceptor saves the time of the last warning about an unsupported server
+// version, so that it is displayed only once per day. This is optional, if not set the warning is displayed every time
+// that the tool runs.
+func (b *InterceptorBuilder) SetCacheDir(value string) *InterceptorBuilder {
+	b.cacheDir = value
+	return b
+}
+
+// SetWriter sets the writer where the interceptor writes the warning displayed when the version of the server isn't
+// supported, usually the standard error of the process. This is optional, if not set the warning is only written to
+// the log.
+func (b *InterceptorBuilder) SetWriter(value io.Writer) *InterceptorBuilder {
+	b.writer = value
+	return b
+}
+
 // defaultProduct calculates the default product name from the binary path.
 func (b *InterceptorBuilder) defaultProduct() string {
 	executable, err := os.Executable()
@@ -125,9 +152,12 @@ func (b *InterceptorBuilder) Build() (result *Interceptor, err error) {
 
 	// Create and populate the object:
 	result = &Interceptor{
-		logger:  b.logger,
-		product: product,
-		version: version,
+		logger:   b.logger,
+		product:  product,
+		version:  version,
+		cacheDir: b.cacheDir,
+		writer:   b.writer,
+		lock:     &sync.Mutex{},
 	}
 	return
 }
@@ -137,19 +167,152 @@ func (i *Interceptor) userAgentHeaderValue() string {
 	return fmt.Sprintf("%s/%s", i.product, i.version)
 }
 
-// UnaryClient is the unary client interceptor function that adds the version details.
+// UnaryClient is the unary client interceptor function that adds the version details, and checks the version of the
+// server sent in the response headers or trailers.
 func (i *Interceptor) UnaryClient(ctx context.Context, method string, request, response any,
 	conn *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	ctx = metadata.AppendToOutgoingContext(ctx, userAgentHeaderName, i.userAgentHeaderValue())
-	return invoker(ctx, method, request, response, conn, opts...)
+	var header, trailer metadata.MD
+	opts = append(slices.Clip(opts), grpc.Header(&header), grpc.Trailer(&trailer))
+	err := invoker(ctx, method, request, response, conn, opts...)
+	i.checkServer(ctx, header, trailer)
+	return err
 }
 
-// StreamClient is the stream client interceptor function that adds the user agent header.
+// StreamClient is the stream client interceptor function that adds the user agent header, and checks the version of
+// the server sent in the response headers or trailers.
 func (i *Interceptor) StreamClient(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string,
 	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 	ctx = metadata.AppendToOutgoingContext(ctx, userAgentHeaderName, i.userAgentHeaderValue())
-	return streamer(ctx, desc, conn, method, opts...)
+	stream, err := streamer(ctx, desc, conn, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &interceptorStream{
+		ClientStream: stream,
+		interceptor:  i,
+		ctx:          ctx,
+	}, nil
+}
+
+// checkServer checks the version of the server contained in the given headers or trailers. If it isn't inside the
+// supported range it writes a warning, but only the first time that the version is received and only if no other
+// warning has been written during the last day.
+func (i *Interceptor) checkServer(ctx context.Context, mds ...metadata.MD) {
+	server := ServerFromMetadata(mds...)
+	if server == "" {
+		return
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.checked {
+		return
+	}
+	i.checked = true
+	compatibility := CheckServer(server)
+	i.logger.DebugContext(
+		ctx,
+		"Checked server version",
+		slog.String("server", server),
+		slog.String("compatibility", string(compatibility)),
+	)
+	if compatibility != ServerOlder && compatibility != ServerNewer {
+		return
+	}
+	i.logger.WarnContext(
+		ctx,
+		"Server version isn't supported",
+		slog.String("server", server),
+		slog.String("min", MinServer),
+		slog.String("max", MaxServer),
+	)
+	if i.writer == nil || !i.warningDue(ctx) {
+		return
+	}
+	advice := "Consider upgrading the CLI."
+	if compatibility == ServerOlder {
+		advice = "Consider upgrading the server, or using an older version of the CLI."
+	}
+	fmt.Fprintf(
+		i.writer,
+		"Warning: The version of the server is %s, which is %s than the versions supported by this CLI (%s). "+
+			"%s\n",
+		server, compatibility, ServerRange(), advice,
+	)
+	i.saveWarning(ctx)
+}
+
+// warningDue checks if the last warning was written more than one day ago.
+func (i *Interceptor) warningDue(ctx context.Context) bool {
+	if i.cacheDir == "" {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(i.cacheDir, warningFileName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			i.logger.DebugContext(
+				ctx,
+				"Failed to read time of last server version warning",
+				slog.Any("error", err),
+			)
+		}
+		return true
+	}
+	last, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return true
+	}
+	return time.Since(last) >= warningInterval
+}
+
+// saveWarning saves the time of the last warning to the cache directory.
+func (i *Interceptor) saveWarning(ctx context.Context) {
+	if i.cacheDir == "" {
+		return
+	}
+	data := []byte(time.Now().UTC().Format(time.RFC3339) + "\n")
+	err := os.WriteFile(filepath.Join(i.cacheDir, warningFileName), data, 0600)
+	if err != nil {
+		i.logger.DebugContext(
+			ctx,
+			"Failed to save time of last server version warning",
+			slog.Any("error", err),
+		)
+	}
+}
+
+// interceptorStream wraps a client stream so that the version of the server can be checked when the first message,
+// or the end of the stream, is received.
+type interceptorStream struct {
+	grpc.ClientStream
+	interceptor *Interceptor
+	ctx         context.Context
+	received    bool
+}
+
+// RecvMsg is part of the implementation of the grpc.ClientStream interface.
+func (s *interceptorStream) RecvMsg(message any) error {
+	err := s.ClientStream.RecvMsg(message)
+	if s.received {
+		return err
+	}
+	s.received = true
+	if err == nil {
+		// The headers have already been received at this point, so this doesn't block:
+		header, _ := s.ClientStream.Header()
+		s.interceptor.checkServer(s.ctx, header)
+	} else {
+		s.interceptor.checkServer(s.ctx, s.ClientStream.Trailer())
+	}
+	return err
 }
 
 // userAgentHeaderName is the name of the user agent header.
 const userAgentHeaderName = "User-Agent"
+
+// warningFileName is the name of the file inside the cache directory that contains the time of the last warning about
+// an unsupported server version.
+const warningFileName = "server-version-warning"
+
+// warningInterval is the minimum time between two warnings about an unsupported server version.
+const warningInterval = 24 * time.Hour
@@ -14,9 +14,14 @@ language governing permissions and limitations under the License.
 package version
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2/dsl/core"
 	. "github.com/onsi/gomega"
@@ -178,5 +183,95 @@ var _ = Describe("Interceptor", func() {
 				Expect(err).To(MatchError("my error"))
 			})
 		})
+
+		Describe("Server version", func() {
+			var (
+				output   *bytes.Buffer
+				cacheDir string
+			)
+
+			// respond returns an invoker that sends the given server version in the response header.
+			respond := func(value string) grpc.UnaryInvoker {
+				return func(ctx context.Context, _ string, _ any, _ any, _ *grpc.ClientConn,
+					opts ...grpc.CallOption) error {
+					for _, opt := range opts {
+						headerOpt, ok := opt.(grpc.HeaderCallOption)
+						if ok {
+							*headerOpt.HeaderAddr = metadata.Pairs(ServerHeaderName, value)
+						}
+					}
+					return nil
+				}
+			}
+
+			// build creates a new interceptor that writes warnings to the output and uses the cache directory.
+			build := func() *Interceptor {
+				result, err := NewInterceptor().
+					SetLogger(logger).
+					SetCacheDir(cacheDir).
+					SetWriter(output).
+					Build()
+				Expect(err).ToNot(HaveOccurred())
+				return result
+			}
+
+			BeforeEach(func() {
+				output = &bytes.Buffer{}
+				cacheDir = GinkgoT().TempDir()
+			})
+
+			It("Doesn't warn if the server version is supported", func() {
+				err := build().UnaryClient(ctx, "", nil, nil, conn, respond("0.0.30"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.String()).To(BeEmpty())
+			})
+
+			It("Warns if the server is newer", func() {
+				err := build().UnaryClient(ctx, "", nil, nil, conn, respond("1.0.0"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.String()).To(ContainSubstring("The version of the server is 1.0.0"))
+				Expect(output.String()).To(ContainSubstring("newer"))
+				Expect(output.String()).To(ContainSubstring("upgrading the CLI"))
+			})
+
+			It("Warns if the server is older", func() {
+				err := build().UnaryClient(ctx, "", nil, nil, conn, respond("0.0.0"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.String()).To(ContainSubstring("older"))
+				Expect(output.String()).To(ContainSubstring("upgrading the server"))
+			})
+
+			It("Warns only once per interceptor", func() {
+				interceptor := build()
+				for range 3 {
+					err := interceptor.UnaryClient(ctx, "", nil, nil, conn, respond("1.0.0"))
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(strings.Count(output.String(), "Warning:")).To(Equal(1))
+			})
+
+			It("Warns only once per day", func() {
+				err := build().UnaryClient(ctx, "", nil, nil, conn, respond("1.0.0"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.String()).ToNot(BeEmpty())
+				output.Reset()
+				err = build().UnaryClient(ctx, "", nil, nil, conn, respond("1.0.0"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.String()).To(BeEmpty())
+			})
+
+			It("Warns again after one day", func() {
+				file := filepath.Join(cacheDir, warningFileName)
+				last := time.Now().Add(-25 * time.Hour).UTC().Format(time.RFC3339)
+				err := os.WriteFile(file, []byte(last), 0600)
+				Expect(err).ToNot(HaveOccurred())
+				err = build().UnaryClient(ctx, "", nil, nil, conn, respond("1.0.0"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.String()).ToNot(BeEmpty())
+				data, err := os.ReadFile(file)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).ToNot(ContainSubstring(last))
+			})
+		})
 	})
 })
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package version
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"google.golang.org/grpc/metadata"
+)
+
+// ServerHeaderName is the name of the header, or trailer, that the server uses to send its version.
+const ServerHeaderName = "x-fulfillment-api-version"
+
+// Range of server versions supported by this version of the CLI. The minimum is included and the maximum isn't. These
+// need to be updated when the CLI starts to depend on new features of the server, or when the server makes changes
+// that aren't backwards compatible.
+const (
+	MinServer = "0.0.1"
+	MaxServer = "0.1.0"
+)
+
+// Compatibility describes how the version of the server relates to the versions supported by the CLI.
+type Compatibility string
+
+const (
+	// ServerCompatible means that the version of the server is inside the supported range.
+	ServerCompatible Compatibility = "compatible"
+
+	// ServerOlder means that the version of the server is older than the minimum supported.
+	ServerOlder Compatibility = "older"
+
+	// ServerNewer means that the version of the server is newer than the maximum supported.
+	ServerNewer Compatibility = "newer"
+
+	// ServerUnknown means that the version of the server isn't known, or isn't a semantic version.
+	ServerUnknown Compatibility = "unknown"
+)
+
+// ServerFromMetadata returns the version of the server from the first of the given headers or trailers that contains
+// it, or an empty string if none contains it.
+func ServerFromMetadata(mds ...metadata.MD) string {
+	for _, md := range mds {
+		values := md.Get(ServerHeaderName)
+		if len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// CheckServer checks if the given version of the server is inside the range supported by the CLI.
+func CheckServer(value string) Compatibility {
+	if value == "" {
+		return ServerUnknown
+	}
+	parsed, err := semver.NewVersion(value)
+	if err != nil {
+		return ServerUnknown
+	}
+	switch {
+	case parsed.LessThan(minServer):
+		return ServerOlder
+	case !parsed.LessThan(maxServer):
+		return ServerNewer
+	default:
+		return ServerCompatible
+	}
+}
+
+// ServerRange returns a text describing the range of supported server versions, intended for messages displayed to
+// the user.
+func ServerRange() string {
+	return fmt.Sprintf("from %s to %s, not included", MinServer, MaxServer)
+}
+
+// Parsed versions of the limits of the supported range:
+var (
+	minServer = semver.MustParse(MinServer)
+	maxServer = semver.MustParse(MaxServer)
+)
@@ -0,0 +1,48 @@
+/*
+Copyright (c) 2025 Red Hat Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the
+License. You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the specific
+language governing permissions and limitations under the License.
+*/
+
+package version
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = Describe("Server version", func() {
+	DescribeTable(
+		"Checks compatibility",
+		func(value string, expected Compatibility) {
+			Expect(CheckServer(value)).To(Equal(expected))
+		},
+		Entry("Empty", "", ServerUnknown),
+		Entry("Not a semantic version", "a1b2c3d", ServerUnknown),
+		Entry("Minimum", MinServer, ServerCompatible),
+		Entry("Inside the range", "0.0.30", ServerCompatible),
+		Entry("With prefix", "v0.0.30", ServerCompatible),
+		Entry("Older", "0.0.0", ServerOlder),
+		Entry("Maximum", MaxServer, ServerNewer),
+		Entry("Newer", "1.0.0", ServerNewer),
+	)
+
+	It("Takes the version from the first metadata that contains it", func() {
+		header := metadata.Pairs("other", "value")
+		trailer := metadata.Pairs(ServerHeaderName, "0.0.30")
+		Expect(ServerFromMetadata(header, trailer)).To(Equal("0.0.30"))
+	})
+
+	It("Returns empty if no metadata contains the version", func() {
+		Expect(ServerFromMetadata(nil, metadata.Pairs("other", "value"))).To(BeEmpty())
+	})
+})
@@ -15,11 +15,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/innabox/fulfillment-cli/internal/cmd"
 	"github.com/innabox/fulfillment-cli/internal/exit"
+	"github.com/innabox/fulfillment-cli/internal/telemetry"
+	"github.com/innabox/fulfillment-cli/internal/timeout"
 )
 
 func main() {
@@ -28,14 +31,38 @@ func main() {
 
 	// Execute the main command:
 	root := cmd.Root()
-	err := root.ExecuteContext(ctx)
+	executed, err := root.ExecuteContextC(ctx)
+	telemetry.Finish(executed.Context(), err)
 	if err != nil {
 		exitErr, ok := err.(exit.Error)
 		if ok {
 			os.Exit(exitErr.Code())
-		} else {
-			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-			os.Exit(1)
 		}
+
+		// If the deadline was exceeded then we want to explain it and use the dedicated exit code. When the
+		// error was caused by a call to the server the message includes the name of the method, otherwise
+		// we report the timeout that was configured.
+		var timeoutErr *timeout.Error
+		if errors.As(err, &timeoutErr) {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", timeoutErr)
+			os.Exit(exit.Timeout.Code())
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			limit := timeout.FromContext(executed.Context())
+			if limit > 0 {
+				fmt.Fprintf(
+					os.Stderr,
+					"Error: command didn't complete within %s, use the '--timeout' flag to change the "+
+						"limit: %s\n",
+					limit, err,
+				)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			}
+			os.Exit(exit.Timeout.Code())
+		}
+
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
 	}
 }